@@ -0,0 +1,594 @@
+package grpcdynamic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+const (
+	connectUnaryProto  = "application/proto"
+	connectUnaryJSON   = "application/json"
+	connectStreamProto = "application/connect+proto"
+	connectStreamJSON  = "application/connect+json"
+
+	// Flags in the first byte of a Connect streaming envelope.
+	connectFlagCompressed = 0x01
+	connectFlagEndStream  = 0x02
+)
+
+// NewConnectHandler returns an HTTP handler that accepts requests that use the
+// Connect protocol (https://connectrpc.com/docs/protocol) for the methods of
+// the given service, and forwards them as gRPC calls to the given upstream
+// connection. This allows clients that speak the Connect protocol, including
+// simple HTTP clients that send JSON, to call gRPC servers.
+//
+// The handler serves paths of the form "/{service}/{method}", where {service}
+// is the fully-qualified name of the given service. The handler can be mounted
+// under a prefix using [http.StripPrefix]. Requests for other paths receive a
+// 404 "Not Found" response.
+//
+// Unary methods accept POST requests whose content type is "application/proto"
+// or "application/json". Streaming methods accept POST requests whose content
+// type is "application/connect+proto" or "application/connect+json". Requests
+// may be compressed with gzip, but responses are never compressed. GET requests
+// for unary methods are not supported.
+//
+// Request and response messages are dynamic messages. The given resolver is
+// used to resolve extensions and the contents of google.protobuf.Any messages
+// when marshaling and unmarshaling. If it is nil, [protoregistry.GlobalTypes] is
+// used.
+//
+// Request headers, other than those that are part of the Connect or HTTP
+// protocol, are sent upstream as request metadata. Response headers and
+// trailers from upstream are returned to the client as described by the Connect
+// protocol. Errors from upstream are translated into Connect errors, including
+// any error details.
+//
+// Request messages larger than 4 MiB are rejected with a "resource_exhausted"
+// error. Use [WithMaxMessageSize] to change this limit.
+func NewConnectHandler(sd protoreflect.ServiceDescriptor, resolver protoresolve.SerializationResolver, upstream grpc.ClientConnInterface, opts ...HandlerOption) http.Handler {
+	if resolver == nil {
+		resolver = protoregistry.GlobalTypes
+	}
+	return &connectHandler{svc: sd, res: resolver, upstream: upstream, opts: newHandlerOptions(opts)}
+}
+
+// HandlerOption is an option that can be used to customize behavior of the
+// HTTP handlers that proxy to a gRPC server: [NewConnectHandler],
+// [NewGRPCWebHandler], and [NewRESTTranscoder].
+type HandlerOption interface {
+	apply(*handlerOptions)
+}
+
+type handlerOptionFunc func(*handlerOptions)
+
+func (f handlerOptionFunc) apply(opts *handlerOptions) {
+	f(opts)
+}
+
+// defaultMaxMessageSize is the default limit on the size of request messages,
+// which is the same as the default limit used by gRPC servers.
+const defaultMaxMessageSize = 4 << 20
+
+type handlerOptions struct {
+	maxMessageSize int
+}
+
+func newHandlerOptions(opts []HandlerOption) handlerOptions {
+	ho := handlerOptions{maxMessageSize: defaultMaxMessageSize}
+	for _, opt := range opts {
+		opt.apply(&ho)
+	}
+	return ho
+}
+
+// WithMaxMessageSize returns a HandlerOption that limits the size of request
+// messages to the given number of bytes. For compressed messages, the limit
+// applies to both the compressed and the decompressed size. Requests with
+// larger messages are rejected with a ResourceExhausted error. If not
+// specified, the limit is 4 MiB.
+func WithMaxMessageSize(size int) HandlerOption {
+	return handlerOptionFunc(func(opts *handlerOptions) {
+		opts.maxMessageSize = size
+	})
+}
+
+type connectHandler struct {
+	svc      protoreflect.ServiceDescriptor
+	res      protoresolve.SerializationResolver
+	upstream grpc.ClientConnInterface
+	opts     handlerOptions
+}
+
+func (h *connectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	svcName, methodName, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !ok || protoreflect.FullName(svcName) != h.svc.FullName() {
+		http.NotFound(w, r)
+		return
+	}
+	method := h.svc.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	streaming := method.IsStreamingClient() || method.IsStreamingServer()
+	var codec connectCodec
+	switch {
+	case !streaming && contentType == connectUnaryProto, streaming && contentType == connectStreamProto:
+		codec = connectCodec{res: h.res}
+	case !streaming && contentType == connectUnaryJSON, streaming && contentType == connectStreamJSON:
+		codec = connectCodec{json: true, res: h.res}
+	default:
+		if streaming {
+			w.Header().Set("Accept-Post", connectStreamProto+", "+connectStreamJSON)
+		} else {
+			w.Header().Set("Accept-Post", connectUnaryProto+", "+connectUnaryJSON)
+		}
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	ctx := r.Context()
+	if timeout := r.Header.Get("Connect-Timeout-Ms"); timeout != "" {
+		millis, err := strconv.ParseInt(timeout, 10, 64)
+		if err != nil || millis < 0 {
+			h.writeUnaryError(w, status.Errorf(codes.InvalidArgument, "invalid timeout %q", timeout), nil, nil)
+			return
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(millis)*time.Millisecond)
+		defer cancel()
+	}
+	md, err := httpRequestMetadata(r.Header, connectProtocolHeaders)
+	if err != nil {
+		h.writeUnaryError(w, err, nil, nil)
+		return
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	fullMethod := requestMethod(method)
+	if streaming {
+		h.serveStream(ctx, w, r, method, fullMethod, codec, contentType)
+	} else {
+		h.serveUnary(ctx, w, r, method, fullMethod, codec, contentType)
+	}
+}
+
+func (h *connectHandler) serveUnary(ctx context.Context, w http.ResponseWriter, r *http.Request, method protoreflect.MethodDescriptor, fullMethod string, codec connectCodec, contentType string) {
+	body, err := readConnectBody(r.Body, r.Header.Get("Content-Encoding"), h.opts.maxMessageSize)
+	if err != nil {
+		h.writeUnaryError(w, err, nil, nil)
+		return
+	}
+	req := dynamicpb.NewMessage(method.Input())
+	if err := codec.unmarshal(body, req); err != nil {
+		h.writeUnaryError(w, status.Errorf(codes.InvalidArgument, "failed to unmarshal request: %v", err), nil, nil)
+		return
+	}
+	resp := dynamicpb.NewMessage(method.Output())
+	var header, trailer metadata.MD
+	err = h.upstream.Invoke(ctx, fullMethod, req, resp, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		h.writeUnaryError(w, err, header, trailer)
+		return
+	}
+	data, err := codec.marshal(resp)
+	if err != nil {
+		h.writeUnaryError(w, status.Errorf(codes.Internal, "failed to marshal response: %v", err), header, trailer)
+		return
+	}
+	addHTTPMetadata(w.Header(), header, "")
+	addHTTPMetadata(w.Header(), trailer, "Trailer-")
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(data)
+}
+
+func (h *connectHandler) writeUnaryError(w http.ResponseWriter, err error, header, trailer metadata.MD) {
+	addHTTPMetadata(w.Header(), header, "")
+	addHTTPMetadata(w.Header(), trailer, "Trailer-")
+	st := status.Convert(err)
+	data, _ := json.Marshal(newConnectError(st))
+	w.Header().Set("Content-Type", connectUnaryJSON)
+	w.WriteHeader(httpStatusFromCode(st.Code()))
+	_, _ = w.Write(data)
+}
+
+func (h *connectHandler) serveStream(ctx context.Context, w http.ResponseWriter, r *http.Request, method protoreflect.MethodDescriptor, fullMethod string, codec connectCodec, contentType string) {
+	// Streaming responses always use a 200 status; errors are reported in the
+	// final message of the stream.
+	w.Header().Set("Content-Type", contentType)
+	rc := http.NewResponseController(w)
+	if method.IsStreamingClient() && method.IsStreamingServer() {
+		// Bidi streams need to read requests while writing responses. HTTP/2
+		// connections are always full-duplex, so the error is ignored.
+		_ = rc.EnableFullDuplex()
+	}
+	encoding := r.Header.Get("Connect-Content-Encoding")
+	if encoding != "" && encoding != "identity" && encoding != "gzip" {
+		h.writeEndStream(w, status.Errorf(codes.Unimplemented, "unsupported compression %q", encoding), nil)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	desc := &grpc.StreamDesc{
+		StreamName:    string(method.Name()),
+		ServerStreams: method.IsStreamingServer(),
+		ClientStreams: method.IsStreamingClient(),
+	}
+	cs, err := h.upstream.NewStream(ctx, desc, fullMethod)
+	if err != nil {
+		h.writeEndStream(w, err, nil)
+		return
+	}
+
+	// forward requests upstream in the background
+	reqErrs := make(chan error, 1)
+	go func() {
+		err := forwardConnectRequests(method.Input(), codec, r.Body, encoding, h.opts.maxMessageSize, cs)
+		if err != nil {
+			// abort the upstream call
+			cancel()
+		}
+		reqErrs <- err
+	}()
+
+	sentHeaders := false
+	for {
+		resp := dynamicpb.NewMessage(method.Output())
+		err := cs.RecvMsg(resp)
+		if !sentHeaders {
+			// Headers are available once we receive the first message
+			// or the end of the stream.
+			if md, headerErr := cs.Header(); headerErr == nil {
+				addHTTPMetadata(w.Header(), md, "")
+			}
+			w.WriteHeader(http.StatusOK)
+			sentHeaders = true
+		}
+		if err == io.EOF {
+			h.writeEndStream(w, nil, cs.Trailer())
+			return
+		} else if err != nil {
+			if ctx.Err() != nil && r.Context().Err() == nil {
+				// upstream call was aborted due to an error forwarding requests
+				if reqErr := <-reqErrs; reqErr != nil {
+					err = reqErr
+				}
+			}
+			h.writeEndStream(w, err, cs.Trailer())
+			return
+		}
+		data, err := codec.marshal(resp)
+		if err != nil {
+			h.writeEndStream(w, status.Errorf(codes.Internal, "failed to marshal response: %v", err), cs.Trailer())
+			return
+		}
+		if err := writeConnectEnvelope(w, 0, data); err != nil {
+			return
+		}
+		_ = rc.Flush()
+	}
+}
+
+func forwardConnectRequests(reqType protoreflect.MessageDescriptor, codec connectCodec, body io.Reader, encoding string, maxSize int, cs grpc.ClientStream) error {
+	for {
+		flags, data, err := readConnectEnvelope(body, maxSize)
+		if err == io.EOF {
+			return cs.CloseSend()
+		} else if err != nil {
+			return err
+		}
+		if flags&connectFlagCompressed != 0 {
+			if encoding == "" || encoding == "identity" {
+				return status.Error(codes.InvalidArgument, "received compressed message without Connect-Content-Encoding")
+			}
+			if data, err = gunzip(data, maxSize); err != nil {
+				return err
+			}
+		}
+		req := dynamicpb.NewMessage(reqType)
+		if err := codec.unmarshal(data, req); err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to unmarshal request: %v", err)
+		}
+		if err := cs.SendMsg(req); err == io.EOF {
+			// Upstream stream is done. The actual status will
+			// be reported from cs.RecvMsg.
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (h *connectHandler) writeEndStream(w http.ResponseWriter, err error, trailer metadata.MD) {
+	var end struct {
+		Error    *connectError       `json:"error,omitempty"`
+		Metadata map[string][]string `json:"metadata,omitempty"`
+	}
+	if err != nil {
+		end.Error = newConnectError(status.Convert(err))
+	}
+	if len(trailer) > 0 {
+		hdrs := http.Header{}
+		addHTTPMetadata(hdrs, trailer, "")
+		end.Metadata = hdrs
+	}
+	data, _ := json.Marshal(&end)
+	_ = writeConnectEnvelope(w, connectFlagEndStream, data)
+}
+
+func readConnectEnvelope(r io.Reader, maxSize int) (byte, []byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err == io.EOF {
+		return 0, nil, io.EOF
+	} else if err != nil {
+		return 0, nil, status.Errorf(codes.InvalidArgument, "failed to read message: %v", err)
+	}
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if uint64(size) > uint64(maxSize) {
+		return 0, nil, status.Errorf(codes.ResourceExhausted, "message size %d exceeds maximum %d", size, maxSize)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, status.Errorf(codes.InvalidArgument, "failed to read message: %v", err)
+	}
+	return prefix[0], data, nil
+}
+
+func writeConnectEnvelope(w io.Writer, flags byte, data []byte) error {
+	var prefix [5]byte
+	prefix[0] = flags
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+	if _, err := w.Write(prefix[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func readConnectBody(body io.Reader, encoding string, maxSize int) ([]byte, error) {
+	data, err := readLimited(body, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	switch encoding {
+	case "", "identity":
+		return data, nil
+	case "gzip":
+		return gunzip(data, maxSize)
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "unsupported compression %q", encoding)
+	}
+}
+
+// readLimited reads all of r, failing with a ResourceExhausted error if it
+// contains more than maxSize bytes.
+func readLimited(r io.Reader, maxSize int) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to read request: %v", err)
+	}
+	if len(data) > maxSize {
+		return nil, status.Errorf(codes.ResourceExhausted, "message size exceeds maximum %d", maxSize)
+	}
+	return data, nil
+}
+
+// gunzip decompresses the given data. The decompressed size is limited to
+// maxSize bytes.
+func gunzip(data []byte, maxSize int) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to decompress request: %v", err)
+	}
+	data, err = readLimited(zr, maxSize)
+	if status.Code(err) == codes.InvalidArgument {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to decompress request: %v", status.Convert(err).Message())
+	}
+	return data, err
+}
+
+type connectCodec struct {
+	json bool
+	res  protoresolve.SerializationResolver
+}
+
+func (c connectCodec) marshal(msg proto.Message) ([]byte, error) {
+	if c.json {
+		return protojson.MarshalOptions{Resolver: c.res}.Marshal(msg)
+	}
+	return proto.Marshal(msg)
+}
+
+func (c connectCodec) unmarshal(data []byte, msg proto.Message) error {
+	if c.json {
+		return protojson.UnmarshalOptions{Resolver: c.res}.Unmarshal(data, msg)
+	}
+	return proto.UnmarshalOptions{Resolver: c.res}.Unmarshal(data, msg)
+}
+
+// connectError is the JSON representation of an error in the Connect protocol.
+type connectError struct {
+	Code    string               `json:"code"`
+	Message string               `json:"message,omitempty"`
+	Details []connectErrorDetail `json:"details,omitempty"`
+}
+
+type connectErrorDetail struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func newConnectError(st *status.Status) *connectError {
+	connectErr := &connectError{
+		Code:    connectCodeNames[st.Code()],
+		Message: st.Message(),
+	}
+	if connectErr.Code == "" {
+		connectErr.Code = connectCodeNames[codes.Unknown]
+	}
+	for _, detail := range st.Proto().GetDetails() {
+		connectErr.Details = append(connectErr.Details, connectErrorDetail{
+			Type:  string(protoresolve.TypeNameFromURL(detail.GetTypeUrl())),
+			Value: base64.RawStdEncoding.EncodeToString(detail.GetValue()),
+		})
+	}
+	return connectErr
+}
+
+var connectCodeNames = map[codes.Code]string{
+	codes.Canceled:           "canceled",
+	codes.Unknown:            "unknown",
+	codes.InvalidArgument:    "invalid_argument",
+	codes.DeadlineExceeded:   "deadline_exceeded",
+	codes.NotFound:           "not_found",
+	codes.AlreadyExists:      "already_exists",
+	codes.PermissionDenied:   "permission_denied",
+	codes.ResourceExhausted:  "resource_exhausted",
+	codes.FailedPrecondition: "failed_precondition",
+	codes.Aborted:            "aborted",
+	codes.OutOfRange:         "out_of_range",
+	codes.Unimplemented:      "unimplemented",
+	codes.Internal:           "internal",
+	codes.Unavailable:        "unavailable",
+	codes.DataLoss:           "data_loss",
+	codes.Unauthenticated:    "unauthenticated",
+}
+
+// httpStatusFromCode returns the HTTP status code used for responses that
+// fail with the given code. This is the mapping defined by the Connect protocol,
+// which is also used by gRPC transcoding.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// httpProtocolHeaders are request headers that are part of the HTTP protocol,
+// so are not sent upstream as request metadata.
+var httpProtocolHeaders = map[string]struct{}{
+	"accept":            {},
+	"accept-encoding":   {},
+	"connection":        {},
+	"content-encoding":  {},
+	"content-length":    {},
+	"content-type":      {},
+	"host":              {},
+	"keep-alive":        {},
+	"te":                {},
+	"trailer":           {},
+	"transfer-encoding": {},
+	"upgrade":           {},
+	"user-agent":        {},
+}
+
+// connectProtocolHeaders are request headers that are part of the Connect
+// protocol, so are not sent upstream as request metadata.
+var connectProtocolHeaders = map[string]struct{}{
+	"connect-protocol-version": {},
+	"connect-timeout-ms":       {},
+	"connect-content-encoding": {},
+	"connect-accept-encoding":  {},
+}
+
+// httpRequestMetadata returns the request metadata to send upstream for the
+// given HTTP request headers. Headers that are part of the HTTP protocol, gRPC
+// protocol, or in the given set of protocol headers are excluded.
+func httpRequestMetadata(hdrs http.Header, protocolHeaders map[string]struct{}) (metadata.MD, error) {
+	md := metadata.MD{}
+	for key, vals := range hdrs {
+		key = strings.ToLower(key)
+		if _, ok := httpProtocolHeaders[key]; ok || strings.HasPrefix(key, "grpc-") {
+			continue
+		}
+		if _, ok := protocolHeaders[key]; ok {
+			continue
+		}
+		if strings.HasSuffix(key, "-bin") {
+			// Binary values are base64-encoded in HTTP headers, but
+			// are raw bytes in gRPC metadata.
+			for _, val := range vals {
+				decoded, err := decodeBinaryHeader(val)
+				if err != nil {
+					return nil, status.Errorf(codes.InvalidArgument, "invalid value for binary header %s: %v", key, err)
+				}
+				md.Append(key, string(decoded))
+			}
+			continue
+		}
+		md.Append(key, vals...)
+	}
+	return md, nil
+}
+
+func decodeBinaryHeader(val string) ([]byte, error) {
+	// Both padded and unpadded values are allowed.
+	if len(val)%4 == 0 {
+		return base64.StdEncoding.DecodeString(val)
+	}
+	return base64.RawStdEncoding.DecodeString(val)
+}
+
+func addHTTPMetadata(hdrs http.Header, md metadata.MD, prefix string) {
+	for key, vals := range md {
+		if strings.HasSuffix(key, "-bin") {
+			for _, val := range vals {
+				hdrs.Add(prefix+key, base64.RawStdEncoding.EncodeToString([]byte(val)))
+			}
+			continue
+		}
+		for _, val := range vals {
+			hdrs.Add(prefix+key, val)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package grpcdynamic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	grpctestprotos "github.com/jhump/protoreflect/v2/internal/testprotos/grpc"
+)
+
+func newConnectServer(t *testing.T, opts ...HandlerOption) *httptest.Server {
+	t.Helper()
+	upstreamSvr := grpc.NewServer()
+	grpctestprotos.RegisterTestServiceServer(upstreamSvr, metadataEchoService{})
+	upstream := serve(t, upstreamSvr)
+	sd := unaryMd.Parent().(protoreflect.ServiceDescriptor)
+	svr := httptest.NewServer(NewConnectHandler(sd, nil, upstream, opts...))
+	t.Cleanup(svr.Close)
+	return svr
+}
+
+func connectPost(t *testing.T, url, contentType string, body []byte, hdrs ...string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	for i := 0; i < len(hdrs); i += 2 {
+		req.Header.Add(hdrs[i], hdrs[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = resp.Body.Close()
+	})
+	return resp
+}
+
+func connectEnvelopes(t *testing.T, msgs ...proto.Message) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, msg := range msgs {
+		data, err := proto.Marshal(msg)
+		require.NoError(t, err)
+		require.NoError(t, writeConnectEnvelope(&buf, 0, data))
+	}
+	return buf.Bytes()
+}
+
+// readConnectStream reads all envelopes in a streaming response, returning the
+// message payloads and the contents of the final end-stream envelope.
+func readConnectStream(t *testing.T, r io.Reader) ([][]byte, map[string]any) {
+	t.Helper()
+	var msgs [][]byte
+	for {
+		flags, data, err := readConnectEnvelope(r, defaultMaxMessageSize)
+		require.NoError(t, err)
+		if flags&connectFlagEndStream != 0 {
+			var end map[string]any
+			require.NoError(t, json.Unmarshal(data, &end))
+			_, _, err = readConnectEnvelope(r, defaultMaxMessageSize)
+			require.Equal(t, io.EOF, err)
+			return msgs, end
+		}
+		msgs = append(msgs, data)
+	}
+}
+
+func TestConnectHandler_Unary(t *testing.T) {
+	svr := newConnectServer(t)
+	url := svr.URL + "/grpc.testing.TestService/UnaryCall"
+
+	// binary
+	reqData, err := proto.Marshal(&grpctestprotos.SimpleRequest{Payload: payload})
+	require.NoError(t, err)
+	resp := connectPost(t, url, "application/proto", reqData, "X-Test", "abc")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/proto", resp.Header.Get("Content-Type"))
+	require.Equal(t, "abc", resp.Header.Get("X-Header"))
+	require.Equal(t, "abc", resp.Header.Get("Trailer-X-Trailer"))
+	respData, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var respMsg grpctestprotos.SimpleResponse
+	require.NoError(t, proto.Unmarshal(respData, &respMsg))
+	require.True(t, proto.Equal(&grpctestprotos.SimpleResponse{Payload: payload}, &respMsg))
+
+	// JSON
+	reqData, err = protojson.Marshal(&grpctestprotos.SimpleRequest{Payload: payload})
+	require.NoError(t, err)
+	resp = connectPost(t, url, "application/json; charset=utf-8", reqData, "X-Test", "abc")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	respData, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	respMsg.Reset()
+	require.NoError(t, protojson.Unmarshal(respData, &respMsg))
+	require.True(t, proto.Equal(&grpctestprotos.SimpleResponse{Payload: payload}, &respMsg))
+
+	// errors from upstream
+	resp = connectPost(t, url, "application/json", reqData, "X-Test", "abc", "X-Fail", "oops")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Equal(t, "abc", resp.Header.Get("Trailer-X-Trailer"))
+	var connectErr map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&connectErr))
+	require.Equal(t, map[string]any{"code": "failed_precondition", "message": "oops"}, connectErr)
+
+	// malformed request
+	resp = connectPost(t, url, "application/json", []byte(`{"payload": 123}`))
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	connectErr = nil
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&connectErr))
+	require.Equal(t, "invalid_argument", connectErr["code"])
+}
+
+func TestConnectHandler_Streams(t *testing.T) {
+	svr := newConnectServer(t)
+
+	// server stream
+	reqData := connectEnvelopes(t, &grpctestprotos.StreamingOutputCallRequest{
+		Payload:            payload,
+		ResponseParameters: []*grpctestprotos.ResponseParameters{{}, {}, {}},
+	})
+	resp := connectPost(t, svr.URL+"/grpc.testing.TestService/StreamingOutputCall", "application/connect+proto", reqData)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/connect+proto", resp.Header.Get("Content-Type"))
+	msgs, end := readConnectStream(t, resp.Body)
+	require.Len(t, msgs, 3)
+	for _, data := range msgs {
+		var msg grpctestprotos.StreamingOutputCallResponse
+		require.NoError(t, proto.Unmarshal(data, &msg))
+		require.True(t, proto.Equal(payload, msg.Payload))
+	}
+	require.Empty(t, end)
+
+	// client stream
+	req := &grpctestprotos.StreamingInputCallRequest{Payload: payload}
+	reqData = connectEnvelopes(t, req, req, req)
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/StreamingInputCall", "application/connect+proto", reqData)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	msgs, end = readConnectStream(t, resp.Body)
+	require.Len(t, msgs, 1)
+	var inputResp grpctestprotos.StreamingInputCallResponse
+	require.NoError(t, proto.Unmarshal(msgs[0], &inputResp))
+	require.Equal(t, int32(3*len(payload.Body)), inputResp.AggregatedPayloadSize)
+	require.Empty(t, end)
+
+	// bidi stream, using JSON
+	var buf bytes.Buffer
+	for i := 0; i < 2; i++ {
+		data, err := protojson.Marshal(&grpctestprotos.StreamingOutputCallRequest{Payload: payload})
+		require.NoError(t, err)
+		require.NoError(t, writeConnectEnvelope(&buf, 0, data))
+	}
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/FullDuplexCall", "application/connect+json", buf.Bytes())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/connect+json", resp.Header.Get("Content-Type"))
+	msgs, end = readConnectStream(t, resp.Body)
+	require.Len(t, msgs, 2)
+	for _, data := range msgs {
+		var msg grpctestprotos.StreamingOutputCallResponse
+		require.NoError(t, protojson.Unmarshal(data, &msg))
+		require.True(t, proto.Equal(payload, msg.Payload))
+	}
+	require.Empty(t, end)
+
+	// errors are reported in the end-stream message
+	var bad bytes.Buffer
+	require.NoError(t, writeConnectEnvelope(&bad, 0, []byte{0xff}))
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/FullDuplexCall", "application/connect+proto", bad.Bytes())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	msgs, end = readConnectStream(t, resp.Body)
+	require.Empty(t, msgs)
+	require.Equal(t, "invalid_argument", end["error"].(map[string]any)["code"])
+}
+
+func TestConnectHandler_BadRequests(t *testing.T) {
+	svr := newConnectServer(t)
+
+	resp := connectPost(t, svr.URL+"/grpc.testing.TestService/NoSuchMethod", "application/proto", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = connectPost(t, svr.URL+"/foo.Bar/UnaryCall", "application/proto", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/UnaryCall", "text/plain", nil)
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	// streaming content type is not allowed for unary methods
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/UnaryCall", "application/connect+proto", nil)
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/FullDuplexCall", "application/proto", nil)
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	resp, err := http.Get(svr.URL + "/grpc.testing.TestService/UnaryCall")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestConnectHandler_MessageSizeLimit(t *testing.T) {
+	svr := newConnectServer(t, WithMaxMessageSize(100))
+
+	// unary request body that is too large
+	reqData, err := proto.Marshal(&grpctestprotos.SimpleRequest{Payload: &grpctestprotos.Payload{Body: make([]byte, 100)}})
+	require.NoError(t, err)
+	resp := connectPost(t, svr.URL+"/grpc.testing.TestService/UnaryCall", "application/proto", reqData)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	var connectErr map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&connectErr))
+	require.Equal(t, "resource_exhausted", connectErr["code"])
+
+	// compressed request that is small, but too large once decompressed
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err = zw.Write(reqData)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.Less(t, buf.Len(), 100)
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/UnaryCall", "application/proto", buf.Bytes(), "Content-Encoding", "gzip")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// streaming request whose length prefix is too large; the handler must
+	// reject it without trying to read (or allocate) the message
+	reqData = []byte{0, 0xff, 0xff, 0xff, 0xff}
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/FullDuplexCall", "application/connect+proto", reqData)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	msgs, end := readConnectStream(t, resp.Body)
+	require.Empty(t, msgs)
+	require.Equal(t, "resource_exhausted", end["error"].(map[string]any)["code"])
+}
+
+func TestNewConnectError(t *testing.T) {
+	st, err := status.New(codes.ResourceExhausted, "slow down").WithDetails(wrapperspb.String("abc"))
+	require.NoError(t, err)
+	data, err := json.Marshal(newConnectError(st))
+	require.NoError(t, err)
+	var connectErr struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details []struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		} `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(data, &connectErr))
+	require.Equal(t, "resource_exhausted", connectErr.Code)
+	require.Equal(t, "slow down", connectErr.Message)
+	require.Len(t, connectErr.Details, 1)
+	require.Equal(t, "google.protobuf.StringValue", connectErr.Details[0].Type)
+	// unpadded base64
+	require.Equal(t, "CgNhYmM", connectErr.Details[0].Value)
+	require.Equal(t, http.StatusTooManyRequests, httpStatusFromCode(st.Code()))
+}
@@ -0,0 +1,375 @@
+package grpcdynamic
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+const (
+	grpcWebContentType     = "application/grpc-web"
+	grpcWebTextContentType = "application/grpc-web-text"
+
+	// Flags in the first byte of a gRPC-Web frame.
+	grpcWebFlagCompressed = 0x01
+	grpcWebFlagTrailer    = 0x80
+)
+
+// NewGRPCWebHandler returns an HTTP handler that accepts requests that use the
+// gRPC-Web protocol (https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md)
+// for the methods of the given service, and forwards them as gRPC calls to the
+// given upstream connection. This allows browser clients, which cannot use
+// HTTP/2 trailers and so cannot speak the gRPC protocol, to call gRPC servers.
+//
+// The handler serves paths of the form "/{service}/{method}", where {service}
+// is the fully-qualified name of the given service. Requests for other paths
+// receive a 404 "Not Found" response. Only POST requests whose content type is
+// "application/grpc-web" or "application/grpc-web-text" (with an optional
+// "+proto" suffix) are accepted. In the latter case, request and response bodies
+// are base64-encoded. Request messages may be compressed with gzip, but
+// responses are never compressed. The handler does not handle CORS requests: if
+// browser clients are served from another origin, the handler should be wrapped
+// with a handler that does.
+//
+// Request and response messages are dynamic messages. The given resolver is
+// used to resolve extensions when unmarshaling. If it is nil,
+// [protoregistry.GlobalTypes] is used.
+//
+// Request headers, other than those that are part of the HTTP or gRPC-Web
+// protocols, are sent upstream as request metadata. Response headers from
+// upstream are returned as HTTP response headers, and the final status and
+// response trailers are sent to the client in a trailer frame at the end of the
+// response body.
+//
+// Request messages larger than 4 MiB are rejected with a ResourceExhausted
+// error. Use [WithMaxMessageSize] to change this limit. Since the body of a
+// "application/grpc-web-text" request must be decoded all at once, such a
+// request may contain only a single message of the maximum size.
+func NewGRPCWebHandler(sd protoreflect.ServiceDescriptor, upstream grpc.ClientConnInterface, resolver protoresolve.SerializationResolver, opts ...HandlerOption) http.Handler {
+	if resolver == nil {
+		resolver = protoregistry.GlobalTypes
+	}
+	return &grpcWebHandler{svc: sd, res: resolver, upstream: upstream, opts: newHandlerOptions(opts)}
+}
+
+type grpcWebHandler struct {
+	svc      protoreflect.ServiceDescriptor
+	res      protoresolve.SerializationResolver
+	upstream grpc.ClientConnInterface
+	opts     handlerOptions
+}
+
+func (h *grpcWebHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	svcName, methodName, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !ok || protoreflect.FullName(svcName) != h.svc.FullName() {
+		http.NotFound(w, r)
+		return
+	}
+	method := h.svc.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var text bool
+	switch strings.TrimSuffix(contentType, "+proto") {
+	case grpcWebContentType:
+	case grpcWebTextContentType:
+		text = true
+	default:
+		w.Header().Set("Accept-Post", grpcWebContentType+", "+grpcWebTextContentType)
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// From here on, the response is always a 200 status; errors are reported
+	// in the trailer frame.
+	w.Header().Set("Content-Type", contentType)
+	var rw io.Writer = w
+	var body io.Reader = r.Body
+	if text {
+		rw = base64FrameWriter{w: w}
+		// Each chunk of the body is base64-encoded separately, so padding can
+		// appear in the middle of it. So we read and decode it all up front,
+		// allowing enough room for one frame with a message of the maximum size.
+		data, err := readLimited(r.Body, base64.StdEncoding.EncodedLen(h.opts.maxMessageSize+5))
+		if err != nil {
+			h.writeTrailer(w, rw, err, nil)
+			return
+		}
+		data, err = decodeGRPCWebText(data)
+		if err != nil {
+			h.writeTrailer(w, rw, err, nil)
+			return
+		}
+		body = bytes.NewReader(data)
+	}
+	encoding := r.Header.Get("Grpc-Encoding")
+	if encoding != "" && encoding != "identity" && encoding != "gzip" {
+		h.writeTrailer(w, rw, status.Errorf(codes.Unimplemented, "unsupported compression %q", encoding), nil)
+		return
+	}
+
+	ctx := r.Context()
+	if timeout := r.Header.Get("Grpc-Timeout"); timeout != "" {
+		d, err := parseGRPCTimeout(timeout)
+		if err != nil {
+			h.writeTrailer(w, rw, err, nil)
+			return
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	md, err := httpRequestMetadata(r.Header, grpcWebProtocolHeaders)
+	if err != nil {
+		h.writeTrailer(w, rw, err, nil)
+		return
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	if method.IsStreamingClient() && method.IsStreamingServer() {
+		// Bidi streams need to read requests while writing responses. HTTP/2
+		// connections are always full-duplex, so the error is ignored.
+		_ = rc.EnableFullDuplex()
+	}
+	desc := &grpc.StreamDesc{
+		StreamName:    string(method.Name()),
+		ServerStreams: method.IsStreamingServer(),
+		ClientStreams: method.IsStreamingClient(),
+	}
+	cs, err := h.upstream.NewStream(ctx, desc, requestMethod(method))
+	if err != nil {
+		h.writeTrailer(w, rw, err, nil)
+		return
+	}
+
+	// forward requests upstream in the background
+	reqErrs := make(chan error, 1)
+	go func() {
+		err := h.forwardRequests(method.Input(), body, encoding, cs)
+		if err != nil {
+			// abort the upstream call
+			cancel()
+		}
+		reqErrs <- err
+	}()
+
+	sentHeaders := false
+	for {
+		resp := dynamicpb.NewMessage(method.Output())
+		err := cs.RecvMsg(resp)
+		if !sentHeaders {
+			// Headers are available once we receive the first message
+			// or the end of the stream.
+			if md, headerErr := cs.Header(); headerErr == nil {
+				addHTTPMetadata(w.Header(), md, "")
+			}
+			w.WriteHeader(http.StatusOK)
+			sentHeaders = true
+		}
+		if err == io.EOF {
+			h.writeTrailer(w, rw, nil, cs.Trailer())
+			return
+		} else if err != nil {
+			if ctx.Err() != nil && r.Context().Err() == nil {
+				// upstream call was aborted due to an error forwarding requests
+				if reqErr := <-reqErrs; reqErr != nil {
+					err = reqErr
+				}
+			}
+			h.writeTrailer(w, rw, err, cs.Trailer())
+			return
+		}
+		data, err := proto.Marshal(resp)
+		if err != nil {
+			h.writeTrailer(w, rw, status.Errorf(codes.Internal, "failed to marshal response: %v", err), cs.Trailer())
+			return
+		}
+		if err := writeGRPCWebFrame(rw, 0, data); err != nil {
+			return
+		}
+		_ = rc.Flush()
+	}
+}
+
+func (h *grpcWebHandler) forwardRequests(reqType protoreflect.MessageDescriptor, body io.Reader, encoding string, cs grpc.ClientStream) error {
+	for {
+		// gRPC-Web frames have the same shape as Connect envelopes
+		flags, data, err := readConnectEnvelope(body, h.opts.maxMessageSize)
+		if err == io.EOF {
+			return cs.CloseSend()
+		} else if err != nil {
+			return err
+		}
+		if flags&grpcWebFlagTrailer != 0 {
+			return status.Error(codes.InvalidArgument, "request must not contain trailer frame")
+		}
+		if flags&grpcWebFlagCompressed != 0 {
+			if encoding == "" || encoding == "identity" {
+				return status.Error(codes.InvalidArgument, "received compressed message without grpc-encoding")
+			}
+			if data, err = gunzip(data, h.opts.maxMessageSize); err != nil {
+				return err
+			}
+		}
+		req := dynamicpb.NewMessage(reqType)
+		if err := (proto.UnmarshalOptions{Resolver: h.res}).Unmarshal(data, req); err != nil {
+			return status.Errorf(codes.InvalidArgument, "failed to unmarshal request: %v", err)
+		}
+		if err := cs.SendMsg(req); err == io.EOF {
+			// Upstream stream is done. The actual status will
+			// be reported from cs.RecvMsg.
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// writeTrailer writes the final frame of the response, which contains the
+// status of the call and any response trailers.
+func (h *grpcWebHandler) writeTrailer(w http.ResponseWriter, rw io.Writer, err error, trailer metadata.MD) {
+	st := status.Convert(err)
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "grpc-status: %d\r\n", st.Code())
+	if st.Message() != "" {
+		_, _ = fmt.Fprintf(&buf, "grpc-message: %s\r\n", encodeGRPCMessage(st.Message()))
+	}
+	if len(st.Proto().GetDetails()) > 0 {
+		if data, err := proto.Marshal(st.Proto()); err == nil {
+			_, _ = fmt.Fprintf(&buf, "grpc-status-details-bin: %s\r\n", base64.StdEncoding.EncodeToString(data))
+		}
+	}
+	for key, vals := range trailer {
+		for _, val := range vals {
+			if strings.HasSuffix(key, "-bin") {
+				val = base64.StdEncoding.EncodeToString([]byte(val))
+			}
+			_, _ = fmt.Fprintf(&buf, "%s: %s\r\n", strings.ToLower(key), val)
+		}
+	}
+	_ = writeGRPCWebFrame(rw, grpcWebFlagTrailer, buf.Bytes())
+	_ = http.NewResponseController(w).Flush()
+}
+
+func writeGRPCWebFrame(w io.Writer, flags byte, data []byte) error {
+	var frame bytes.Buffer
+	// gRPC-Web frames have the same shape as Connect envelopes
+	_ = writeConnectEnvelope(&frame, flags, data)
+	// Write the frame all at once, so that each frame is encoded as its
+	// own base64 chunk in text mode.
+	_, err := w.Write(frame.Bytes())
+	return err
+}
+
+// base64FrameWriter base64-encodes each call to Write separately, for responses
+// that use the "application/grpc-web-text" content type.
+type base64FrameWriter struct {
+	w io.Writer
+}
+
+func (w base64FrameWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, base64.StdEncoding.EncodeToString(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// decodeGRPCWebText decodes a request body that uses the
+// "application/grpc-web-text" content type. The body may consist of multiple
+// base64-encoded chunks concatenated together, each with its own padding.
+func decodeGRPCWebText(data []byte) ([]byte, error) {
+	data = bytes.Join(bytes.Fields(data), nil)
+	if len(data)%4 != 0 {
+		return nil, status.Error(codes.InvalidArgument, "failed to decode request: base64 data has invalid length")
+	}
+	decoded := make([]byte, 0, base64.StdEncoding.DecodedLen(len(data)))
+	var buf [3]byte
+	for i := 0; i < len(data); i += 4 {
+		n, err := base64.StdEncoding.Decode(buf[:], data[i:i+4])
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to decode request: %v", err)
+		}
+		decoded = append(decoded, buf[:n]...)
+	}
+	return decoded, nil
+}
+
+// parseGRPCTimeout parses the value of a "grpc-timeout" header, which is a
+// positive integer with at most 8 digits followed by a unit.
+func parseGRPCTimeout(timeout string) (time.Duration, error) {
+	if len(timeout) < 2 || len(timeout) > 9 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid timeout %q", timeout)
+	}
+	var unit time.Duration
+	switch timeout[len(timeout)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, status.Errorf(codes.InvalidArgument, "invalid timeout %q", timeout)
+	}
+	val, err := strconv.ParseUint(timeout[:len(timeout)-1], 10, 64)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid timeout %q", timeout)
+	}
+	return time.Duration(val) * unit, nil
+}
+
+// encodeGRPCMessage percent-encodes the given status message, as required for
+// the value of the "grpc-message" trailer.
+func encodeGRPCMessage(msg string) string {
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c < ' ' || c > '~' || c == '%' {
+			_, _ = fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// grpcWebProtocolHeaders are request headers that are part of the gRPC-Web
+// protocol, so are not sent upstream as request metadata.
+var grpcWebProtocolHeaders = map[string]struct{}{
+	"x-grpc-web":   {},
+	"x-user-agent": {},
+}
@@ -0,0 +1,178 @@
+package grpcdynamic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	grpctestprotos "github.com/jhump/protoreflect/v2/internal/testprotos/grpc"
+)
+
+func newGRPCWebServer(t *testing.T, opts ...HandlerOption) *httptest.Server {
+	t.Helper()
+	upstreamSvr := grpc.NewServer()
+	grpctestprotos.RegisterTestServiceServer(upstreamSvr, metadataEchoService{})
+	upstream := serve(t, upstreamSvr)
+	sd := unaryMd.Parent().(protoreflect.ServiceDescriptor)
+	svr := httptest.NewServer(NewGRPCWebHandler(sd, upstream, nil, opts...))
+	t.Cleanup(svr.Close)
+	return svr
+}
+
+// readGRPCWebResponse reads all frames in a response body, returning the
+// message payloads and the contents of the final trailer frame.
+func readGRPCWebResponse(t *testing.T, r io.Reader) ([][]byte, string) {
+	t.Helper()
+	var msgs [][]byte
+	for {
+		flags, data, err := readConnectEnvelope(r, defaultMaxMessageSize)
+		require.NoError(t, err)
+		if flags&grpcWebFlagTrailer != 0 {
+			_, _, err = readConnectEnvelope(r, defaultMaxMessageSize)
+			require.Equal(t, io.EOF, err)
+			return msgs, string(data)
+		}
+		msgs = append(msgs, data)
+	}
+}
+
+func TestGRPCWebHandler_Unary(t *testing.T) {
+	svr := newGRPCWebServer(t)
+	url := svr.URL + "/grpc.testing.TestService/UnaryCall"
+	reqData := connectEnvelopes(t, &grpctestprotos.SimpleRequest{Payload: payload})
+
+	// binary
+	resp := connectPost(t, url, "application/grpc-web+proto", reqData, "X-Test", "abc", "X-Grpc-Web", "1")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/grpc-web+proto", resp.Header.Get("Content-Type"))
+	require.Equal(t, "abc", resp.Header.Get("X-Header"))
+	msgs, trailer := readGRPCWebResponse(t, resp.Body)
+	require.Len(t, msgs, 1)
+	var respMsg grpctestprotos.SimpleResponse
+	require.NoError(t, proto.Unmarshal(msgs[0], &respMsg))
+	require.True(t, proto.Equal(&grpctestprotos.SimpleResponse{Payload: payload}, &respMsg))
+	require.Equal(t, "grpc-status: 0\r\nx-trailer: abc\r\n", trailer)
+
+	// text
+	resp = connectPost(t, url, "application/grpc-web-text", []byte(base64.StdEncoding.EncodeToString(reqData)), "X-Test", "abc")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/grpc-web-text", resp.Header.Get("Content-Type"))
+	respData, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	respData, err = decodeGRPCWebText(respData)
+	require.NoError(t, err)
+	msgs, trailer = readGRPCWebResponse(t, bytes.NewReader(respData))
+	require.Len(t, msgs, 1)
+	respMsg.Reset()
+	require.NoError(t, proto.Unmarshal(msgs[0], &respMsg))
+	require.True(t, proto.Equal(&grpctestprotos.SimpleResponse{Payload: payload}, &respMsg))
+	require.Equal(t, "grpc-status: 0\r\nx-trailer: abc\r\n", trailer)
+
+	// errors from upstream
+	resp = connectPost(t, url, "application/grpc-web", reqData, "X-Test", "abc", "X-Fail", "oops 100%")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	msgs, trailer = readGRPCWebResponse(t, resp.Body)
+	require.Empty(t, msgs)
+	require.Equal(t, "grpc-status: 9\r\ngrpc-message: oops 100%25\r\nx-trailer: abc\r\n", trailer)
+}
+
+func TestGRPCWebHandler_ServerStream(t *testing.T) {
+	svr := newGRPCWebServer(t)
+	reqData := connectEnvelopes(t, &grpctestprotos.StreamingOutputCallRequest{
+		Payload:            payload,
+		ResponseParameters: []*grpctestprotos.ResponseParameters{{}, {}, {}},
+	})
+	resp := connectPost(t, svr.URL+"/grpc.testing.TestService/StreamingOutputCall", "application/grpc-web", reqData)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	msgs, trailer := readGRPCWebResponse(t, resp.Body)
+	require.Len(t, msgs, 3)
+	for _, data := range msgs {
+		var msg grpctestprotos.StreamingOutputCallResponse
+		require.NoError(t, proto.Unmarshal(data, &msg))
+		require.True(t, proto.Equal(payload, msg.Payload))
+	}
+	require.Equal(t, "grpc-status: 0\r\n", trailer)
+
+	// malformed request
+	var bad bytes.Buffer
+	require.NoError(t, writeConnectEnvelope(&bad, 0, []byte{0xff}))
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/StreamingOutputCall", "application/grpc-web", bad.Bytes())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	msgs, trailer = readGRPCWebResponse(t, resp.Body)
+	require.Empty(t, msgs)
+	require.True(t, strings.HasPrefix(trailer, "grpc-status: 3\r\ngrpc-message: failed to unmarshal request"), trailer)
+}
+
+func TestGRPCWebHandler_BadRequests(t *testing.T) {
+	svr := newGRPCWebServer(t)
+
+	resp := connectPost(t, svr.URL+"/grpc.testing.TestService/NoSuchMethod", "application/grpc-web", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/UnaryCall", "application/grpc", nil)
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	resp = connectPost(t, svr.URL+"/grpc.testing.TestService/UnaryCall", "application/grpc-web+json", nil)
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+}
+
+func TestGRPCWebHandler_MessageSizeLimit(t *testing.T) {
+	svr := newGRPCWebServer(t, WithMaxMessageSize(100))
+	url := svr.URL + "/grpc.testing.TestService/UnaryCall"
+
+	// length prefix that is too large; the handler must reject it without
+	// trying to read (or allocate) the message
+	resp := connectPost(t, url, "application/grpc-web", []byte{0, 0xff, 0xff, 0xff, 0xff})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	msgs, trailer := readGRPCWebResponse(t, resp.Body)
+	require.Empty(t, msgs)
+	require.Contains(t, trailer, "grpc-status: 8\r\n")
+
+	// text request body that is too large
+	reqData := connectEnvelopes(t, &grpctestprotos.SimpleRequest{Payload: &grpctestprotos.Payload{Body: make([]byte, 100)}})
+	resp = connectPost(t, url, "application/grpc-web-text", []byte(base64.StdEncoding.EncodeToString(reqData)))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	respData, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	respData, err = decodeGRPCWebText(respData)
+	require.NoError(t, err)
+	msgs, trailer = readGRPCWebResponse(t, bytes.NewReader(respData))
+	require.Empty(t, msgs)
+	require.Contains(t, trailer, "grpc-status: 8\r\n")
+}
+
+func TestDecodeGRPCWebText(t *testing.T) {
+	// separately encoded chunks, each with padding
+	data := base64.StdEncoding.EncodeToString([]byte("a")) + base64.StdEncoding.EncodeToString([]byte("bc")) +
+		"\r\n" + base64.StdEncoding.EncodeToString([]byte("def"))
+	decoded, err := decodeGRPCWebText([]byte(data))
+	require.NoError(t, err)
+	require.Equal(t, "abcdef", string(decoded))
+
+	_, err = decodeGRPCWebText([]byte("abc"))
+	require.ErrorContains(t, err, "invalid length")
+	_, err = decodeGRPCWebText([]byte("a$c="))
+	require.ErrorContains(t, err, "illegal base64 data")
+}
+
+func TestParseGRPCTimeout(t *testing.T) {
+	d, err := parseGRPCTimeout("10S")
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Second, d)
+	d, err = parseGRPCTimeout("250m")
+	require.NoError(t, err)
+	require.Equal(t, 250*time.Millisecond, d)
+
+	for _, timeout := range []string{"", "S", "10", "10s", "123456789S", "-1S"} {
+		_, err = parseGRPCTimeout(timeout)
+		require.ErrorContains(t, err, "invalid timeout", timeout)
+	}
+}
@@ -0,0 +1,145 @@
+package grpcdynamic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+// NewProxyHandler returns a stream handler that forwards all RPCs it receives to
+// the given upstream connection. It is intended to be installed in a gRPC server
+// via the [grpc.UnknownServiceHandler] server option, so that the server acts as
+// a proxy for methods it does not itself implement.
+//
+// The given resolver is used to find the descriptor for each incoming method.
+// If the method cannot be resolved, the RPC fails with an "Unimplemented" error.
+// Messages are decoded into and re-encoded from dynamic messages, so the proxy
+// need not be compiled with generated code for any of the proxied services.
+// Unrecognized fields are preserved, so messages are forwarded without loss.
+//
+// Request headers are forwarded upstream, and response headers and trailers are
+// forwarded back to the caller, without modification.
+func NewProxyHandler(upstream grpc.ClientConnInterface, resolver protoresolve.DescriptorResolver) grpc.StreamHandler {
+	return func(_ any, stream grpc.ServerStream) error {
+		fullMethod, ok := grpc.MethodFromServerStream(stream)
+		if !ok {
+			return status.Error(codes.Internal, "could not determine method for stream")
+		}
+		method, err := findMethod(resolver, fullMethod)
+		if err != nil {
+			return err
+		}
+		return proxyStream(upstream, method, fullMethod, stream)
+	}
+}
+
+func findMethod(resolver protoresolve.DescriptorResolver, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	// fullMethod has the form "/package.Service/Method"
+	svcName, methodName, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "malformed method name %q", fullMethod)
+	}
+	d, err := resolver.FindDescriptorByName(protoreflect.FullName(svcName))
+	if errors.Is(err, protoresolve.ErrNotFound) {
+		return nil, status.Errorf(codes.Unimplemented, "unknown service %s", svcName)
+	} else if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resolve service %s: %v", svcName, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "unknown service %s", svcName)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(methodName))
+	if md == nil {
+		return nil, status.Errorf(codes.Unimplemented, "unknown method %s for service %s", methodName, svcName)
+	}
+	return md, nil
+}
+
+func proxyStream(upstream grpc.ClientConnInterface, method protoreflect.MethodDescriptor, fullMethod string, stream grpc.ServerStream) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = metadata.NewOutgoingContext(ctx, md.Copy())
+	}
+	desc := &grpc.StreamDesc{
+		StreamName:    string(method.Name()),
+		ServerStreams: method.IsStreamingServer(),
+		ClientStreams: method.IsStreamingClient(),
+	}
+	cs, err := upstream.NewStream(ctx, desc, fullMethod)
+	if err != nil {
+		return err
+	}
+
+	// forward requests upstream in the background
+	reqErrs := make(chan error, 1)
+	go func() {
+		err := forwardRequests(method.Input(), stream, cs)
+		if err != nil {
+			// abort the upstream call
+			cancel()
+		}
+		reqErrs <- err
+	}()
+
+	sentHeaders := false
+	for {
+		resp := dynamicpb.NewMessage(method.Output())
+		err := cs.RecvMsg(resp)
+		if !sentHeaders {
+			// Headers are available once we receive the first message
+			// or the end of the stream.
+			if md, headerErr := cs.Header(); headerErr == nil {
+				if err := stream.SendHeader(md); err != nil {
+					return err
+				}
+			}
+			sentHeaders = true
+		}
+		if err == io.EOF {
+			stream.SetTrailer(cs.Trailer())
+			return nil
+		} else if err != nil {
+			stream.SetTrailer(cs.Trailer())
+			if ctx.Err() != nil && stream.Context().Err() == nil {
+				// upstream call was aborted due to an error forwarding requests
+				if reqErr := <-reqErrs; reqErr != nil {
+					return reqErr
+				}
+			}
+			return err
+		}
+		if err := stream.SendMsg(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func forwardRequests(reqType protoreflect.MessageDescriptor, stream grpc.ServerStream, cs grpc.ClientStream) error {
+	for {
+		req := dynamicpb.NewMessage(reqType)
+		if err := stream.RecvMsg(req); err == io.EOF {
+			return cs.CloseSend()
+		} else if err != nil {
+			return err
+		}
+		if err := cs.SendMsg(req); err == io.EOF {
+			// Upstream stream is done. The actual status will
+			// be reported from cs.RecvMsg.
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package grpcdynamic
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	grpctesting "github.com/jhump/protoreflect/v2/internal/testing"
+	grpctestprotos "github.com/jhump/protoreflect/v2/internal/testprotos/grpc"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+// metadataEchoService echoes request metadata back in response headers and trailers.
+type metadataEchoService struct {
+	grpctesting.TestService
+}
+
+func (s metadataEchoService) UnaryCall(ctx context.Context, req *grpctestprotos.SimpleRequest) (*grpctestprotos.SimpleResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if err := grpc.SetHeader(ctx, metadata.Pairs("x-header", md.Get("x-test")[0])); err != nil {
+		return nil, err
+	}
+	if err := grpc.SetTrailer(ctx, metadata.Pairs("x-trailer", md.Get("x-test")[0])); err != nil {
+		return nil, err
+	}
+	if len(md.Get("x-fail")) > 0 {
+		return nil, status.Error(codes.FailedPrecondition, md.Get("x-fail")[0])
+	}
+	return s.TestService.UnaryCall(ctx, req)
+}
+
+func serve(t *testing.T, svr *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = svr.Serve(l)
+	}()
+	t.Cleanup(svr.Stop)
+	cc, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = cc.Close()
+	})
+	return cc
+}
+
+func TestProxy(t *testing.T) {
+	upstreamSvr := grpc.NewServer()
+	grpctestprotos.RegisterTestServiceServer(upstreamSvr, metadataEchoService{})
+	upstream := serve(t, upstreamSvr)
+
+	proxySvr := grpc.NewServer(grpc.UnknownServiceHandler(NewProxyHandler(upstream, protoresolve.GlobalDescriptors)))
+	proxyStub := NewStub(serve(t, proxySvr))
+
+	// unary, including metadata
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-test", "abc")
+	var header, trailer metadata.MD
+	resp, err := proxyStub.InvokeRpc(ctx, unaryMd, &grpctestprotos.SimpleRequest{Payload: payload},
+		grpc.Header(&header), grpc.Trailer(&trailer))
+	require.NoError(t, err)
+	require.True(t, proto.Equal(&grpctestprotos.SimpleResponse{Payload: payload}, resp))
+	require.Equal(t, []string{"abc"}, header.Get("x-header"))
+	require.Equal(t, []string{"abc"}, trailer.Get("x-trailer"))
+
+	// errors from upstream are passed through
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-fail", "oops")
+	_, err = proxyStub.InvokeRpc(ctx, unaryMd, &grpctestprotos.SimpleRequest{Payload: payload},
+		grpc.Header(&header), grpc.Trailer(&trailer))
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Equal(t, "oops", status.Convert(err).Message())
+	require.Equal(t, []string{"abc"}, header.Get("x-header"))
+	require.Equal(t, []string{"abc"}, trailer.Get("x-trailer"))
+
+	// streaming
+	cs, err := proxyStub.InvokeRpcClientStream(context.Background(), clientStreamingMd)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, cs.SendMsg(&grpctestprotos.StreamingInputCallRequest{Payload: payload}))
+	}
+	csResp, err := cs.CloseAndReceive()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(&grpctestprotos.StreamingInputCallResponse{AggregatedPayloadSize: int32(3 * len(payload.Body))}, csResp))
+
+	ss, err := proxyStub.InvokeRpcServerStream(context.Background(), serverStreamingMd, &grpctestprotos.StreamingOutputCallRequest{
+		Payload:            payload,
+		ResponseParameters: []*grpctestprotos.ResponseParameters{{}, {}, {}},
+	})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		ssResp, err := ss.RecvMsg()
+		require.NoError(t, err)
+		require.True(t, proto.Equal(&grpctestprotos.StreamingOutputCallResponse{Payload: payload}, ssResp))
+	}
+	_, err = ss.RecvMsg()
+	require.Equal(t, io.EOF, err)
+
+	bds, err := proxyStub.InvokeRpcBidiStream(context.Background(), bidiStreamingMd)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, bds.SendMsg(&grpctestprotos.StreamingOutputCallRequest{Payload: payload}))
+		bdsResp, err := bds.RecvMsg()
+		require.NoError(t, err)
+		require.True(t, proto.Equal(&grpctestprotos.StreamingOutputCallResponse{Payload: payload}, bdsResp))
+	}
+	require.NoError(t, bds.CloseSend())
+	_, err = bds.RecvMsg()
+	require.Equal(t, io.EOF, err)
+
+	// unknown methods
+	var registry protoresolve.Registry
+	proxySvr = grpc.NewServer(grpc.UnknownServiceHandler(NewProxyHandler(upstream, &registry)))
+	proxyStub = NewStub(serve(t, proxySvr))
+	_, err = proxyStub.InvokeRpc(context.Background(), unaryMd, &grpctestprotos.SimpleRequest{Payload: payload})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
@@ -0,0 +1,606 @@
+package grpcdynamic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/internal/httprule"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+// NewRESTTranscoder returns an HTTP handler that transcodes HTTP/JSON requests
+// into gRPC calls on the given upstream connection, as described by the
+// "google.api.http" options on the methods of the given service. This provides
+// the essential functionality of gRPC-Gateway, but driven by descriptors at
+// runtime instead of by generated code.
+//
+// The options are read using
+// [github.com/jhump/protoreflect/v2/protohttp.GetHTTPRule], so they are found
+// even if the "google.api.http" extension was not known when the service's
+// descriptor was built. Methods that do not have the option are not exposed by
+// the handler. An error is returned if a path template in any of the options is
+// invalid. The given resolver is used to resolve the contents of
+// google.protobuf.Any messages when marshaling and unmarshaling JSON. If it is
+// nil, [protoresolve.GlobalDescriptors] is used.
+//
+// For each request, the routes are tried in the order in which the methods and
+// their bindings are declared, and the first match is used. Path variables and
+// query parameters are mapped to fields of the request message, and the request
+// body is mapped to either the entire message or to a single field, according to
+// the binding's "body" field. Responses are serialized as JSON. Errors are
+// returned as JSON representations of google.rpc.Status messages, with an HTTP
+// status code that corresponds to the gRPC status code.
+//
+// Server-streaming methods respond with a stream of newline-delimited JSON
+// objects, each with either a "result" or an "error" field. Client-streaming
+// and bidi-streaming methods are not supported and are never routed.
+//
+// Request headers, other than those that are part of the HTTP protocol, are
+// sent upstream as request metadata. Response headers and trailers from
+// upstream are returned as HTTP response headers with "Grpc-Metadata-" and
+// "Grpc-Trailer-" prefixes, respectively.
+//
+// Request bodies larger than 4 MiB are rejected with a ResourceExhausted error.
+// Use [WithMaxMessageSize] to change this limit.
+func NewRESTTranscoder(sd protoreflect.ServiceDescriptor, upstream grpc.ClientConnInterface, resolver protoresolve.Resolver, opts ...HandlerOption) (http.Handler, error) {
+	if resolver == nil {
+		resolver = protoresolve.GlobalDescriptors
+	}
+	t := &restTranscoder{upstream: upstream, res: resolver.AsTypeResolver(), opts: newHandlerOptions(opts)}
+	methods := sd.Methods()
+	for i, length := 0, methods.Len(); i < length; i++ {
+		md := methods.Get(i)
+		if md.IsStreamingClient() {
+			continue
+		}
+		for _, b := range httprule.Bindings(md) {
+			if err := t.addRoute(md, b); err != nil {
+				return nil, fmt.Errorf("method %s: %w", md.FullName(), err)
+			}
+		}
+	}
+	return t, nil
+}
+
+type restTranscoder struct {
+	upstream grpc.ClientConnInterface
+	res      protoresolve.TypeResolver
+	opts     handlerOptions
+	routes   []*restRoute
+}
+
+// restRoute is an HTTP method and path template that is bound to an RPC method.
+type restRoute struct {
+	method   protoreflect.MethodDescriptor
+	httpVerb string
+	path     *pathTemplate
+	// the field that is set from the request body; if bodyAll is true,
+	// the body is the entire input message
+	body         protoreflect.FieldDescriptor
+	bodyAll      bool
+	responseBody protoreflect.FieldDescriptor
+}
+
+func (t *restTranscoder) addRoute(md protoreflect.MethodDescriptor, b httprule.Binding) error {
+	route := restRoute{method: md, httpVerb: b.Method}
+	var err error
+	route.path, err = parsePathTemplate(b.Path)
+	if err != nil {
+		return err
+	}
+	for _, v := range route.path.variables {
+		if _, err := resolveFieldPath(md.Input(), v.fieldPath); err != nil {
+			return fmt.Errorf("path template %q: %w", b.Path, err)
+		}
+	}
+	switch b.Body {
+	case "":
+	case "*":
+		route.bodyAll = true
+	default:
+		route.body = md.Input().Fields().ByName(protoreflect.Name(b.Body))
+		if route.body == nil {
+			return fmt.Errorf("body field %q not found in %s", b.Body, md.Input().FullName())
+		}
+	}
+	if b.ResponseBody != "" {
+		route.responseBody = md.Output().Fields().ByName(protoreflect.Name(b.ResponseBody))
+		if route.responseBody == nil {
+			return fmt.Errorf("response body field %q not found in %s", b.ResponseBody, md.Output().FullName())
+		}
+	}
+	t.routes = append(t.routes, &route)
+	return nil
+}
+
+func (t *restTranscoder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route, vars := t.findRoute(r)
+	if route == nil {
+		t.writeError(w, status.Errorf(codes.NotFound, "no route for %s %s", r.Method, r.URL.Path), nil, nil)
+		return
+	}
+	req := dynamicpb.NewMessage(route.method.Input())
+	if err := t.populateRequest(req, route, vars, r); err != nil {
+		t.writeError(w, err, nil, nil)
+		return
+	}
+	md, err := httpRequestMetadata(r.Header, nil)
+	if err != nil {
+		t.writeError(w, err, nil, nil)
+		return
+	}
+	ctx := metadata.NewOutgoingContext(r.Context(), md)
+	fullMethod := requestMethod(route.method)
+
+	if !route.method.IsStreamingServer() {
+		resp := dynamicpb.NewMessage(route.method.Output())
+		var header, trailer metadata.MD
+		err := t.upstream.Invoke(ctx, fullMethod, req, resp, grpc.Header(&header), grpc.Trailer(&trailer))
+		if err != nil {
+			t.writeError(w, err, header, trailer)
+			return
+		}
+		data, err := t.marshalResponse(route, resp)
+		if err != nil {
+			t.writeError(w, status.Errorf(codes.Internal, "failed to marshal response: %v", err), header, trailer)
+			return
+		}
+		addHTTPMetadata(w.Header(), header, "Grpc-Metadata-")
+		addHTTPMetadata(w.Header(), trailer, "Grpc-Trailer-")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+		return
+	}
+
+	desc := &grpc.StreamDesc{StreamName: string(route.method.Name()), ServerStreams: true}
+	cs, err := t.upstream.NewStream(ctx, desc, fullMethod)
+	if err == nil {
+		err = cs.SendMsg(req)
+	}
+	if err == nil {
+		err = cs.CloseSend()
+	}
+	if err != nil && err != io.EOF {
+		t.writeError(w, err, nil, nil)
+		return
+	}
+	rc := http.NewResponseController(w)
+	sentHeaders := false
+	for {
+		resp := dynamicpb.NewMessage(route.method.Output())
+		err := cs.RecvMsg(resp)
+		if !sentHeaders {
+			if md, headerErr := cs.Header(); headerErr == nil {
+				addHTTPMetadata(w.Header(), md, "Grpc-Metadata-")
+			}
+			if err != nil && err != io.EOF {
+				// nothing sent yet, so we can send a normal error response
+				t.writeError(w, err, nil, cs.Trailer())
+				return
+			}
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			sentHeaders = true
+		}
+		if err == io.EOF {
+			return
+		}
+		var line []byte
+		if err == nil {
+			var data []byte
+			data, err = t.marshalResponse(route, resp)
+			if err != nil {
+				err = status.Errorf(codes.Internal, "failed to marshal response: %v", err)
+			} else {
+				line, _ = json.Marshal(map[string]json.RawMessage{"result": data})
+			}
+		}
+		if err != nil {
+			line, _ = json.Marshal(map[string]json.RawMessage{"error": t.statusJSON(status.Convert(err))})
+		}
+		if _, writeErr := w.Write(append(line, '\n')); writeErr != nil {
+			return
+		}
+		_ = rc.Flush()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (t *restTranscoder) findRoute(r *http.Request) (*restRoute, map[*pathVariable]string) {
+	for _, route := range t.routes {
+		if route.httpVerb != r.Method {
+			continue
+		}
+		if vars, ok := route.path.match(r.URL.EscapedPath()); ok {
+			return route, vars
+		}
+	}
+	return nil, nil
+}
+
+func (t *restTranscoder) populateRequest(req *dynamicpb.Message, route *restRoute, vars map[*pathVariable]string, r *http.Request) error {
+	body, err := readLimited(r.Body, t.opts.maxMessageSize)
+	if err != nil {
+		return err
+	}
+	opts := protojson.UnmarshalOptions{Resolver: t.res}
+	switch {
+	case route.bodyAll:
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err := opts.Unmarshal(body, req); err != nil {
+				return status.Errorf(codes.InvalidArgument, "failed to unmarshal request body: %v", err)
+			}
+		}
+	case route.body != nil:
+		if len(bytes.TrimSpace(body)) > 0 {
+			// Wrap the body in an object so that protojson can handle any kind
+			// of field, including scalars, lists, and maps.
+			wrapped, _ := json.Marshal(map[string]json.RawMessage{route.body.JSONName(): body})
+			if err := opts.Unmarshal(wrapped, req); err != nil {
+				return status.Errorf(codes.InvalidArgument, "failed to unmarshal request body: %v", err)
+			}
+		}
+	}
+	for v, val := range vars {
+		if err := setFieldPath(req, v.fieldPath, val); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid value for path variable %s: %v", strings.Join(v.fieldPath, "."), err)
+		}
+	}
+	if route.bodyAll {
+		// no query parameters when the entire message is in the body
+		return nil
+	}
+	for key, vals := range r.URL.Query() {
+		fieldPath := strings.Split(key, ".")
+		if route.body != nil && (fieldPath[0] == string(route.body.Name()) || fieldPath[0] == route.body.JSONName()) {
+			return status.Errorf(codes.InvalidArgument, "query parameter %s refers to the request body", key)
+		}
+		for _, val := range vals {
+			if err := setFieldPath(req, fieldPath, val); err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid value for query parameter %s: %v", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (t *restTranscoder) marshalResponse(route *restRoute, resp *dynamicpb.Message) ([]byte, error) {
+	opts := protojson.MarshalOptions{Resolver: t.res}
+	if route.responseBody == nil {
+		return opts.Marshal(resp)
+	}
+	data, err := opts.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if val, ok := fields[route.responseBody.JSONName()]; ok {
+		return val, nil
+	}
+	// field is not set, so we marshal its default value
+	switch fd := route.responseBody; {
+	case fd.IsList():
+		return []byte("[]"), nil
+	case fd.IsMap():
+		return []byte("{}"), nil
+	case fd.Message() != nil:
+		// The default value is read-only and cannot be set on another
+		// message, so marshal a new, empty message instead.
+		return opts.Marshal(resp.NewField(fd).Message().Interface())
+	}
+	fieldMsg := dynamicpb.NewMessage(route.method.Output())
+	fieldMsg.Set(route.responseBody, resp.Get(route.responseBody))
+	data, err = protojson.MarshalOptions{Resolver: t.res, EmitUnpopulated: true}.Marshal(fieldMsg)
+	if err != nil {
+		return nil, err
+	}
+	fields = nil
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields[route.responseBody.JSONName()], nil
+}
+
+func (t *restTranscoder) writeError(w http.ResponseWriter, err error, header, trailer metadata.MD) {
+	addHTTPMetadata(w.Header(), header, "Grpc-Metadata-")
+	addHTTPMetadata(w.Header(), trailer, "Grpc-Trailer-")
+	st := status.Convert(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatusFromCode(st.Code()))
+	_, _ = w.Write(t.statusJSON(st))
+}
+
+// statusJSON returns the JSON representation of the given status as a
+// google.rpc.Status message.
+func (t *restTranscoder) statusJSON(st *status.Status) []byte {
+	data, err := protojson.MarshalOptions{Resolver: t.res}.Marshal(st.Proto())
+	if err != nil {
+		// Probably could not resolve the type of an error detail. So we omit
+		// the details.
+		data, _ = protojson.Marshal(status.New(st.Code(), st.Message()).Proto())
+	}
+	return data
+}
+
+// resolveFieldPath resolves the given path of field names, starting from the
+// given message. Field names may be either proto names or JSON names. All
+// fields in the path, except for the last one, must be singular message fields.
+func resolveFieldPath(md protoreflect.MessageDescriptor, path []string) ([]protoreflect.FieldDescriptor, error) {
+	fields := make([]protoreflect.FieldDescriptor, len(path))
+	for i, name := range path {
+		if md == nil {
+			return nil, fmt.Errorf("field %s is not a singular message field", strings.Join(path[:i], "."))
+		}
+		fld := md.Fields().ByName(protoreflect.Name(name))
+		if fld == nil {
+			fld = md.Fields().ByJSONName(name)
+		}
+		if fld == nil {
+			return nil, fmt.Errorf("field %q not found in %s", name, md.FullName())
+		}
+		fields[i] = fld
+		md = nil
+		if fld.Message() != nil && fld.Cardinality() != protoreflect.Repeated {
+			md = fld.Message()
+		}
+	}
+	return fields, nil
+}
+
+// setFieldPath sets the field at the given path in msg to the given value,
+// parsed from a string. If the field is repeated, the value is appended.
+func setFieldPath(msg protoreflect.Message, path []string, val string) error {
+	fields, err := resolveFieldPath(msg.Descriptor(), path)
+	if err != nil {
+		return err
+	}
+	for _, fld := range fields[:len(fields)-1] {
+		msg = msg.Mutable(fld).Message()
+	}
+	fld := fields[len(fields)-1]
+	if fld.IsMap() {
+		return fmt.Errorf("map field %s cannot be set from a string", fld.FullName())
+	}
+	v, err := parseFieldValue(msg, fld, val)
+	if err != nil {
+		return err
+	}
+	if fld.IsList() {
+		msg.Mutable(fld).List().Append(v)
+	} else {
+		msg.Set(fld, v)
+	}
+	return nil
+}
+
+func parseFieldValue(msg protoreflect.Message, fld protoreflect.FieldDescriptor, val string) (protoreflect.Value, error) {
+	switch fld.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(val)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := strconv.ParseInt(val, 10, 32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(val, 10, 64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := strconv.ParseUint(val, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(u)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := strconv.ParseUint(val, 10, 64)
+		return protoreflect.ValueOfUint64(u), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(val, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(val, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(val), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			b, err = base64.URLEncoding.DecodeString(val)
+		}
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.EnumKind:
+		if ev := fld.Enum().Values().ByName(protoreflect.Name(val)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		i, err := strconv.ParseInt(val, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("%q is not a valid value for enum %s", val, fld.Enum().FullName())
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), nil
+	default:
+		// Messages can be set from strings if they are well-known types
+		// whose JSON representation is a string, like timestamps and
+		// durations, or a scalar, like wrappers.
+		var v protoreflect.Value
+		if fld.IsList() {
+			v = msg.NewField(fld).List().NewElement()
+		} else {
+			v = msg.NewField(fld)
+		}
+		quoted, _ := json.Marshal(val)
+		if err := protojson.Unmarshal(quoted, v.Message().Interface()); err != nil {
+			if protojson.Unmarshal([]byte(val), v.Message().Interface()) != nil {
+				return protoreflect.Value{}, err
+			}
+		}
+		return v, nil
+	}
+}
+
+// pathTemplate is a parsed path template from a google.api.http option.
+type pathTemplate struct {
+	segments  []pathSegment
+	variables []*pathVariable
+	verb      string
+}
+
+type pathSegment struct {
+	// literal is empty for wildcards
+	literal string
+	// multi is true for "**" wildcards
+	multi bool
+}
+
+type pathVariable struct {
+	fieldPath []string
+	// the segments the variable matches, as indexes into the template's
+	// segments; end is exclusive
+	start, end int
+}
+
+// parsePathTemplate parses the given path template, which has the following
+// syntax:
+//
+//	Template = "/" Segments [ Verb ] ;
+//	Segments = Segment { "/" Segment } ;
+//	Segment  = "*" | "**" | LITERAL | Variable ;
+//	Variable = "{" FieldPath [ "=" Segments ] "}" ;
+//	FieldPath = IDENT { "." IDENT } ;
+//	Verb     = ":" LITERAL ;
+func parsePathTemplate(tmpl string) (*pathTemplate, error) {
+	if !strings.HasPrefix(tmpl, "/") {
+		return nil, fmt.Errorf("path template %q must start with '/'", tmpl)
+	}
+	p := &pathTemplate{}
+	rest := tmpl[1:]
+	if i := strings.LastIndexByte(rest, ':'); i >= 0 && i > strings.LastIndexByte(rest, '/') && i > strings.LastIndexByte(rest, '}') {
+		p.verb = rest[i+1:]
+		rest = rest[:i]
+		if p.verb == "" {
+			return nil, fmt.Errorf("path template %q has empty verb", tmpl)
+		}
+	}
+	var inVariable *pathVariable
+	for _, seg := range strings.Split(rest, "/") {
+		if strings.HasPrefix(seg, "{") {
+			if inVariable != nil {
+				return nil, fmt.Errorf("path template %q has nested variables", tmpl)
+			}
+			name, value, hasValue := strings.Cut(strings.TrimPrefix(seg, "{"), "=")
+			closed := false
+			if !hasValue {
+				name, closed = strings.CutSuffix(name, "}")
+				if !closed {
+					return nil, fmt.Errorf("path template %q has unbalanced braces", tmpl)
+				}
+				value = "*"
+			} else {
+				value, closed = strings.CutSuffix(value, "}")
+			}
+			if name == "" {
+				return nil, fmt.Errorf("path template %q has variable with no name", tmpl)
+			}
+			inVariable = &pathVariable{fieldPath: strings.Split(name, "."), start: len(p.segments)}
+			p.variables = append(p.variables, inVariable)
+			seg = value
+			if closed {
+				if err := p.addSegment(tmpl, seg); err != nil {
+					return nil, err
+				}
+				inVariable.end = len(p.segments)
+				inVariable = nil
+				continue
+			}
+		}
+		var closed bool
+		if inVariable != nil {
+			seg, closed = strings.CutSuffix(seg, "}")
+		}
+		if err := p.addSegment(tmpl, seg); err != nil {
+			return nil, err
+		}
+		if closed {
+			inVariable.end = len(p.segments)
+			inVariable = nil
+		}
+	}
+	if inVariable != nil {
+		return nil, fmt.Errorf("path template %q has unbalanced braces", tmpl)
+	}
+	for i, seg := range p.segments {
+		if seg.multi && i != len(p.segments)-1 {
+			return nil, fmt.Errorf("path template %q has '**' that is not in the last segment", tmpl)
+		}
+	}
+	return p, nil
+}
+
+func (p *pathTemplate) addSegment(tmpl, seg string) error {
+	switch {
+	case seg == "":
+		return fmt.Errorf("path template %q has empty segment", tmpl)
+	case seg == "*":
+		p.segments = append(p.segments, pathSegment{})
+	case seg == "**":
+		p.segments = append(p.segments, pathSegment{multi: true})
+	case strings.ContainsAny(seg, "{}*="):
+		return fmt.Errorf("path template %q has invalid segment %q", tmpl, seg)
+	default:
+		p.segments = append(p.segments, pathSegment{literal: seg})
+	}
+	return nil
+}
+
+// match matches the given escaped URL path against the template. If it
+// matches, the values of the template's variables are returned.
+func (p *pathTemplate) match(path string) (map[*pathVariable]string, bool) {
+	path, ok := strings.CutPrefix(path, "/")
+	if !ok {
+		return nil, false
+	}
+	if p.verb != "" {
+		if path, ok = strings.CutSuffix(path, ":"+p.verb); !ok {
+			return nil, false
+		}
+	}
+	parts := strings.Split(path, "/")
+	multi := len(p.segments) > 0 && p.segments[len(p.segments)-1].multi
+	if len(parts) < len(p.segments) || (!multi && len(parts) != len(p.segments)) {
+		return nil, false
+	}
+	for i := range parts {
+		part, err := url.PathUnescape(parts[i])
+		if err != nil || part == "" {
+			return nil, false
+		}
+		parts[i] = part
+		if i < len(p.segments) && p.segments[i].literal != "" && p.segments[i].literal != part {
+			return nil, false
+		}
+	}
+	vars := make(map[*pathVariable]string, len(p.variables))
+	for _, v := range p.variables {
+		end := v.end
+		if end == len(p.segments) && multi {
+			end = len(parts)
+		}
+		vars[v] = strings.Join(parts[v.start:end], "/")
+	}
+	return vars, true
+}
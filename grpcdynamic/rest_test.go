@@ -0,0 +1,356 @@
+package grpcdynamic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+const httpProto = `
+	syntax = "proto3";
+	package google.api;
+	import "google/protobuf/descriptor.proto";
+	extend google.protobuf.MethodOptions {
+		HttpRule http = 72295728;
+	}
+	message HttpRule {
+		string selector = 1;
+		oneof pattern {
+			string get = 2;
+			string put = 3;
+			string post = 4;
+			string delete = 5;
+			string patch = 6;
+			CustomHttpPattern custom = 8;
+		}
+		string body = 7;
+		string response_body = 12;
+		repeated HttpRule additional_bindings = 11;
+	}
+	message CustomHttpPattern {
+		string kind = 1;
+		string path = 2;
+	}
+`
+
+const libraryProto = `
+	syntax = "proto3";
+	package test;
+	import "google/api/http.proto";
+	import "google/protobuf/timestamp.proto";
+	service Library {
+		rpc GetBook(Book) returns (Book) {
+			option (google.api.http) = {
+				get: "/v1/{name=shelves/*/books/*}"
+				additional_bindings { get: "/v1/books/{version}" }
+			};
+		}
+		rpc UpdateBook(UpdateBookRequest) returns (UpdateBookRequest) {
+			option (google.api.http) = {
+				patch: "/v1/{book.name=shelves/*/books/*}"
+				body: "book"
+				response_body: "book"
+			};
+		}
+		rpc CreateBook(Book) returns (Book) {
+			option (google.api.http) = { post: "/v1/books:create" body: "*" };
+		}
+		rpc DeleteBook(Book) returns (Book) {
+			option (google.api.http) = { delete: "/v1/{name=files/**}" };
+		}
+		rpc ListBooks(Book) returns (stream Book) {
+			option (google.api.http) = { get: "/v1/books" };
+		}
+		rpc GetTags(Book) returns (Book) {
+			option (google.api.http) = { get: "/v1/tags/{name}" response_body: "tags" };
+		}
+		rpc GetLabels(Book) returns (Book) {
+			option (google.api.http) = { get: "/v1/labels/{name}" response_body: "labels" };
+		}
+		rpc GetSequel(Book) returns (Book) {
+			option (google.api.http) = { get: "/v1/sequels/{name}" response_body: "sequel" };
+		}
+		rpc ListTags(Book) returns (stream Book) {
+			option (google.api.http) = { get: "/v1/tags" response_body: "tags" };
+		}
+		rpc Unbound(Book) returns (Book);
+	}
+	message Book {
+		string name = 1;
+		int64 version = 2;
+		repeated string tags = 3;
+		Genre genre = 4;
+		bool published = 5;
+		google.protobuf.Timestamp published_at = 6;
+		Book sequel = 7;
+		map<string, string> labels = 8;
+	}
+	message UpdateBookRequest {
+		Book book = 1;
+	}
+	enum Genre {
+		GENRE_UNSPECIFIED = 0;
+		GENRE_FICTION = 1;
+	}
+`
+
+// echoHandler is an upstream handler that echoes back the request message.
+// Requests whose name is "fail" fail with a NotFound error. Server-streaming
+// methods echo the request twice.
+func echoHandler(sd protoreflect.ServiceDescriptor) grpc.StreamHandler {
+	return func(_ any, ss grpc.ServerStream) error {
+		fullMethod, _ := grpc.MethodFromServerStream(ss)
+		md := sd.Methods().ByName(protoreflect.Name(fullMethod[strings.LastIndexByte(fullMethod, '/')+1:]))
+		if md == nil {
+			return status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+		}
+		req := dynamicpb.NewMessage(md.Input())
+		if err := ss.RecvMsg(req); err != nil {
+			return err
+		}
+		reqMd, _ := metadata.FromIncomingContext(ss.Context())
+		if err := ss.SetHeader(metadata.Pairs("x-header", strings.Join(reqMd.Get("x-test"), ","))); err != nil {
+			return err
+		}
+		ss.SetTrailer(metadata.Pairs("x-trailer", "abc"))
+		nameFld := md.Input().Fields().ByName("name")
+		if nameFld != nil && req.Get(nameFld).String() == "fail" {
+			return status.Error(codes.NotFound, "no such book")
+		}
+		count := 1
+		if md.IsStreamingServer() {
+			count = 2
+		}
+		for i := 0; i < count; i++ {
+			if err := ss.SendMsg(req); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func newRESTServer(t *testing.T, opts ...HandlerOption) *httptest.Server {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"google/api/http.proto": httpProto,
+				"test.proto":            libraryProto,
+			}),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	var reg protoresolve.Registry
+	require.NoError(t, registerRecursive(&reg, files[0]))
+	sd := files[0].Services().ByName("Library")
+
+	upstreamSvr := grpc.NewServer(grpc.UnknownServiceHandler(echoHandler(sd)))
+	upstream := serve(t, upstreamSvr)
+	handler, err := NewRESTTranscoder(sd, upstream, &reg, opts...)
+	require.NoError(t, err)
+	svr := httptest.NewServer(handler)
+	t.Cleanup(svr.Close)
+	return svr
+}
+
+func registerRecursive(reg *protoresolve.Registry, fd protoreflect.FileDescriptor) error {
+	if _, err := reg.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+	imports := fd.Imports()
+	for i, length := 0, imports.Len(); i < length; i++ {
+		if err := registerRecursive(reg, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return reg.RegisterFile(fd)
+}
+
+func doREST(t *testing.T, method, url, body string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("X-Test", "xyz")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+func TestRESTTranscoder(t *testing.T) {
+	svr := newRESTServer(t)
+
+	// path variables and query parameters
+	resp, body := doREST(t, http.MethodGet, svr.URL+"/v1/shelves/1/books/a%20b?tags=x&tags=y&genre=GENRE_FICTION&published=true&sequel.version=3&publishedAt=2024-01-02T03:04:05Z", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Equal(t, "xyz", resp.Header.Get("Grpc-Metadata-X-Header"))
+	require.Equal(t, "abc", resp.Header.Get("Grpc-Trailer-X-Trailer"))
+	require.JSONEq(t, `{
+		"name": "shelves/1/books/a b",
+		"tags": ["x", "y"],
+		"genre": "GENRE_FICTION",
+		"published": true,
+		"publishedAt": "2024-01-02T03:04:05Z",
+		"sequel": {"version": "3"}
+	}`, body)
+
+	// additional binding
+	resp, body = doREST(t, http.MethodGet, svr.URL+"/v1/books/42", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.JSONEq(t, `{"version": "42"}`, body)
+
+	// body field and response body
+	resp, body = doREST(t, http.MethodPatch, svr.URL+"/v1/shelves/1/books/2", `{"version": 5}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.JSONEq(t, `{"name": "shelves/1/books/2", "version": "5"}`, body)
+
+	// entire body, with verb
+	resp, body = doREST(t, http.MethodPost, svr.URL+"/v1/books:create", `{"name": "abc", "tags": ["t"]}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.JSONEq(t, `{"name": "abc", "tags": ["t"]}`, body)
+
+	// multi-segment wildcard
+	resp, body = doREST(t, http.MethodDelete, svr.URL+"/v1/files/a/b/c", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.JSONEq(t, `{"name": "files/a/b/c"}`, body)
+
+	// server stream
+	resp, body = doREST(t, http.MethodGet, svr.URL+"/v1/books?name=abc", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	require.Equal(t, `{"result":{"name":"abc"}}`+"\n"+`{"result":{"name":"abc"}}`+"\n", body)
+}
+
+func TestRESTTranscoder_ResponseBody(t *testing.T) {
+	svr := newRESTServer(t)
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "repeated", path: "/v1/tags/a?tags=x&tags=y", expected: `["x", "y"]`},
+		{name: "repeated not set", path: "/v1/tags/a", expected: `[]`},
+		{name: "map not set", path: "/v1/labels/a", expected: `{}`},
+		{name: "message", path: "/v1/sequels/a?sequel.name=b", expected: `{"name": "b"}`},
+		{name: "message not set", path: "/v1/sequels/a", expected: `{}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, body := doREST(t, http.MethodGet, svr.URL+tc.path, "")
+			require.Equal(t, http.StatusOK, resp.StatusCode, body)
+			require.JSONEq(t, tc.expected, body)
+		})
+	}
+
+	// server stream
+	resp, body := doREST(t, http.MethodGet, svr.URL+"/v1/tags?name=abc", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+	require.Equal(t, `{"result":[]}`+"\n"+`{"result":[]}`+"\n", body)
+}
+
+func TestRESTTranscoder_Errors(t *testing.T) {
+	svr := newRESTServer(t)
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		statusCode int
+		code       codes.Code
+	}{
+		{name: "upstream error", method: http.MethodPost, path: "/v1/books:create", body: `{"name": "fail"}`, statusCode: http.StatusNotFound, code: codes.NotFound},
+		{name: "no route", method: http.MethodGet, path: "/v2/books", statusCode: http.StatusNotFound, code: codes.NotFound},
+		{name: "wrong method", method: http.MethodPut, path: "/v1/books:create", statusCode: http.StatusNotFound, code: codes.NotFound},
+		{name: "missing verb", method: http.MethodPost, path: "/v1/books", statusCode: http.StatusNotFound, code: codes.NotFound},
+		{name: "unbound method", method: http.MethodPost, path: "/test.Library/Unbound", statusCode: http.StatusNotFound, code: codes.NotFound},
+		{name: "invalid path variable", method: http.MethodGet, path: "/v1/books/abc", statusCode: http.StatusBadRequest, code: codes.InvalidArgument},
+		{name: "invalid query param", method: http.MethodGet, path: "/v1/books?genre=NOPE", statusCode: http.StatusBadRequest, code: codes.InvalidArgument},
+		{name: "unknown query param", method: http.MethodGet, path: "/v1/books?foo=bar", statusCode: http.StatusBadRequest, code: codes.InvalidArgument},
+		{name: "query param for body", method: http.MethodPatch, path: "/v1/shelves/1/books/2?book.version=1", statusCode: http.StatusBadRequest, code: codes.InvalidArgument},
+		{name: "invalid body", method: http.MethodPost, path: "/v1/books:create", body: `{"version": "abc"}`, statusCode: http.StatusBadRequest, code: codes.InvalidArgument},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, body := doREST(t, tc.method, svr.URL+tc.path, tc.body)
+			require.Equal(t, tc.statusCode, resp.StatusCode, body)
+			require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			var st map[string]any
+			require.NoError(t, json.Unmarshal([]byte(body), &st))
+			require.Equal(t, float64(tc.code), st["code"])
+		})
+	}
+}
+
+func TestRESTTranscoder_MessageSizeLimit(t *testing.T) {
+	svr := newRESTServer(t, WithMaxMessageSize(20))
+
+	resp, body := doREST(t, http.MethodPost, svr.URL+"/v1/books:create", `{"name": "abc"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode, body)
+
+	resp, body = doREST(t, http.MethodPost, svr.URL+"/v1/books:create", `{"name": "abcdefghijklmnopqrstuvwxyz"}`)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode, body)
+	var st map[string]any
+	require.NoError(t, json.Unmarshal([]byte(body), &st))
+	require.Equal(t, float64(codes.ResourceExhausted), st["code"])
+}
+
+func TestParsePathTemplate(t *testing.T) {
+	tmpl, err := parsePathTemplate("/v1/{name=shelves/*/books/*}/pages/{page}:read")
+	require.NoError(t, err)
+	require.Equal(t, "read", tmpl.verb)
+	vars, ok := tmpl.match("/v1/shelves/1/books/2/pages/3:read")
+	require.True(t, ok)
+	require.Len(t, vars, 2)
+	require.Equal(t, "shelves/1/books/2", vars[tmpl.variables[0]])
+	require.Equal(t, "3", vars[tmpl.variables[1]])
+	for _, path := range []string{
+		"/v1/shelves/1/books/2/pages/3",
+		"/v1/shelves/1/books/2/pages/3:write",
+		"/v1/shelves/1/books/2/pages:read",
+		"/v1/shelves/1/magazines/2/pages/3:read",
+		"/v1/shelves/1/books//pages/3:read",
+	} {
+		_, ok := tmpl.match(path)
+		require.False(t, ok, path)
+	}
+
+	for tmpl, errMsg := range map[string]string{
+		"v1/books":          "must start with '/'",
+		"/v1/{name":         "unbalanced braces",
+		"/v1/{name=a/b":     "unbalanced braces",
+		"/v1/{=a}":          "variable with no name",
+		"/v1/{a=b/{c}}":     "nested variables",
+		"/v1//books":        "empty segment",
+		"/v1/**/books":      "'**' that is not in the last segment",
+		"/v1/books:":        "empty verb",
+		"/v1/bo{oks":        "invalid segment",
+		"/v1/{name=a*}/foo": "invalid segment",
+	} {
+		_, err := parsePathTemplate(tmpl)
+		require.ErrorContains(t, err, errMsg, tmpl)
+	}
+}
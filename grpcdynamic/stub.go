@@ -2,6 +2,13 @@
 // methods that are unknown at compile time, using method descriptors to drive the
 // invocations at runtime. The actual request and response messages may be (and
 // likely often are) dynamic messages.
+//
+// It also provides a stream handler, NewProxyHandler, that uses the same
+// technique to forward arbitrary RPCs from a server to an upstream connection,
+// and HTTP handlers, NewConnectHandler, NewGRPCWebHandler, and NewRESTTranscoder,
+// that translate requests that use the Connect and gRPC-Web protocols, or that
+// are described by google.api.http annotations, into RPCs on an upstream
+// connection.
 package grpcdynamic
 
 import (
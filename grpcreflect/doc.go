@@ -8,5 +8,9 @@
 // dynamic client. (See the grpcdynamic package in this same repo for more on
 // that.)
 //
+// On the server side, the Register function registers an implementation of the
+// reflection service that is backed by a protoresolve.Resolver, instead of the
+// global registries.
+//
 // [gRPC reflection service]: https://github.com/grpc/grpc/blob/master/src/proto/grpc/reflection/v1/reflection.proto
 package grpcreflect
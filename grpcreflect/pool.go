@@ -0,0 +1,123 @@
+package grpcreflect
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+// RemotePool is a descriptor pool whose contents are downloaded from a server,
+// using the server reflection service. It contains the files that define the
+// services exposed by the server, along with all of their dependencies.
+//
+// Unlike the resolver returned by [Client.AsResolver], which queries the server
+// on demand, a RemotePool is a snapshot: all of its contents are downloaded
+// when it is created and queries never contact the server. The Refresh method
+// can be used to download a new snapshot, such as when the server's schema may
+// have changed. It is safe to use a RemotePool concurrently from multiple
+// goroutines, including concurrent calls to Refresh.
+type RemotePool struct {
+	conn grpc.ClientConnInterface
+
+	mu  sync.RWMutex
+	reg *protoresolve.Registry
+}
+
+var _ protoresolve.DescriptorPool = (*RemotePool)(nil)
+
+// NewRemotePool creates a new pool by downloading the schema from the server at
+// the other end of the given connection. This asks the server for the names of
+// all of its services and then downloads the files that define those services,
+// along with their dependencies. The given context is only used for the calls
+// made to download the schema; it is not retained by the returned pool.
+func NewRemotePool(ctx context.Context, conn grpc.ClientConnInterface) (*RemotePool, error) {
+	pool := &RemotePool{conn: conn}
+	if err := pool.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// Refresh downloads the schema from the server again, replacing the pool's
+// contents. If an error occurs, the pool's contents are left unchanged.
+func (p *RemotePool) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	client := NewClientAuto(ctx, p.conn)
+	defer client.Reset()
+
+	services, err := client.ListServices()
+	if err != nil {
+		return err
+	}
+	reg := &protoresolve.Registry{}
+	for _, svc := range services {
+		fd, err := client.FileContainingSymbol(svc)
+		if err != nil {
+			return fmt.Errorf("failed to download file for service %s: %w", svc, err)
+		}
+		if err := registerFileAndDeps(reg, fd); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reg = reg
+	return nil
+}
+
+func registerFileAndDeps(reg *protoresolve.Registry, fd protoreflect.FileDescriptor) error {
+	if _, err := reg.FindFileByPath(fd.Path()); err == nil {
+		// already registered
+		return nil
+	}
+	imports := fd.Imports()
+	for i, length := 0, imports.Len(); i < length; i++ {
+		if err := registerFileAndDeps(reg, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return reg.RegisterFile(fd)
+}
+
+func (p *RemotePool) registry() *protoresolve.Registry {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.reg
+}
+
+// FindFileByPath implements part of the protoresolve.DescriptorPool interface.
+func (p *RemotePool) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	return p.registry().FindFileByPath(path)
+}
+
+// NumFiles implements part of the protoresolve.DescriptorPool interface.
+func (p *RemotePool) NumFiles() int {
+	return p.registry().NumFiles()
+}
+
+// RangeFiles implements part of the protoresolve.DescriptorPool interface.
+func (p *RemotePool) RangeFiles(fn func(protoreflect.FileDescriptor) bool) {
+	p.registry().RangeFiles(fn)
+}
+
+// NumFilesByPackage implements part of the protoresolve.DescriptorPool interface.
+func (p *RemotePool) NumFilesByPackage(name protoreflect.FullName) int {
+	return p.registry().NumFilesByPackage(name)
+}
+
+// RangeFilesByPackage implements part of the protoresolve.DescriptorPool interface.
+func (p *RemotePool) RangeFilesByPackage(name protoreflect.FullName, fn func(protoreflect.FileDescriptor) bool) {
+	p.registry().RangeFilesByPackage(name, fn)
+}
+
+// FindDescriptorByName implements part of the protoresolve.DescriptorPool interface.
+func (p *RemotePool) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	return p.registry().FindDescriptorByName(name)
+}
@@ -0,0 +1,77 @@
+package grpcreflect
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	testprotosgrpc "github.com/jhump/protoreflect/v2/internal/testprotos/grpc"
+)
+
+func startServerForPoolTest(t *testing.T, withReflection bool) *grpc.ClientConn {
+	t.Helper()
+	svr := grpc.NewServer()
+	testprotosgrpc.RegisterDummyServiceServer(svr, testService{})
+	if withReflection {
+		reflection.Register(svr)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = svr.Serve(l)
+	}()
+	t.Cleanup(svr.Stop)
+	cconn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = cconn.Close()
+	})
+	return cconn
+}
+
+func TestRemotePool(t *testing.T) {
+	cconn := startServerForPoolTest(t, true)
+	pool, err := NewRemotePool(context.Background(), cconn)
+	require.NoError(t, err)
+
+	var paths []string
+	pool.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		paths = append(paths, fd.Path())
+		return true
+	})
+	// service files and their dependencies
+	require.Contains(t, paths, "grpc/dummy.proto")
+	require.Contains(t, paths, "desc_test1.proto")
+	require.Contains(t, paths, "pkg/desc_test_pkg.proto")
+	require.Contains(t, paths, "grpc/reflection/v1/reflection.proto")
+	require.Equal(t, len(paths), pool.NumFiles())
+
+	d, err := pool.FindDescriptorByName("testprotos.DummyService")
+	require.NoError(t, err)
+	require.Implements(t, (*protoreflect.ServiceDescriptor)(nil), d)
+	fd, err := pool.FindFileByPath("grpc/dummy.proto")
+	require.NoError(t, err)
+	require.Same(t, fd, d.ParentFile())
+	require.Equal(t, 2, pool.NumFilesByPackage("testprotos"))
+
+	// refreshing replaces the snapshot
+	require.NoError(t, pool.Refresh(context.Background()))
+	refreshed, err := pool.FindFileByPath("grpc/dummy.proto")
+	require.NoError(t, err)
+	require.NotSame(t, fd, refreshed)
+	require.Equal(t, len(paths), pool.NumFiles())
+}
+
+func TestRemotePool_NoReflection(t *testing.T) {
+	cconn := startServerForPoolTest(t, false)
+	_, err := NewRemotePool(context.Background(), cconn)
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
@@ -1,10 +1,14 @@
 package grpcreflect
 
+//lint:file-ignore SA1019 The refv1alpha package is deprecated, but we still register it for older clients
+
 import (
 	"fmt"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
+	refv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	refv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 
@@ -16,6 +20,49 @@ import (
 // accessor for retrieving metadata about all registered services.
 type GRPCServer = reflection.GRPCServer
 
+// Register registers the server reflection service on the given gRPC server.
+// Both the v1 and v1alpha versions are registered. Unlike [reflection.Register],
+// the given resolver, instead of the global registries, is used to answer
+// queries for files, symbols, and extensions. The set of services advertised
+// is those registered with srv.
+func Register(srv GRPCServer, resolver protoresolve.Resolver) {
+	opts := reflection.ServerOptions{
+		Services:           srv,
+		DescriptorResolver: resolver,
+		ExtensionResolver:  extensionResolver{resolver},
+	}
+	refv1alpha.RegisterServerReflectionServer(srv, reflection.NewServer(opts))
+	refv1.RegisterServerReflectionServer(srv, reflection.NewServerV1(opts))
+}
+
+// extensionResolver adapts a protoresolve.Resolver to the
+// reflection.ExtensionResolver interface.
+type extensionResolver struct {
+	res protoresolve.Resolver
+}
+
+func (r extensionResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	ext, err := r.res.FindExtensionByName(field)
+	if err != nil {
+		return nil, err
+	}
+	return protoresolve.ExtensionType(ext), nil
+}
+
+func (r extensionResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	ext, err := r.res.FindExtensionByNumber(message, field)
+	if err != nil {
+		return nil, err
+	}
+	return protoresolve.ExtensionType(ext), nil
+}
+
+func (r extensionResolver) RangeExtensionsByMessage(message protoreflect.FullName, fn func(protoreflect.ExtensionType) bool) {
+	r.res.RangeExtensionsByMessage(message, func(ext protoreflect.ExtensionDescriptor) bool {
+		return fn(protoresolve.ExtensionType(ext))
+	})
+}
+
 // LoadServiceDescriptors loads the service descriptors for all services exposed by the
 // given GRPC server.
 func LoadServiceDescriptors(s GRPCServer) (map[string]protoreflect.ServiceDescriptor, error) {
@@ -1,13 +1,21 @@
 package grpcreflect
 
+//lint:file-ignore SA1019 The refv1alpha package is deprecated, but we need it in order to test the v1alpha service
+
 import (
+	"context"
+	"net"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	refv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	refv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	"google.golang.org/protobuf/reflect/protoreflect"
 
 	testprotosgrpc "github.com/jhump/protoreflect/v2/internal/testprotos/grpc"
+	"github.com/jhump/protoreflect/v2/protoresolve"
 )
 
 type testService struct {
@@ -53,3 +61,69 @@ func checkServiceDescriptor(t *testing.T, sd protoreflect.ServiceDescriptor) {
 		require.Equal(t, c.response, md.Output().FullName())
 	}
 }
+
+func TestRegister(t *testing.T) {
+	var reg protoresolve.Registry
+	var registerWithDeps func(fd protoreflect.FileDescriptor)
+	registerWithDeps = func(fd protoreflect.FileDescriptor) {
+		if _, err := reg.FindFileByPath(fd.Path()); err == nil {
+			return
+		}
+		imports := fd.Imports()
+		for i, length := 0, imports.Len(); i < length; i++ {
+			registerWithDeps(imports.Get(i).FileDescriptor)
+		}
+		require.NoError(t, reg.RegisterFile(fd))
+	}
+	registerWithDeps(testprotosgrpc.File_grpc_dummy_proto)
+
+	svr := grpc.NewServer()
+	testprotosgrpc.RegisterDummyServiceServer(svr, testService{})
+	Register(svr, &reg)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		_ = svr.Serve(l)
+	}()
+	defer svr.Stop()
+	cconn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() {
+		_ = cconn.Close()
+	}()
+
+	clients := map[string]*Client{
+		"v1":      NewClientV1(context.Background(), refv1.NewServerReflectionClient(cconn)),
+		"v1alpha": NewClientV1Alpha(context.Background(), refv1alpha.NewServerReflectionClient(cconn)),
+	}
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			defer client.Reset()
+
+			svcs, err := client.ListServices()
+			require.NoError(t, err)
+			require.Contains(t, svcs, protoreflect.FullName("testprotos.DummyService"))
+			require.Contains(t, svcs, protoreflect.FullName("grpc.reflection.v1.ServerReflection"))
+
+			fd, err := client.FileContainingSymbol("testprotos.DummyService")
+			require.NoError(t, err)
+			require.Equal(t, "grpc/dummy.proto", fd.Path())
+			sd := fd.Services().ByName("DummyService")
+			require.NotNil(t, sd)
+			checkServiceDescriptor(t, sd)
+
+			fd, err = client.FileContainingExtension("testprotos.AnotherTestMessage", 100)
+			require.NoError(t, err)
+			require.Equal(t, "desc_test1.proto", fd.Path())
+			extNums, err := client.AllExtensionNumbersForType("testprotos.AnotherTestMessage")
+			require.NoError(t, err)
+			require.Len(t, extNums, 5)
+
+			// files that are linked into the program, but not in the registry, are not found
+			_, err = client.FileContainingSymbol("grpc.testing.SimpleRequest")
+			require.True(t, IsElementNotFoundError(err), "unexpected error: %v", err)
+			_, err = client.FileByFilename("grpc/test.proto")
+			require.True(t, IsElementNotFoundError(err), "unexpected error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Package httprule extracts the HTTP bindings of RPC methods from their
+// "google.api.http" options, for use by packages that transcode or document
+// HTTP/JSON APIs.
+package httprule
+
+import (
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/protohttp"
+)
+
+// Binding is a single HTTP method and path template that is bound to an RPC
+// method.
+type Binding struct {
+	Method string
+	// Path is the path template, which may contain variables, like
+	// "/v1/{name=shelves/*}".
+	Path string
+	// Body is "" for no body, "*" for the entire input message, or the name of
+	// a field of the input message.
+	Body string
+	// ResponseBody is "" for the entire output message, or the name of a
+	// field of the output message.
+	ResponseBody string
+}
+
+// Bindings returns the bindings defined by the given method's
+// "google.api.http" option, including any additional bindings, in the order in
+// which they are declared. It returns nil if the method has no such option.
+func Bindings(md protoreflect.MethodDescriptor) []Binding {
+	rule, ok := protohttp.GetHTTPRule(md)
+	if !ok {
+		return nil
+	}
+	var bindings []Binding
+	addBindings(rule, &bindings)
+	return bindings
+}
+
+func addBindings(rule *annotations.HttpRule, bindings *[]Binding) {
+	if method, path := protohttp.HTTPMethodAndPath(rule); method != "" {
+		*bindings = append(*bindings, Binding{
+			Method:       method,
+			Path:         path,
+			Body:         rule.GetBody(),
+			ResponseBody: rule.GetResponseBody(),
+		})
+	}
+	for _, additional := range rule.GetAdditionalBindings() {
+		addBindings(additional, bindings)
+	}
+}
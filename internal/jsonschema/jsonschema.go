@@ -0,0 +1,295 @@
+// Package jsonschema maps protobuf messages and enums to JSON schemas that
+// describe their JSON format, for use by packages that generate JSON Schema
+// and OpenAPI documents.
+//
+// The schemas follow the mapping used by the
+// [google.golang.org/protobuf/encoding/protojson] package, including the
+// special representations of well-known types.
+package jsonschema
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Dialect is the flavor of JSON schema to generate.
+type Dialect int
+
+const (
+	// Draft07 is JSON Schema draft-07. Values that can be written as more than
+	// one JSON type, like 64-bit integers, are described with a list of types.
+	Draft07 = Dialect(iota)
+	// OpenAPI30 is the schema object of OpenAPI 3.0, which allows only a single
+	// type. Values are described by their canonical JSON type, along with a
+	// format that indicates their range, and "null" types are replaced with the
+	// "nullable" keyword.
+	OpenAPI30
+)
+
+// Schema is a JSON schema. Its keywords are the union of those used by the
+// supported dialects.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+}
+
+// Generator accumulates the schemas for messages and enums. Each message and
+// enum is described once, in Defs, and is referred to elsewhere via "$ref", so
+// recursive messages are supported.
+type Generator struct {
+	// Defs are the schemas for all messages and enums referenced so far, keyed
+	// by their fully-qualified names.
+	Defs map[string]*Schema
+
+	dialect   Dialect
+	refPrefix string
+}
+
+// NewGenerator returns a generator for the given dialect. A reference to a
+// message or enum is the given prefix followed by its fully-qualified name,
+// so the prefix should indicate where the caller puts Defs in the document.
+func NewGenerator(dialect Dialect, refPrefix string) *Generator {
+	return &Generator{
+		Defs:      map[string]*Schema{},
+		dialect:   dialect,
+		refPrefix: refPrefix,
+	}
+}
+
+// MessageRef adds the schema for the given message, and for all messages and
+// enums it references, to Defs. It returns a schema that refers to it.
+func (g *Generator) MessageRef(md protoreflect.MessageDescriptor) *Schema {
+	g.addMessage(md)
+	return g.ref(md)
+}
+
+// FieldSchema returns the schema for the values of the given field, adding the
+// schemas for any messages and enums it references to Defs.
+func (g *Generator) FieldSchema(fld protoreflect.FieldDescriptor) *Schema {
+	switch {
+	case fld.IsMap():
+		// JSON object keys are always strings, regardless of map key type
+		return &Schema{
+			Type:                 "object",
+			AdditionalProperties: g.singularSchema(fld.MapValue()),
+		}
+	case fld.IsList():
+		return &Schema{
+			Type:  "array",
+			Items: g.singularSchema(fld),
+		}
+	default:
+		return g.singularSchema(fld)
+	}
+}
+
+// WellKnownSchema returns the schema for well-known types that have a special
+// JSON representation. It returns nil if md is not such a type.
+func (g *Generator) WellKnownSchema(md protoreflect.MessageDescriptor) *Schema {
+	if md.ParentFile().Package() != "google.protobuf" {
+		return nil
+	}
+	switch md.Name() {
+	case "Any", "Struct":
+		return &Schema{Type: "object"}
+	case "Value":
+		// any JSON value
+		return &Schema{}
+	case "ListValue":
+		return &Schema{Type: "array", Items: &Schema{}}
+	case "Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "Duration", "FieldMask":
+		return &Schema{Type: "string"}
+	case "BoolValue", "Int32Value", "Int64Value", "UInt32Value", "UInt64Value",
+		"FloatValue", "DoubleValue", "StringValue", "BytesValue":
+		if fld := md.Fields().ByName("value"); fld != nil {
+			s := g.scalarSchema(fld.Kind())
+			if g.dialect == OpenAPI30 {
+				s.Nullable = true
+			}
+			return s
+		}
+	}
+	return nil
+}
+
+// Description returns the leading comments of the given descriptor, if its
+// file has source code info.
+func Description(d protoreflect.Descriptor) string {
+	loc := d.ParentFile().SourceLocations().ByDescriptor(d)
+	return strings.TrimSpace(loc.LeadingComments)
+}
+
+func (g *Generator) ref(d protoreflect.Descriptor) *Schema {
+	return &Schema{Ref: g.refPrefix + string(d.FullName())}
+}
+
+func (g *Generator) addMessage(md protoreflect.MessageDescriptor) {
+	name := string(md.FullName())
+	if _, ok := g.Defs[name]; ok {
+		return
+	}
+	if s := g.WellKnownSchema(md); s != nil {
+		g.Defs[name] = s
+		return
+	}
+	s := &Schema{
+		Type:        "object",
+		Description: Description(md),
+		Properties:  map[string]*Schema{},
+	}
+	// add before processing fields, in case of recursion
+	g.Defs[name] = s
+
+	fields := md.Fields()
+	for i, length := 0, fields.Len(); i < length; i++ {
+		fld := fields.Get(i)
+		prop := g.FieldSchema(fld)
+		if desc := Description(fld); desc != "" {
+			if prop.Ref != "" {
+				// other keywords are ignored alongside $ref
+				prop = &Schema{AllOf: []*Schema{prop}}
+			}
+			prop.Description = desc
+		}
+		s.Properties[fld.JSONName()] = prop
+	}
+
+	oneofs := md.Oneofs()
+	for i, length := 0, oneofs.Len(); i < length; i++ {
+		ood := oneofs.Get(i)
+		if ood.IsSynthetic() {
+			continue
+		}
+		constraint := oneofConstraint(ood)
+		if s.OneOf == nil && len(s.AllOf) == 0 {
+			s.OneOf = constraint
+			continue
+		}
+		if s.OneOf != nil {
+			// multiple oneofs: each becomes its own "oneOf" inside "allOf"
+			s.AllOf = append(s.AllOf, &Schema{OneOf: s.OneOf})
+			s.OneOf = nil
+		}
+		s.AllOf = append(s.AllOf, &Schema{OneOf: constraint})
+	}
+}
+
+// oneofConstraint returns alternatives that match if exactly one of the oneof's
+// fields is present or if none of them are.
+func oneofConstraint(ood protoreflect.OneofDescriptor) []*Schema {
+	fields := ood.Fields()
+	alternatives := make([]*Schema, 0, fields.Len()+1)
+	for i, length := 0, fields.Len(); i < length; i++ {
+		alternatives = append(alternatives, &Schema{Required: []string{fields.Get(i).JSONName()}})
+	}
+	none := &Schema{Not: &Schema{AnyOf: append([]*Schema(nil), alternatives...)}}
+	return append(alternatives, none)
+}
+
+func (g *Generator) addEnum(ed protoreflect.EnumDescriptor) {
+	name := string(ed.FullName())
+	if _, ok := g.Defs[name]; ok {
+		return
+	}
+	if ed.FullName() == "google.protobuf.NullValue" {
+		if g.dialect == OpenAPI30 {
+			g.Defs[name] = &Schema{Nullable: true}
+		} else {
+			g.Defs[name] = &Schema{Type: "null"}
+		}
+		return
+	}
+	vals := ed.Values()
+	names := make([]any, vals.Len())
+	for i := range names {
+		names[i] = string(vals.Get(i).Name())
+	}
+	g.Defs[name] = &Schema{
+		Type:        "string",
+		Description: Description(ed),
+		Enum:        names,
+	}
+}
+
+func (g *Generator) singularSchema(fld protoreflect.FieldDescriptor) *Schema {
+	switch fld.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.MessageRef(fld.Message())
+	case protoreflect.EnumKind:
+		g.addEnum(fld.Enum())
+		return g.ref(fld.Enum())
+	default:
+		return g.scalarSchema(fld.Kind())
+	}
+}
+
+func (g *Generator) scalarSchema(kind protoreflect.Kind) *Schema {
+	if g.dialect == OpenAPI30 {
+		return openAPIScalarSchema(kind)
+	}
+	switch kind {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// 64-bit integers are written as strings, but numbers are also accepted
+		return &Schema{Type: []string{"integer", "string"}}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		// non-finite values are written as strings: "NaN", "Infinity", "-Infinity"
+		return &Schema{Type: []string{"number", "string"}}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", ContentEncoding: "base64"}
+	default:
+		// should not be possible
+		return &Schema{}
+	}
+}
+
+func openAPIScalarSchema(kind protoreflect.Kind) *Schema {
+	switch kind {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// written as strings, since not all values can be represented exactly
+		// by a JSON number
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	default:
+		// should not be possible
+		return &Schema{}
+	}
+}
@@ -18,16 +18,19 @@ func SortFiles(files []*descriptorpb.FileDescriptorProto) error {
 		}
 		allFiles[file.GetName()] = fileState{file: file}
 	}
-	origLen := len(files)
-	files = files[:0]
-	for _, file := range files {
-		if err := addFileSorted(file, allFiles, &files); err != nil {
+	origFiles := make([]*descriptorpb.FileDescriptorProto, len(files))
+	copy(origFiles, files)
+	// sorted shares the backing array with files, so the
+	// caller's slice is re-ordered in place
+	sorted := files[:0]
+	for _, file := range origFiles {
+		if err := addFileSorted(file, allFiles, &sorted); err != nil {
 			return err
 		}
 	}
-	if origLen != len(files) {
+	if len(origFiles) != len(sorted) {
 		// should not be possible since we've already removed duplicates...
-		return fmt.Errorf("internal: sorted files has length %d, but original had length %d", len(files), origLen)
+		return fmt.Errorf("internal: sorted files has length %d, but original had length %d", len(sorted), len(origFiles))
 	}
 	return nil
 }
@@ -0,0 +1,521 @@
+// Package protocbor marshals and unmarshals protobuf messages to and from CBOR
+// (RFC 8949), a compact binary format with a data model similar to JSON.
+//
+// The format mirrors the JSON format defined by the protobuf spec: messages are
+// CBOR maps whose keys are the JSON names of their fields, and extensions use
+// keys that are the extension's fully-qualified name in brackets. Unlike JSON,
+// CBOR has native support for binary data and for 64-bit integers. So bytes
+// fields are encoded as CBOR byte strings, and all integer fields are encoded
+// as CBOR integers. Map fields are encoded as CBOR maps, but keys have the
+// field's key type instead of always being strings. Enum values are encoded as
+// their names, like in JSON, unless the number is not a known value of the
+// enum, in which case the number is used.
+//
+// Well-known types that have special representations in JSON, such as
+// google.protobuf.Timestamp and google.protobuf.Struct, use that same
+// representation in CBOR. For example, a Timestamp is encoded as a string in
+// RFC 3339 format.
+//
+// This works with both generated messages and dynamic messages.
+package protocbor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// wellKnownTypes are the message types whose JSON format is not a simple object
+// with a key for each field. These are encoded to and decoded from CBOR using
+// the same structure as their JSON representation.
+var wellKnownTypes = map[protoreflect.FullName]struct{}{
+	"google.protobuf.Any":         {},
+	"google.protobuf.Timestamp":   {},
+	"google.protobuf.Duration":    {},
+	"google.protobuf.FieldMask":   {},
+	"google.protobuf.Struct":      {},
+	"google.protobuf.Value":       {},
+	"google.protobuf.ListValue":   {},
+	"google.protobuf.DoubleValue": {},
+	"google.protobuf.FloatValue":  {},
+	"google.protobuf.Int64Value":  {},
+	"google.protobuf.UInt64Value": {},
+	"google.protobuf.Int32Value":  {},
+	"google.protobuf.UInt32Value": {},
+	"google.protobuf.BoolValue":   {},
+	"google.protobuf.StringValue": {},
+	"google.protobuf.BytesValue":  {},
+}
+
+var encMode = func() cbor.EncMode {
+	// Core deterministic encoding sorts map keys, so the output is stable.
+	mode, err := cbor.CoreDetEncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}()
+
+// Marshal returns the CBOR encoding of the given message.
+func Marshal(msg proto.Message) ([]byte, error) {
+	val, err := messageToValue(msg.ProtoReflect())
+	if err != nil {
+		return nil, err
+	}
+	return encMode.Marshal(val)
+}
+
+// Unmarshal parses the given CBOR data into msg. The message is reset before
+// the data is unmarshalled into it. Extensions are resolved using
+// [protoregistry.GlobalTypes].
+//
+// In addition to the format produced by Marshal, this also accepts some of the
+// alternate forms that [protojson.Unmarshal] accepts. So fields may be named
+// using their proto names, enum values may be numbers, integers may be strings,
+// bytes may be base64-encoded strings, and a null value is the same as omitting
+// the field.
+func Unmarshal(data []byte, msg proto.Message) error {
+	proto.Reset(msg)
+	var val any
+	if err := cbor.Unmarshal(data, &val); err != nil {
+		return err
+	}
+	return valueToMessage(val, msg.ProtoReflect())
+}
+
+func messageToValue(msg protoreflect.Message) (any, error) {
+	if _, ok := wellKnownTypes[msg.Descriptor().FullName()]; ok {
+		return wellKnownToValue(msg)
+	}
+	result := map[string]any{}
+	var err error
+	msg.Range(func(fd protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		var v any
+		v, err = fieldToValue(fd, val)
+		if err != nil {
+			return false
+		}
+		result[fieldKey(fd)] = v
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func fieldKey(fd protoreflect.FieldDescriptor) string {
+	if fd.IsExtension() {
+		return "[" + string(fd.FullName()) + "]"
+	}
+	return fd.JSONName()
+}
+
+func fieldToValue(fd protoreflect.FieldDescriptor, val protoreflect.Value) (any, error) {
+	switch {
+	case fd.IsList():
+		list := val.List()
+		result := make([]any, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			v, err := singularToValue(fd, list.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = v
+		}
+		return result, nil
+	case fd.IsMap():
+		result := map[any]any{}
+		var err error
+		val.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			var mapVal any
+			mapVal, err = singularToValue(fd.MapValue(), v)
+			if err != nil {
+				return false
+			}
+			result[k.Interface()] = mapVal
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	default:
+		return singularToValue(fd, val)
+	}
+}
+
+func singularToValue(fd protoreflect.FieldDescriptor, val protoreflect.Value) (any, error) {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if fd.Enum().FullName() == "google.protobuf.NullValue" {
+			return nil, nil
+		}
+		if enumVal := fd.Enum().Values().ByNumber(val.Enum()); enumVal != nil {
+			return string(enumVal.Name()), nil
+		}
+		return int64(val.Enum()), nil
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageToValue(val.Message())
+	case protoreflect.FloatKind:
+		return float32(val.Float()), nil
+	default:
+		return val.Interface(), nil
+	}
+}
+
+func wellKnownToValue(msg protoreflect.Message) (any, error) {
+	data, err := protojson.Marshal(msg.Interface())
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var val any
+	if err := dec.Decode(&val); err != nil {
+		return nil, err
+	}
+	return fromJSON(val), nil
+}
+
+// fromJSON replaces JSON numbers in the given value, which was decoded from
+// JSON, with integers or floats.
+func fromJSON(val any) any {
+	switch val := val.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]any:
+		for k, v := range val {
+			val[k] = fromJSON(v)
+		}
+		return val
+	case []any:
+		for i, v := range val {
+			val[i] = fromJSON(v)
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+// toJSON converts the given value, which was decoded from CBOR, into a value
+// that can be marshalled to JSON.
+func toJSON(val any) any {
+	switch val := val.(type) {
+	case map[any]any:
+		result := make(map[string]any, len(val))
+		for k, v := range val {
+			result[fmt.Sprint(k)] = toJSON(v)
+		}
+		return result
+	case []any:
+		result := make([]any, len(val))
+		for i, v := range val {
+			result[i] = toJSON(v)
+		}
+		return result
+	case float64:
+		switch {
+		case math.IsNaN(val):
+			return "NaN"
+		case math.IsInf(val, 1):
+			return "Infinity"
+		case math.IsInf(val, -1):
+			return "-Infinity"
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+func valueToMessage(val any, msg protoreflect.Message) error {
+	md := msg.Descriptor()
+	if _, ok := wellKnownTypes[md.FullName()]; ok {
+		data, err := json.Marshal(toJSON(val))
+		if err != nil {
+			return err
+		}
+		return protojson.Unmarshal(data, msg.Interface())
+	}
+	fields, ok := val.(map[any]any)
+	if !ok {
+		return fmt.Errorf("expecting map for message %s, got %T", md.FullName(), val)
+	}
+	for k, v := range fields {
+		name, ok := k.(string)
+		if !ok {
+			return fmt.Errorf("message %s: expecting string keys, got %T", md.FullName(), k)
+		}
+		fd, err := findField(md, name)
+		if err != nil {
+			return err
+		}
+		if v == nil && !isValueMessage(fd) {
+			continue
+		}
+		if err := setField(msg, fd, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findField(md protoreflect.MessageDescriptor, name string) (protoreflect.FieldDescriptor, error) {
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		xt, err := protoregistry.GlobalTypes.FindExtensionByName(protoreflect.FullName(name[1 : len(name)-1]))
+		if err != nil {
+			return nil, fmt.Errorf("message %s: could not resolve extension %s: %w", md.FullName(), name, err)
+		}
+		fd := xt.TypeDescriptor()
+		if fd.ContainingMessage().FullName() != md.FullName() {
+			return nil, fmt.Errorf("message %s: extension %s extends %s", md.FullName(), name, fd.ContainingMessage().FullName())
+		}
+		return fd, nil
+	}
+	fd := md.Fields().ByJSONName(name)
+	if fd == nil {
+		fd = md.Fields().ByName(protoreflect.Name(name))
+	}
+	if fd == nil {
+		return nil, fmt.Errorf("message %s has no field named %q", md.FullName(), name)
+	}
+	return fd, nil
+}
+
+// isValueMessage returns true if fd is a field whose type is
+// google.protobuf.Value, for which null is a valid value.
+func isValueMessage(fd protoreflect.FieldDescriptor) bool {
+	return fd.Message() != nil && fd.Message().FullName() == "google.protobuf.Value"
+}
+
+func setField(msg protoreflect.Message, fd protoreflect.FieldDescriptor, val any) error {
+	switch {
+	case fd.IsList():
+		elems, ok := val.([]any)
+		if !ok {
+			return fmt.Errorf("field %s: expecting array, got %T", fd.FullName(), val)
+		}
+		list := msg.Mutable(fd).List()
+		for _, elem := range elems {
+			v, err := valueToSingular(fd, elem, list.NewElement)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	case fd.IsMap():
+		entries, ok := val.(map[any]any)
+		if !ok {
+			return fmt.Errorf("field %s: expecting map, got %T", fd.FullName(), val)
+		}
+		m := msg.Mutable(fd).Map()
+		for k, v := range entries {
+			key, err := valueToSingular(fd.MapKey(), k, nil)
+			if err != nil {
+				return err
+			}
+			mapVal, err := valueToSingular(fd.MapValue(), v, m.NewValue)
+			if err != nil {
+				return err
+			}
+			m.Set(key.MapKey(), mapVal)
+		}
+		return nil
+	default:
+		v, err := valueToSingular(fd, val, func() protoreflect.Value { return msg.NewField(fd) })
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, v)
+		return nil
+	}
+}
+
+// valueToSingular converts the given value, decoded from CBOR, into a value for
+// the given field. The newMessage function is used to create values for message
+// fields; it is not used for other kinds of fields.
+func valueToSingular(fd protoreflect.FieldDescriptor, val any, newMessage func() protoreflect.Value) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		msgVal := newMessage()
+		if err := valueToMessage(val, msgVal.Message()); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return msgVal, nil
+	case protoreflect.BoolKind:
+		if b, ok := val.(bool); ok {
+			return protoreflect.ValueOfBool(b), nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := toInt(val, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		return protoreflect.ValueOfInt32(int32(i)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := toInt(val, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		return protoreflect.ValueOfInt64(i), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := toUint(val, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		return protoreflect.ValueOfUint32(uint32(u)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := toUint(val, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		return protoreflect.ValueOfUint64(u), nil
+	case protoreflect.FloatKind:
+		f, err := toFloat(val)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		return protoreflect.ValueOfFloat32(float32(f)), nil
+	case protoreflect.DoubleKind:
+		f, err := toFloat(val)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+	case protoreflect.StringKind:
+		if s, ok := val.(string); ok {
+			return protoreflect.ValueOfString(s), nil
+		}
+	case protoreflect.BytesKind:
+		switch val := val.(type) {
+		case []byte:
+			return protoreflect.ValueOfBytes(val), nil
+		case string:
+			b, err := base64.StdEncoding.DecodeString(val)
+			if err != nil {
+				b, err = base64.URLEncoding.DecodeString(val)
+			}
+			if err != nil {
+				return protoreflect.Value{}, fmt.Errorf("field %s: invalid base64 string: %w", fd.FullName(), err)
+			}
+			return protoreflect.ValueOfBytes(b), nil
+		}
+	case protoreflect.EnumKind:
+		if val == nil {
+			return protoreflect.ValueOfEnum(0), nil
+		}
+		if name, ok := val.(string); ok {
+			enumVal := fd.Enum().Values().ByName(protoreflect.Name(name))
+			if enumVal == nil {
+				return protoreflect.Value{}, fmt.Errorf("field %s: %q is not a known value of %s", fd.FullName(), name, fd.Enum().FullName())
+			}
+			return protoreflect.ValueOfEnum(enumVal.Number()), nil
+		}
+		i, err := toInt(val, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("field %s: cannot use %T as %s value", fd.FullName(), val, fd.Kind())
+}
+
+func toInt(val any, bits int) (int64, error) {
+	var i int64
+	switch val := val.(type) {
+	case int64:
+		i = val
+	case uint64:
+		if val > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d overflows int%d", val, bits)
+		}
+		i = int64(val)
+	case float64:
+		if val != math.Trunc(val) || val < math.MinInt64 || val >= math.MaxInt64 {
+			return 0, fmt.Errorf("value %v is not an int%d", val, bits)
+		}
+		i = int64(val)
+	case string:
+		var err error
+		i, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("cannot use %T as int%d value", val, bits)
+	}
+	if bits == 32 && int64(int32(i)) != i {
+		return 0, fmt.Errorf("value %d overflows int%d", i, bits)
+	}
+	return i, nil
+}
+
+func toUint(val any, bits int) (uint64, error) {
+	var u uint64
+	switch val := val.(type) {
+	case uint64:
+		u = val
+	case int64:
+		if val < 0 {
+			return 0, fmt.Errorf("value %d is not a uint%d", val, bits)
+		}
+		u = uint64(val)
+	case float64:
+		if val != math.Trunc(val) || val < 0 || val >= math.MaxUint64 {
+			return 0, fmt.Errorf("value %v is not a uint%d", val, bits)
+		}
+		u = uint64(val)
+	case string:
+		var err error
+		u, err = strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("cannot use %T as uint%d value", val, bits)
+	}
+	if bits == 32 && u > math.MaxUint32 {
+		return 0, fmt.Errorf("value %d overflows uint%d", u, bits)
+	}
+	return u, nil
+}
+
+func toFloat(val any) (float64, error) {
+	switch val := val.(type) {
+	case float64:
+		return val, nil
+	case int64:
+		return float64(val), nil
+	case uint64:
+		return float64(val), nil
+	case string:
+		switch val {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return strconv.ParseFloat(val, 64)
+	default:
+		return 0, fmt.Errorf("cannot use %T as float value", val)
+	}
+}
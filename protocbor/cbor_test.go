@@ -0,0 +1,171 @@
+package protocbor
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+func TestMarshal(t *testing.T) {
+	msg := &testprotos.UnaryFields{
+		I: proto.Int32(-1),
+		J: proto.Int64(math.MaxInt64),
+		K: proto.Int32(-3),
+		L: proto.Int64(-4),
+		M: proto.Uint32(5),
+		N: proto.Uint64(math.MaxUint64),
+		O: proto.Uint32(7),
+		P: proto.Uint64(8),
+		Q: proto.Int32(-9),
+		R: proto.Int64(-10),
+		S: proto.Float32(1.5),
+		T: proto.Float64(math.Inf(-1)),
+		U: []byte{0, 1, 2, 3},
+		V: proto.String("abc"),
+		W: proto.Bool(true),
+		X: &testprotos.RepeatedFields{
+			I: []int32{1, 2, 3},
+			Z: []testprotos.TestEnum{testprotos.TestEnum_FIRST, 99},
+		},
+		Groupy: &testprotos.UnaryFields_GroupY{Ya: proto.String("ya")},
+		Z:      testprotos.TestEnum_SECOND.Enum(),
+	}
+	data, err := Marshal(msg)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, cbor.Unmarshal(data, &raw))
+	assert.Equal(t, int64(-1), raw["i"])
+	assert.Equal(t, uint64(math.MaxInt64), raw["j"])
+	assert.Equal(t, uint64(math.MaxUint64), raw["n"])
+	assert.Equal(t, 1.5, raw["s"])
+	assert.Equal(t, math.Inf(-1), raw["t"])
+	assert.Equal(t, []byte{0, 1, 2, 3}, raw["u"])
+	assert.Equal(t, "SECOND", raw["z"])
+	assert.Equal(t, map[any]any{"ya": "ya"}, raw["groupy"])
+	assert.Equal(t, map[any]any{"i": []any{uint64(1), uint64(2), uint64(3)}, "z": []any{"FIRST", uint64(99)}}, raw["x"])
+
+	// deterministic
+	again, err := Marshal(msg)
+	require.NoError(t, err)
+	require.Equal(t, data, again)
+
+	// round-trip through both generated and dynamic messages
+	var roundTripped testprotos.UnaryFields
+	require.NoError(t, Unmarshal(data, &roundTripped))
+	require.True(t, proto.Equal(msg, &roundTripped))
+	dyn := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+	require.NoError(t, Unmarshal(data, dyn))
+	require.True(t, proto.Equal(msg, dyn))
+	dynData, err := Marshal(dyn)
+	require.NoError(t, err)
+	require.Equal(t, data, dynData)
+}
+
+func TestMarshal_MapsAndExtensions(t *testing.T) {
+	msg := &testprotos.AnotherTestMessage{
+		MapField1: map[int32]string{1: "one", -2: "minus two"},
+		MapField3: map[uint32]bool{3: true},
+		MapField4: map[string]*testprotos.AnotherTestMessage{
+			"abc": {MapField2: map[int64]float32{4: 4.5}},
+		},
+	}
+	proto.SetExtension(msg, testprotos.E_Xtm, &testprotos.TestMessage{
+		Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE2},
+	})
+	data, err := Marshal(msg)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, cbor.Unmarshal(data, &raw))
+	assert.Equal(t, map[any]any{uint64(1): "one", int64(-2): "minus two"}, raw["mapField1"])
+	assert.Equal(t, map[any]any{"ne": []any{"VALUE2"}}, raw["[testprotos.xtm]"])
+
+	var roundTripped testprotos.AnotherTestMessage
+	require.NoError(t, Unmarshal(data, &roundTripped))
+	require.True(t, proto.Equal(msg, &roundTripped))
+}
+
+func TestMarshal_WellKnownTypes(t *testing.T) {
+	msg := &testprotos.TestWellKnownTypes{
+		StartTime: &timestamppb.Timestamp{Seconds: 1700000000, Nanos: 500000000},
+		Elapsed:   &durationpb.Duration{Seconds: 90},
+		Byt:       wrapperspb.Bytes([]byte("hello")),
+		I64:       wrapperspb.Int64(123),
+		Json:      []*structpb.Value{structpb.NewNullValue(), structpb.NewNumberValue(1.5), structpb.NewStringValue("abc")},
+	}
+	data, err := Marshal(msg)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, cbor.Unmarshal(data, &raw))
+	assert.Equal(t, "2023-11-14T22:13:20.500Z", raw["startTime"])
+	assert.Equal(t, "90s", raw["elapsed"])
+	assert.Equal(t, "aGVsbG8=", raw["byt"])
+	assert.Equal(t, "123", raw["i64"])
+	assert.Equal(t, []any{nil, 1.5, "abc"}, raw["json"])
+
+	var roundTripped testprotos.TestWellKnownTypes
+	require.NoError(t, Unmarshal(data, &roundTripped))
+	require.True(t, proto.Equal(msg, &roundTripped))
+}
+
+func TestUnmarshal(t *testing.T) {
+	// alternate forms accepted by protojson
+	data, err := cbor.Marshal(map[string]any{
+		"t":      "NaN",
+		"j":      "-123",
+		"u":      "AAECAw==",
+		"z":      2,
+		"v":      nil,
+		"groupy": map[string]any{"yb": 1.0},
+	})
+	require.NoError(t, err)
+	var msg testprotos.UnaryFields
+	require.NoError(t, Unmarshal(data, &msg))
+	assert.True(t, math.IsNaN(msg.GetT()))
+	assert.Equal(t, int64(-123), msg.GetJ())
+	assert.Equal(t, []byte{0, 1, 2, 3}, msg.GetU())
+	assert.Equal(t, testprotos.TestEnum_SECOND, msg.GetZ())
+	assert.Nil(t, msg.V)
+	assert.Equal(t, int32(1), msg.GetGroupy().GetYb())
+
+	// fields may use proto names instead of JSON names
+	data, err = cbor.Marshal(map[string]any{"map_field1": map[int]string{1: "one"}})
+	require.NoError(t, err)
+	var other testprotos.AnotherTestMessage
+	require.NoError(t, Unmarshal(data, &other))
+	assert.Equal(t, map[int32]string{1: "one"}, other.MapField1)
+
+	testCases := []struct {
+		name   string
+		data   any
+		errMsg string
+	}{
+		{name: "not a map", data: []any{1}, errMsg: "expecting map for message testprotos.UnaryFields, got []interface {}"},
+		{name: "unknown field", data: map[string]any{"nope": 1}, errMsg: `message testprotos.UnaryFields has no field named "nope"`},
+		{name: "wrong type", data: map[string]any{"v": 1}, errMsg: "field testprotos.UnaryFields.v: cannot use uint64 as string value"},
+		{name: "overflow", data: map[string]any{"i": math.MaxInt32 + 1}, errMsg: "field testprotos.UnaryFields.i: value 2147483648 overflows int32"},
+		{name: "negative uint", data: map[string]any{"m": -1}, errMsg: "field testprotos.UnaryFields.m: value -1 is not a uint32"},
+		{name: "unknown enum", data: map[string]any{"z": "NOPE"}, errMsg: `field testprotos.UnaryFields.z: "NOPE" is not a known value of testprotos.TestEnum`},
+		{name: "unknown extension", data: map[string]any{"[foo.bar]": 1}, errMsg: "message testprotos.UnaryFields: could not resolve extension [foo.bar]"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := cbor.Marshal(tc.data)
+			require.NoError(t, err)
+			require.ErrorContains(t, Unmarshal(data, &msg), tc.errMsg)
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package protodescs
+
+import (
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+// ChangeKind describes a kind of backwards-incompatible change to a schema.
+type ChangeKind int
+
+// The various kinds of incompatible changes detected by CheckBackwardCompatibility.
+const (
+	// ChangeKindUnknown is the zero value, which is not a valid kind of change.
+	ChangeKindUnknown = ChangeKind(iota)
+	// ChangeKindMessageRemoved indicates that a message was removed.
+	ChangeKindMessageRemoved
+	// ChangeKindFieldRemoved indicates that a field was removed without
+	// reserving its number.
+	ChangeKindFieldRemoved
+	// ChangeKindFieldTypeChanged indicates that the type of a field changed.
+	ChangeKindFieldTypeChanged
+	// ChangeKindFieldCardinalityChanged indicates that a field changed from
+	// singular to repeated or vice versa.
+	ChangeKindFieldCardinalityChanged
+	// ChangeKindFieldNameChanged indicates that a field's name changed. This
+	// does not impact the binary format but does impact the JSON and text
+	// formats.
+	ChangeKindFieldNameChanged
+	// ChangeKindEnumRemoved indicates that an enum was removed.
+	ChangeKindEnumRemoved
+	// ChangeKindEnumValueRemoved indicates that an enum value was removed
+	// without reserving its number.
+	ChangeKindEnumValueRemoved
+	// ChangeKindEnumValueNameChanged indicates that an enum value's name
+	// changed. This does not impact the binary format but does impact the JSON
+	// and text formats.
+	ChangeKindEnumValueNameChanged
+	// ChangeKindExtensionRemoved indicates that an extension was removed.
+	ChangeKindExtensionRemoved
+	// ChangeKindServiceRemoved indicates that a service was removed.
+	ChangeKindServiceRemoved
+	// ChangeKindMethodRemoved indicates that a method was removed.
+	ChangeKindMethodRemoved
+	// ChangeKindMethodSignatureChanged indicates that a method's request or
+	// response type changed, or that it changed whether the request or
+	// response is streamed.
+	ChangeKindMethodSignatureChanged
+)
+
+// String returns a textual representation of k.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeKindMessageRemoved:
+		return "message removed"
+	case ChangeKindFieldRemoved:
+		return "field removed"
+	case ChangeKindFieldTypeChanged:
+		return "field type changed"
+	case ChangeKindFieldCardinalityChanged:
+		return "field cardinality changed"
+	case ChangeKindFieldNameChanged:
+		return "field name changed"
+	case ChangeKindEnumRemoved:
+		return "enum removed"
+	case ChangeKindEnumValueRemoved:
+		return "enum value removed"
+	case ChangeKindEnumValueNameChanged:
+		return "enum value name changed"
+	case ChangeKindExtensionRemoved:
+		return "extension removed"
+	case ChangeKindServiceRemoved:
+		return "service removed"
+	case ChangeKindMethodRemoved:
+		return "method removed"
+	case ChangeKindMethodSignatureChanged:
+		return "method signature changed"
+	case ChangeKindUnknown:
+		return "unknown"
+	default:
+		return fmt.Sprintf("unknown change kind (%d)", k)
+	}
+}
+
+// CompatibilityIssue describes a backwards-incompatible change to a schema.
+type CompatibilityIssue struct {
+	// The full name of the element in the old schema that was changed
+	// or removed.
+	Name protoreflect.FullName
+	// The kind of change.
+	Kind ChangeKind
+	// A human-readable description of the change.
+	Description string
+}
+
+// String returns a textual representation of the issue.
+func (i CompatibilityIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Name, i.Kind, i.Description)
+}
+
+// CheckBackwardCompatibility compares two versions of a schema and returns the
+// changes in newPool that are not backwards-compatible with oldPool. This
+// includes removed elements and changes to the types, names, or cardinality of
+// fields. Adding new elements is always compatible. Removing a field or enum
+// value is allowed if its number is reserved in the new version.
+//
+// The returned issues are sorted by name.
+func CheckBackwardCompatibility(oldPool, newPool protoresolve.DescriptorPool) []CompatibilityIssue {
+	var c compatChecker
+	protoresolve.RangeDescriptorsByKind(oldPool, protoresolve.DescriptorKindMessage, func(d protoreflect.Descriptor) bool {
+		oldMsg := d.(protoreflect.MessageDescriptor)
+		if oldMsg.IsMapEntry() {
+			// checked as part of the map field
+			return true
+		}
+		newMsg, ok := findDescriptor[protoreflect.MessageDescriptor](newPool, oldMsg.FullName())
+		if !ok {
+			c.report(oldMsg, ChangeKindMessageRemoved, "message %s was removed", oldMsg.FullName())
+			return true
+		}
+		c.checkMessage(oldMsg, newMsg)
+		return true
+	})
+	protoresolve.RangeDescriptorsByKind(oldPool, protoresolve.DescriptorKindEnum, func(d protoreflect.Descriptor) bool {
+		oldEnum := d.(protoreflect.EnumDescriptor)
+		newEnum, ok := findDescriptor[protoreflect.EnumDescriptor](newPool, oldEnum.FullName())
+		if !ok {
+			c.report(oldEnum, ChangeKindEnumRemoved, "enum %s was removed", oldEnum.FullName())
+			return true
+		}
+		c.checkEnum(oldEnum, newEnum)
+		return true
+	})
+	protoresolve.RangeDescriptorsByKind(oldPool, protoresolve.DescriptorKindExtension, func(d protoreflect.Descriptor) bool {
+		oldExt := d.(protoreflect.FieldDescriptor)
+		newExt, ok := findDescriptor[protoreflect.FieldDescriptor](newPool, oldExt.FullName())
+		if !ok || !newExt.IsExtension() {
+			c.report(oldExt, ChangeKindExtensionRemoved, "extension %s was removed", oldExt.FullName())
+			return true
+		}
+		if oldExt.ContainingMessage().FullName() != newExt.ContainingMessage().FullName() || oldExt.Number() != newExt.Number() {
+			c.report(oldExt, ChangeKindExtensionRemoved, "extension %s changed from %s to %s",
+				oldExt.FullName(), extensionTarget(oldExt), extensionTarget(newExt))
+			return true
+		}
+		c.checkFieldType(oldExt, newExt)
+		return true
+	})
+	protoresolve.RangeDescriptorsByKind(oldPool, protoresolve.DescriptorKindService, func(d protoreflect.Descriptor) bool {
+		oldSvc := d.(protoreflect.ServiceDescriptor)
+		newSvc, ok := findDescriptor[protoreflect.ServiceDescriptor](newPool, oldSvc.FullName())
+		if !ok {
+			c.report(oldSvc, ChangeKindServiceRemoved, "service %s was removed", oldSvc.FullName())
+			return true
+		}
+		c.checkService(oldSvc, newSvc)
+		return true
+	})
+	sort.SliceStable(c.issues, func(i, j int) bool {
+		return c.issues[i].Name < c.issues[j].Name
+	})
+	return c.issues
+}
+
+type compatChecker struct {
+	issues []CompatibilityIssue
+}
+
+func (c *compatChecker) report(d protoreflect.Descriptor, kind ChangeKind, format string, args ...any) {
+	c.issues = append(c.issues, CompatibilityIssue{
+		Name:        d.FullName(),
+		Kind:        kind,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *compatChecker) checkMessage(oldMsg, newMsg protoreflect.MessageDescriptor) {
+	oldFields := oldMsg.Fields()
+	for i, length := 0, oldFields.Len(); i < length; i++ {
+		oldField := oldFields.Get(i)
+		newField := newMsg.Fields().ByNumber(oldField.Number())
+		if newField == nil {
+			if !newMsg.ReservedRanges().Has(oldField.Number()) {
+				c.report(oldField, ChangeKindFieldRemoved, "field %s (number %d) was removed without reserving its number",
+					oldField.Name(), oldField.Number())
+			}
+			continue
+		}
+		if oldField.Name() != newField.Name() {
+			c.report(oldField, ChangeKindFieldNameChanged, "field number %d was renamed from %s to %s",
+				oldField.Number(), oldField.Name(), newField.Name())
+		}
+		c.checkFieldType(oldField, newField)
+	}
+}
+
+func (c *compatChecker) checkFieldType(oldField, newField protoreflect.FieldDescriptor) {
+	if oldField.IsList() != newField.IsList() || oldField.IsMap() != newField.IsMap() {
+		c.report(oldField, ChangeKindFieldCardinalityChanged, "field %s changed from %s to %s",
+			oldField.Name(), describeCardinality(oldField), describeCardinality(newField))
+		return
+	}
+	if !sameFieldType(oldField, newField) {
+		c.report(oldField, ChangeKindFieldTypeChanged, "field %s changed type from %s to %s",
+			oldField.Name(), describeType(oldField), describeType(newField))
+	}
+}
+
+func (c *compatChecker) checkEnum(oldEnum, newEnum protoreflect.EnumDescriptor) {
+	oldVals := oldEnum.Values()
+	for i, length := 0, oldVals.Len(); i < length; i++ {
+		oldVal := oldVals.Get(i)
+		newVal := newEnum.Values().ByNumber(oldVal.Number())
+		if newVal == nil {
+			if !newEnum.ReservedRanges().Has(oldVal.Number()) {
+				c.report(oldVal, ChangeKindEnumValueRemoved, "enum value %s (number %d) was removed without reserving its number",
+					oldVal.Name(), oldVal.Number())
+			}
+			continue
+		}
+		if newEnum.Values().ByName(oldVal.Name()) == nil {
+			c.report(oldVal, ChangeKindEnumValueNameChanged, "enum value number %d was renamed from %s to %s",
+				oldVal.Number(), oldVal.Name(), newVal.Name())
+		}
+	}
+}
+
+func (c *compatChecker) checkService(oldSvc, newSvc protoreflect.ServiceDescriptor) {
+	oldMethods := oldSvc.Methods()
+	for i, length := 0, oldMethods.Len(); i < length; i++ {
+		oldMethod := oldMethods.Get(i)
+		newMethod := newSvc.Methods().ByName(oldMethod.Name())
+		if newMethod == nil {
+			c.report(oldMethod, ChangeKindMethodRemoved, "method %s was removed", oldMethod.Name())
+			continue
+		}
+		oldSig, newSig := describeSignature(oldMethod), describeSignature(newMethod)
+		if oldSig != newSig {
+			c.report(oldMethod, ChangeKindMethodSignatureChanged, "method %s changed from %s to %s",
+				oldMethod.Name(), oldSig, newSig)
+		}
+	}
+}
+
+func findDescriptor[D protoreflect.Descriptor](pool protoresolve.DescriptorPool, name protoreflect.FullName) (D, bool) {
+	d, err := pool.FindDescriptorByName(name)
+	if err != nil {
+		var zero D
+		return zero, false
+	}
+	typed, ok := d.(D)
+	return typed, ok
+}
+
+func sameFieldType(a, b protoreflect.FieldDescriptor) bool {
+	if a.Kind() != b.Kind() {
+		return false
+	}
+	switch a.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if a.IsMap() {
+			return sameFieldType(a.MapKey(), b.MapKey()) && sameFieldType(a.MapValue(), b.MapValue())
+		}
+		return a.Message().FullName() == b.Message().FullName()
+	case protoreflect.EnumKind:
+		return a.Enum().FullName() == b.Enum().FullName()
+	default:
+		return true
+	}
+}
+
+func describeCardinality(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsMap():
+		return "map"
+	case fd.IsList():
+		return "repeated"
+	default:
+		return "singular"
+	}
+}
+
+func describeType(fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if fd.IsMap() {
+			return fmt.Sprintf("map<%s, %s>", describeType(fd.MapKey()), describeType(fd.MapValue()))
+		}
+		return string(fd.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(fd.Enum().FullName())
+	default:
+		return fd.Kind().String()
+	}
+}
+
+func describeSignature(md protoreflect.MethodDescriptor) string {
+	var input, output string
+	if md.IsStreamingClient() {
+		input = "stream "
+	}
+	if md.IsStreamingServer() {
+		output = "stream "
+	}
+	return fmt.Sprintf("(%s%s) returns (%s%s)", input, md.Input().FullName(), output, md.Output().FullName())
+}
+
+func extensionTarget(ext protoreflect.FieldDescriptor) string {
+	return fmt.Sprintf("%s:%d", ext.ContainingMessage().FullName(), ext.Number())
+}
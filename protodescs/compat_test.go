@@ -0,0 +1,122 @@
+package protodescs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestCheckBackwardCompatibility(t *testing.T) {
+	oldPool := compileForTest(t, `
+		syntax = "proto3";
+		package test;
+		message Foo {
+			string name = 1;
+			int32 id = 2;
+			repeated string tags = 3;
+			map<string, int32> counts = 4;
+			Bar bar = 5;
+			string removed = 6;
+			string removed_and_reserved = 7;
+			message Nested { string a = 1; }
+		}
+		message Bar {}
+		message Gone {}
+		enum Kind {
+			KIND_UNSPECIFIED = 0;
+			KIND_A = 1;
+			KIND_B = 2;
+			KIND_C = 3;
+		}
+		service Svc {
+			rpc Get(Foo) returns (Bar);
+			rpc List(Foo) returns (stream Bar);
+			rpc Delete(Foo) returns (Bar);
+		}
+		service GoneSvc {}
+	`)
+	newPool := compileForTest(t, `
+		syntax = "proto3";
+		package test;
+		message Foo {
+			string full_name = 1;
+			int64 id = 2;
+			string tags = 3;
+			map<string, int64> counts = 4;
+			Baz bar = 5;
+			reserved 7;
+			message Nested { string a = 1; string b = 2; }
+			string added = 8;
+		}
+		message Bar {}
+		message Baz {}
+		enum Kind {
+			KIND_UNSPECIFIED = 0;
+			KIND_AA = 1;
+			reserved 3;
+		}
+		service Svc {
+			rpc Get(Foo) returns (Bar);
+			rpc List(Foo) returns (Bar);
+		}
+	`)
+	require.Empty(t, CheckBackwardCompatibility(oldPool, oldPool))
+
+	var actual []string
+	kinds := map[protoreflect.FullName]ChangeKind{}
+	for _, issue := range CheckBackwardCompatibility(oldPool, newPool) {
+		actual = append(actual, issue.String())
+		kinds[issue.Name] = issue.Kind
+	}
+	require.Equal(t, []string{
+		"test.Foo.bar: field type changed: field bar changed type from test.Bar to test.Baz",
+		"test.Foo.counts: field type changed: field counts changed type from map<string, int32> to map<string, int64>",
+		"test.Foo.id: field type changed: field id changed type from int32 to int64",
+		"test.Foo.name: field name changed: field number 1 was renamed from name to full_name",
+		"test.Foo.removed: field removed: field removed (number 6) was removed without reserving its number",
+		"test.Foo.tags: field cardinality changed: field tags changed from repeated to singular",
+		"test.Gone: message removed: message test.Gone was removed",
+		"test.GoneSvc: service removed: service test.GoneSvc was removed",
+		"test.KIND_A: enum value name changed: enum value number 1 was renamed from KIND_A to KIND_AA",
+		"test.KIND_B: enum value removed: enum value KIND_B (number 2) was removed without reserving its number",
+		"test.Svc.Delete: method removed: method Delete was removed",
+		"test.Svc.List: method signature changed: method List changed from (test.Foo) returns (stream test.Bar) to (test.Foo) returns (test.Bar)",
+	}, actual)
+	require.Equal(t, map[protoreflect.FullName]ChangeKind{
+		"test.Foo.bar":     ChangeKindFieldTypeChanged,
+		"test.Foo.counts":  ChangeKindFieldTypeChanged,
+		"test.Foo.id":      ChangeKindFieldTypeChanged,
+		"test.Foo.name":    ChangeKindFieldNameChanged,
+		"test.Foo.removed": ChangeKindFieldRemoved,
+		"test.Foo.tags":    ChangeKindFieldCardinalityChanged,
+		"test.Gone":        ChangeKindMessageRemoved,
+		"test.GoneSvc":     ChangeKindServiceRemoved,
+		"test.KIND_A":      ChangeKindEnumValueNameChanged,
+		"test.KIND_B":      ChangeKindEnumValueRemoved,
+		"test.Svc.Delete":  ChangeKindMethodRemoved,
+		"test.Svc.List":    ChangeKindMethodSignatureChanged,
+	}, kinds)
+	require.Equal(t, "unknown", ChangeKindUnknown.String())
+	require.Equal(t, "unknown change kind (100)", ChangeKind(100).String())
+}
+
+func compileForTest(t *testing.T, source string) protoresolve.DescriptorPool {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"test.proto": source,
+			}),
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(files[0]))
+	return &reg
+}
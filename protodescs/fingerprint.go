@@ -0,0 +1,58 @@
+package protodescs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"slices"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Fingerprint computes a SHA-256 hash of the given file's contents. Two file
+// descriptors that represent the same schema have the same fingerprint, even
+// if they were loaded from different sources.
+//
+// The hash is computed over the deterministic serialization of the file's
+// descriptor proto, with source code info removed. So differences in comments
+// or in the formatting of the original source file do not change the
+// fingerprint.
+//
+// The deterministic serialization is stable for a given version of the
+// protobuf runtime, but is not guaranteed to be stable across versions. So
+// fingerprints should not be persisted and compared with fingerprints computed
+// by a different program.
+func Fingerprint(fd protoreflect.FileDescriptor) [32]byte {
+	fdProto := protodesc.ToFileDescriptorProto(fd)
+	fdProto.SourceCodeInfo = nil
+	// Options may contain custom options whose message types have required
+	// fields, so we must allow partial messages.
+	data, err := proto.MarshalOptions{Deterministic: true, AllowPartial: true}.Marshal(fdProto)
+	if err != nil {
+		// should not be possible since nothing is checked when marshaling
+		// partial messages
+		panic(err)
+	}
+	return sha256.Sum256(data)
+}
+
+// FileSetFingerprint computes a SHA-256 hash of the given set of files. The
+// order of the files does not matter: the result is a hash of the sorted
+// fingerprints of the individual files. See Fingerprint.
+func FileSetFingerprint(fds []protoreflect.FileDescriptor) [32]byte {
+	fingerprints := make([][32]byte, len(fds))
+	for i, fd := range fds {
+		fingerprints[i] = Fingerprint(fd)
+	}
+	slices.SortFunc(fingerprints, func(a, b [32]byte) int {
+		return bytes.Compare(a[:], b[:])
+	})
+	hasher := sha256.New()
+	for _, fingerprint := range fingerprints {
+		hasher.Write(fingerprint[:])
+	}
+	var result [32]byte
+	hasher.Sum(result[:0])
+	return result
+}
@@ -0,0 +1,76 @@
+package protodescs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/sourceinfo"
+)
+
+func TestFingerprint(t *testing.T) {
+	// same schema, loaded from different sources
+	withSrcInfo, err := sourceinfo.Files.FindFileByPath("desc_test1.proto")
+	require.NoError(t, err)
+	require.NotZero(t, withSrcInfo.SourceLocations().Len())
+	rebuilt, err := protodesc.NewFile(protodesc.ToFileDescriptorProto(testprotos.File_desc_test1_proto), protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	fingerprint := Fingerprint(testprotos.File_desc_test1_proto)
+	require.Equal(t, fingerprint, Fingerprint(withSrcInfo))
+	require.Equal(t, fingerprint, Fingerprint(rebuilt))
+	require.NotEqual(t, fingerprint, Fingerprint(testprotos.File_desc_test2_proto))
+
+	// file sets are order-independent
+	setFingerprint := FileSetFingerprint([]protoreflect.FileDescriptor{testprotos.File_desc_test1_proto, testprotos.File_desc_test2_proto})
+	require.Equal(t, setFingerprint, FileSetFingerprint([]protoreflect.FileDescriptor{testprotos.File_desc_test2_proto, rebuilt}))
+	require.NotEqual(t, setFingerprint, FileSetFingerprint([]protoreflect.FileDescriptor{testprotos.File_desc_test1_proto}))
+}
+
+func TestFingerprint_PartialOptions(t *testing.T) {
+	// custom option whose message type has a required field that is not set
+	fdProto := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test.proto"),
+		Package:    proto.String("test"),
+		Dependency: []string{"google/protobuf/descriptor.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Opt"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:   proto.String("req"),
+				Number: proto.Int32(1),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_REQUIRED.Enum(),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			}},
+		}},
+		Extension: []*descriptorpb.FieldDescriptorProto{{
+			Name:     proto.String("opt"),
+			Number:   proto.Int32(50000),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(".test.Opt"),
+			Extendee: proto.String(".google.protobuf.FileOptions"),
+		}},
+	}
+	fd, err := protodesc.NewFile(fdProto, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	opts := &descriptorpb.FileOptions{}
+	ext := dynamicpb.NewExtensionType(fd.Extensions().Get(0))
+	proto.SetExtension(opts, ext, dynamicpb.NewMessage(fd.Messages().Get(0)))
+	fdProto.Options = opts
+	fd, err = protodesc.NewFile(fdProto, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	require.NotPanics(t, func() {
+		Fingerprint(fd)
+	})
+	// the option is part of the fingerprint
+	fdProto.Options = nil
+	withoutOpts, err := protodesc.NewFile(fdProto, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	require.NotEqual(t, Fingerprint(withoutOpts), Fingerprint(fd))
+}
@@ -0,0 +1,129 @@
+package protodescs
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DependencyGraph returns the import graph for the given files. The returned map
+// has an entry for each given file, keyed by its path. Each value is the list of
+// paths that the file directly imports, in the order they are declared. Imported
+// files need not be present in the given slice.
+//
+// An error is returned if the given files include duplicates (more than one
+// entry with the same path).
+func DependencyGraph(fds []protoreflect.FileDescriptor) (map[string][]string, error) {
+	graph := make(map[string][]string, len(fds))
+	for _, fd := range fds {
+		if _, exists := graph[fd.Path()]; exists {
+			return nil, fmt.Errorf("duplicate file %q", fd.Path())
+		}
+		imports := fd.Imports()
+		deps := make([]string, imports.Len())
+		for i := range deps {
+			deps[i] = imports.Get(i).Path()
+		}
+		graph[fd.Path()] = deps
+	}
+	return graph, nil
+}
+
+// TopologicalSort returns the given files in dependency order: each file appears
+// after all the files that it imports. Imported files that are not present in
+// the given slice are not included in the result. When the order between two
+// files is not constrained by their imports, they are returned in the same
+// relative order as in the given slice. The given slice is not modified.
+//
+// An error is returned if the given files include duplicates or if their
+// imports form a cycle. (The latter should not be possible with valid file
+// descriptors, but custom implementations of protoreflect.FileDescriptor may
+// not enforce that.)
+func TopologicalSort(fds []protoreflect.FileDescriptor) ([]protoreflect.FileDescriptor, error) {
+	graph, err := DependencyGraph(fds)
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]protoreflect.FileDescriptor, len(fds))
+	for _, fd := range fds {
+		byPath[fd.Path()] = fd
+	}
+	// done is false while a file's imports are being visited
+	// and true once the file has been added to sorted
+	done := make(map[string]bool, len(fds))
+	sorted := make([]protoreflect.FileDescriptor, 0, len(fds))
+	var visit func(path string, chain []string) error
+	visit = func(path string, chain []string) error {
+		if isDone, ok := done[path]; ok {
+			if !isDone {
+				return fmt.Errorf("import cycle: %s", strings.Join(append(chain, path), " -> "))
+			}
+			return nil
+		}
+		done[path] = false
+		chain = append(chain, path)
+		for _, dep := range graph[path] {
+			if _, ok := byPath[dep]; !ok {
+				continue
+			}
+			if err := visit(dep, chain); err != nil {
+				return err
+			}
+		}
+		done[path] = true
+		sorted = append(sorted, byPath[path])
+		return nil
+	}
+	for _, fd := range fds {
+		if err := visit(fd.Path(), nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// TransitiveDependencies returns all the files that the given file imports,
+// directly or indirectly. Each file appears once, even if it is imported via
+// multiple paths. The files are returned in dependency order: each file appears
+// after all the files that it imports. The given file is not included in the
+// result.
+//
+// This can be used, for example, to build a complete FileDescriptorSet for the
+// given file, by appending the file itself to the returned files.
+func TransitiveDependencies(fd protoreflect.FileDescriptor) []protoreflect.FileDescriptor {
+	return transitiveDependencies(fd, true)
+}
+
+// TransitiveDependenciesExcludingWellKnown is like TransitiveDependencies,
+// except that it excludes the standard files whose paths start with
+// "google/protobuf/", such as those that define well-known types. This is useful
+// when the consumer of the files already has these standard files, so they need
+// not be included. The dependencies of an excluded file are also excluded, unless
+// they are also imported by a file that is not excluded.
+func TransitiveDependenciesExcludingWellKnown(fd protoreflect.FileDescriptor) []protoreflect.FileDescriptor {
+	return transitiveDependencies(fd, false)
+}
+
+func transitiveDependencies(fd protoreflect.FileDescriptor, includeWellKnown bool) []protoreflect.FileDescriptor {
+	var deps []protoreflect.FileDescriptor
+	seen := map[string]struct{}{fd.Path(): {}}
+	var visit func(protoreflect.FileDescriptor)
+	visit = func(fd protoreflect.FileDescriptor) {
+		imports := fd.Imports()
+		for i, length := 0, imports.Len(); i < length; i++ {
+			dep := imports.Get(i).FileDescriptor
+			if _, ok := seen[dep.Path()]; ok {
+				continue
+			}
+			seen[dep.Path()] = struct{}{}
+			if !includeWellKnown && strings.HasPrefix(dep.Path(), "google/protobuf/") {
+				continue
+			}
+			visit(dep)
+			deps = append(deps, dep)
+		}
+	}
+	visit(fd)
+	return deps
+}
@@ -0,0 +1,115 @@
+package protodescs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/internal/testprotos/nopkg"
+	"github.com/jhump/protoreflect/v2/internal/testprotos/pkg"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	graph, err := DependencyGraph([]protoreflect.FileDescriptor{
+		testprotos.File_desc_test2_proto,
+		testprotos.File_desc_test1_proto,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"desc_test2.proto": {"desc_test1.proto", "pkg/desc_test_pkg.proto", "nopkg/desc_test_nopkg.proto"},
+		"desc_test1.proto": {},
+	}, graph)
+
+	_, err = DependencyGraph([]protoreflect.FileDescriptor{testprotos.File_desc_test1_proto, testprotos.File_desc_test1_proto})
+	require.EqualError(t, err, `duplicate file "desc_test1.proto"`)
+}
+
+func TestTopologicalSort(t *testing.T) {
+	input := []protoreflect.FileDescriptor{
+		testprotos.File_desc_test2_proto,
+		nopkg.File_nopkg_desc_test_nopkg_proto,
+		testprotos.File_desc_test_proto3_proto,
+		pkg.File_pkg_desc_test_pkg_proto,
+		testprotos.File_desc_test1_proto,
+	}
+	sorted, err := TopologicalSort(input)
+	require.NoError(t, err)
+	var paths []string
+	for _, fd := range sorted {
+		paths = append(paths, fd.Path())
+	}
+	assert.Equal(t, []string{
+		"desc_test1.proto",
+		"pkg/desc_test_pkg.proto",
+		"nopkg/desc_test_nopkg.proto",
+		"desc_test2.proto",
+		"desc_test_proto3.proto",
+	}, paths)
+	// input is unchanged
+	assert.Equal(t, "desc_test2.proto", input[0].Path())
+
+	a := &fakeFile{path: "a.proto"}
+	b := &fakeFile{path: "b.proto"}
+	c := &fakeFile{path: "c.proto"}
+	a.imports = []*fakeFile{b}
+	b.imports = []*fakeFile{c}
+	c.imports = []*fakeFile{a}
+	_, err = TopologicalSort([]protoreflect.FileDescriptor{a, b, c})
+	require.EqualError(t, err, "import cycle: a.proto -> b.proto -> c.proto -> a.proto")
+}
+
+func TestTransitiveDependencies(t *testing.T) {
+	paths := func(fds []protoreflect.FileDescriptor) []string {
+		var paths []string
+		for _, fd := range fds {
+			paths = append(paths, fd.Path())
+		}
+		return paths
+	}
+	assert.Equal(t, []string{
+		"desc_test1.proto",
+		"pkg/desc_test_pkg.proto",
+		"nopkg/desc_test_nopkg_new.proto",
+		"nopkg/desc_test_nopkg.proto",
+	}, paths(TransitiveDependencies(testprotos.File_desc_test2_proto)))
+	assert.Empty(t, TransitiveDependencies(testprotos.File_desc_test1_proto))
+
+	assert.Equal(t, []string{
+		"google/protobuf/empty.proto",
+		"google/protobuf/descriptor.proto",
+		"desc_test_options.proto",
+	}, paths(TransitiveDependencies(testprotos.File_desc_test_comments_proto)))
+	assert.Equal(t, []string{
+		"desc_test_options.proto",
+	}, paths(TransitiveDependenciesExcludingWellKnown(testprotos.File_desc_test_comments_proto)))
+}
+
+type fakeFile struct {
+	protoreflect.FileDescriptor
+	path    string
+	imports []*fakeFile
+}
+
+func (f *fakeFile) Path() string {
+	return f.path
+}
+
+func (f *fakeFile) Imports() protoreflect.FileImports {
+	return fakeImports{files: f.imports}
+}
+
+type fakeImports struct {
+	protoreflect.FileImports
+	files []*fakeFile
+}
+
+func (f fakeImports) Len() int {
+	return len(f.files)
+}
+
+func (f fakeImports) Get(i int) protoreflect.FileImport {
+	return protoreflect.FileImport{FileDescriptor: f.files[i]}
+}
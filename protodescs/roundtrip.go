@@ -0,0 +1,82 @@
+package protodescs
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ValidateRoundTrip verifies that the given file can be converted to a
+// descriptor proto and back without any loss of information. It converts the
+// file to a google.protobuf.FileDescriptorProto, creates a new file descriptor
+// from that proto using [protodesc.NewFile], and then checks that the
+// serialized form of the new file is byte-for-byte identical to that of the
+// original. The file's imports are used to resolve references in the proto.
+//
+// This is useful for validating file descriptors that were not created from
+// a descriptor proto, such as custom implementations of the
+// protoreflect.FileDescriptor interface, which may have inconsistencies that
+// cannot be represented in a descriptor proto.
+//
+// An error is returned if the new file descriptor cannot be created or if its
+// serialized form differs from the original. In the latter case, the error
+// message includes a diff of the two descriptor protos.
+func ValidateRoundTrip(fd protoreflect.FileDescriptor) error {
+	var deps protoregistry.Files
+	if err := registerImports(fd, &deps, map[string]struct{}{}); err != nil {
+		return fmt.Errorf("failed to register imports of %q: %w", fd.Path(), err)
+	}
+	orig := protodesc.ToFileDescriptorProto(fd)
+	rebuilt, err := protodesc.NewFile(orig, &deps)
+	if err != nil {
+		return fmt.Errorf("failed to round-trip %q: %w", fd.Path(), err)
+	}
+	return compareRoundTrip(orig, protodesc.ToFileDescriptorProto(rebuilt))
+}
+
+func registerImports(fd protoreflect.FileDescriptor, reg *protoregistry.Files, seen map[string]struct{}) error {
+	imports := fd.Imports()
+	for i, length := 0, imports.Len(); i < length; i++ {
+		dep := imports.Get(i).FileDescriptor
+		if _, ok := seen[dep.Path()]; ok {
+			continue
+		}
+		seen[dep.Path()] = struct{}{}
+		if err := registerImports(dep, reg, seen); err != nil {
+			return err
+		}
+		if err := reg.RegisterFile(dep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compareRoundTrip(orig, rebuilt *descriptorpb.FileDescriptorProto) error {
+	opts := proto.MarshalOptions{Deterministic: true}
+	origData, err := opts.Marshal(orig)
+	if err != nil {
+		return fmt.Errorf("failed to serialize %q: %w", orig.GetName(), err)
+	}
+	rebuiltData, err := opts.Marshal(rebuilt)
+	if err != nil {
+		return fmt.Errorf("failed to serialize round-tripped %q: %w", orig.GetName(), err)
+	}
+	if bytes.Equal(origData, rebuiltData) {
+		return nil
+	}
+	diff := cmp.Diff(orig, rebuilt, protocmp.Transform())
+	if diff == "" {
+		// messages are equal, but serialized forms differ (for example,
+		// due to the order of unrecognized fields)
+		diff = fmt.Sprintf("serialized forms differ: %d bytes vs %d bytes", len(origData), len(rebuiltData))
+	}
+	return fmt.Errorf("round-trip of %q lost information (-original +round-tripped):\n%s", orig.GetName(), diff)
+}
@@ -0,0 +1,54 @@
+package protodescs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/sourceinfo"
+)
+
+func TestValidateRoundTrip(t *testing.T) {
+	require.NoError(t, ValidateRoundTrip(testprotos.File_desc_test1_proto))
+	require.NoError(t, ValidateRoundTrip(testprotos.File_desc_test_complex_proto))
+	require.NoError(t, ValidateRoundTrip(testprotos.File_desc_test_editions_proto))
+	withSrcInfo, err := sourceinfo.Files.FindFileByPath("desc_test1.proto")
+	require.NoError(t, err)
+	require.NoError(t, ValidateRoundTrip(withSrcInfo))
+
+	// a file that hides its imports cannot be round-tripped
+	err = ValidateRoundTrip(noImportsFile{testprotos.File_desc_test2_proto})
+	require.ErrorContains(t, err, `failed to round-trip "desc_test2.proto"`)
+}
+
+func TestCompareRoundTrip(t *testing.T) {
+	orig := protodesc.ToFileDescriptorProto(testprotos.File_desc_test1_proto)
+	require.NoError(t, compareRoundTrip(orig, proto.Clone(orig).(*descriptorpb.FileDescriptorProto)))
+
+	changed := proto.Clone(orig).(*descriptorpb.FileDescriptorProto)
+	changed.Package = proto.String("foo.baz")
+	err := compareRoundTrip(orig, changed)
+	require.ErrorContains(t, err, `round-trip of "desc_test1.proto" lost information (-original +round-tripped):`)
+	require.ErrorContains(t, err, `foo.baz`)
+}
+
+type noImportsFile struct {
+	protoreflect.FileDescriptor
+}
+
+func (noImportsFile) Imports() protoreflect.FileImports {
+	return emptyImports{}
+}
+
+type emptyImports struct {
+	protoreflect.FileImports
+}
+
+func (emptyImports) Len() int {
+	return 0
+}
@@ -0,0 +1,136 @@
+// Package protoembed provides support for loading file descriptors from files in
+// a file system, such as descriptor files that are embedded into a program using
+// an [embed.FS].
+package protoembed
+
+import (
+	"fmt"
+	"io/fs"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+// LoadFromFS loads file descriptors from the files in fsys whose paths match
+// the given glob pattern. The pattern uses the syntax of [fs.Glob], for example
+// "descriptors/*.pb.bin". If the pattern is empty, "*.pb.bin" is used. Each
+// matching file must contain a serialized google.protobuf.FileDescriptorProto.
+// The name of the file descriptor comes from the proto's name field, not from
+// the path of the file that contained it.
+//
+// The loaded files may import one another; they need not be in any particular
+// order. Imports that are not among the loaded files are resolved using deps,
+// or using [protoresolve.GlobalDescriptors] if deps is nil. Such imports are
+// also added to the returned registry.
+//
+// An error is returned if no files match the pattern, if any file cannot be
+// read or unmarshalled, if more than one loaded file has the same name, or if
+// any file has an import that cannot be resolved.
+func LoadFromFS(fsys fs.FS, glob string, deps protoresolve.DescriptorPool) (*protoresolve.Registry, error) {
+	if glob == "" {
+		glob = "*.pb.bin"
+	}
+	if deps == nil {
+		deps = protoresolve.GlobalDescriptors
+	}
+	paths, err := fs.Glob(fsys, glob)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files match %q", glob)
+	}
+	l := loader{
+		deps:   deps,
+		protos: make(map[string]*descriptorpb.FileDescriptorProto, len(paths)),
+		state:  map[string]loadState{},
+	}
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+		var fdProto descriptorpb.FileDescriptorProto
+		if err := proto.Unmarshal(data, &fdProto); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+		}
+		name := fdProto.GetName()
+		if _, exists := l.protos[name]; exists {
+			return nil, fmt.Errorf("%s: duplicate file %q", path, name)
+		}
+		l.protos[name] = &fdProto
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if err := l.loadProto(name); err != nil {
+			return nil, err
+		}
+	}
+	return &l.reg, nil
+}
+
+type loadState int
+
+const (
+	loading = loadState(iota + 1)
+	loaded
+)
+
+type loader struct {
+	reg    protoresolve.Registry
+	deps   protoresolve.DescriptorPool
+	protos map[string]*descriptorpb.FileDescriptorProto
+	state  map[string]loadState
+}
+
+func (l *loader) loadProto(name string) error {
+	switch l.state[name] {
+	case loaded:
+		return nil
+	case loading:
+		return fmt.Errorf("import cycle involving %q", name)
+	}
+	l.state[name] = loading
+	fdProto := l.protos[name]
+	for _, dep := range fdProto.GetDependency() {
+		if _, ok := l.protos[dep]; ok {
+			if err := l.loadProto(dep); err != nil {
+				return err
+			}
+			continue
+		}
+		depFile, err := l.deps.FindFileByPath(dep)
+		if err != nil {
+			return fmt.Errorf("file %q imports %q: %w", name, dep, err)
+		}
+		if err := l.loadFile(depFile); err != nil {
+			return err
+		}
+	}
+	if _, err := l.reg.RegisterFileProto(fdProto); err != nil {
+		return fmt.Errorf("failed to register %q: %w", name, err)
+	}
+	l.state[name] = loaded
+	return nil
+}
+
+func (l *loader) loadFile(fd protoreflect.FileDescriptor) error {
+	if l.state[fd.Path()] == loaded {
+		return nil
+	}
+	imports := fd.Imports()
+	for i, length := 0, imports.Len(); i < length; i++ {
+		if err := l.loadFile(imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	if err := l.reg.RegisterFile(fd); err != nil {
+		return fmt.Errorf("failed to register %q: %w", fd.Path(), err)
+	}
+	l.state[fd.Path()] = loaded
+	return nil
+}
@@ -0,0 +1,69 @@
+package protoembed
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func descriptorBytes(t *testing.T, fd protoreflect.FileDescriptor) []byte {
+	t.Helper()
+	data, err := proto.Marshal(protodesc.ToFileDescriptorProto(fd))
+	require.NoError(t, err)
+	return data
+}
+
+func TestLoadFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		// the order of the files does not matter
+		"descs/a.pb.bin":   {Data: descriptorBytes(t, testprotos.File_desc_test_proto3_proto)},
+		"descs/b.pb.bin":   {Data: descriptorBytes(t, testprotos.File_desc_test1_proto)},
+		"descs/readme.txt": {Data: []byte("not a descriptor")},
+	}
+	reg, err := LoadFromFS(fsys, "descs/*.pb.bin", nil)
+	require.NoError(t, err)
+	// embedded files plus the imports resolved from the global registry
+	assert.Equal(t, 3, reg.NumFiles())
+	fd, err := reg.FindFileByPath("desc_test_proto3.proto")
+	require.NoError(t, err)
+	assert.Equal(t, "desc_test1.proto", fd.Imports().Get(0).Path())
+	assert.NotSame(t, testprotos.File_desc_test1_proto, fd.Imports().Get(0).FileDescriptor)
+	_, err = reg.FindFileByPath("pkg/desc_test_pkg.proto")
+	require.NoError(t, err)
+	_, err = reg.FindDescriptorByName("testprotos.TestRequest")
+	require.NoError(t, err)
+
+	// default pattern
+	fsys["c.pb.bin"] = &fstest.MapFile{Data: descriptorBytes(t, testprotos.File_desc_test1_proto)}
+	reg, err = LoadFromFS(fsys, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reg.NumFiles())
+}
+
+func TestLoadFromFS_Errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.pb.bin": {Data: descriptorBytes(t, testprotos.File_desc_test_proto3_proto)},
+	}
+	_, err := LoadFromFS(fsys, "*.pb.bin", &protoresolve.Registry{})
+	require.ErrorContains(t, err, `file "desc_test_proto3.proto" imports "desc_test1.proto": `)
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+
+	_, err = LoadFromFS(fsys, "*.bin.pb", nil)
+	require.EqualError(t, err, `no files match "*.bin.pb"`)
+
+	fsys["b.pb.bin"] = &fstest.MapFile{Data: descriptorBytes(t, testprotos.File_desc_test_proto3_proto)}
+	_, err = LoadFromFS(fsys, "*.pb.bin", nil)
+	require.EqualError(t, err, `b.pb.bin: duplicate file "desc_test_proto3.proto"`)
+
+	fsys["b.pb.bin"] = &fstest.MapFile{Data: []byte{0xff, 0xff}}
+	_, err = LoadFromFS(fsys, "*.pb.bin", nil)
+	require.ErrorContains(t, err, "failed to unmarshal b.pb.bin: ")
+}
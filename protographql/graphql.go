@@ -0,0 +1,378 @@
+// Package protographql generates GraphQL schemas from protobuf service
+// descriptors. This can be useful when building a GraphQL API, such as a
+// backend-for-frontend layer, on top of gRPC services.
+package protographql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ServiceToGraphQL returns a GraphQL schema, in the GraphQL schema definition
+// language (SDL), for the given service.
+//
+// Unary methods whose names start with "Get" or "List" become fields of the
+// Query type, and other unary methods become fields of the Mutation type.
+// Server-streaming methods become fields of the Subscription type. Each field
+// is named after its method, in lower camel case, and accepts the method's
+// request message as an argument named "input". Client-streaming methods are
+// not supported, so they are omitted (with a comment noting the omission).
+//
+// Messages that are used as responses become object types, and messages that
+// are used as requests become input types (with an "Input" suffix). Enums
+// become GraphQL enums. Since GraphQL does not support nested types, nested
+// messages and enums are named with the names of their enclosing messages as a
+// prefix, separated by underscores (e.g. "Outer_Inner"). Type names do not
+// include the package name, so it is an error if two types used by the service
+// have the same name but are in different packages.
+//
+// Fields use the same names and representations as the protobuf JSON mapping.
+// So 64-bit integers are represented as strings, bytes are represented as
+// base64-encoded strings, and map fields are represented as lists of key-value
+// entries. Well-known types with special JSON representations are represented
+// as scalars; those with arbitrary JSON values (such as google.protobuf.Struct)
+// use a custom JSON scalar.
+//
+// In object types, a oneof is represented as a single field whose type is a
+// GraphQL union. Since union members must be object types, each member of the
+// union is an object type with a single field, corresponding to one of the
+// oneof's fields. GraphQL input types do not support unions, so in input types,
+// the oneof's fields are instead ordinary nullable fields, at most one of
+// which should be set.
+func ServiceToGraphQL(sd protoreflect.ServiceDescriptor) (string, error) {
+	g := generator{
+		names:     map[string]protoreflect.FullName{},
+		generated: map[string]bool{},
+	}
+	var queries, mutations, subscriptions, omitted []string
+	methods := sd.Methods()
+	for i, length := 0, methods.Len(); i < length; i++ {
+		md := methods.Get(i)
+		if md.IsStreamingClient() {
+			omitted = append(omitted, fmt.Sprintf("# method %s: client-streaming methods are not supported, so it is omitted\n", md.Name()))
+			continue
+		}
+		field := g.description(md, "  ") + g.rootField(md)
+		switch {
+		case md.IsStreamingServer():
+			subscriptions = append(subscriptions, field)
+		case isQuery(md.Name()):
+			queries = append(queries, field)
+		default:
+			mutations = append(mutations, field)
+		}
+	}
+	if g.err != nil {
+		return "", g.err
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "# Code generated from service %s. DO NOT EDIT.\n", sd.FullName())
+	for _, o := range omitted {
+		sb.WriteString(o)
+	}
+	if len(queries) == 0 {
+		// a schema must have a query type, and types must have fields
+		queries = []string{"  _: Boolean\n"}
+	}
+	writeType(&sb, "type Query", queries)
+	writeType(&sb, "type Mutation", mutations)
+	writeType(&sb, "type Subscription", subscriptions)
+	if g.usesJSON {
+		sb.WriteString("\nscalar JSON\n")
+	}
+	for _, def := range g.defs {
+		sb.WriteString("\n")
+		sb.WriteString(def)
+	}
+	return sb.String(), nil
+}
+
+func writeType(sb *strings.Builder, header string, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	sb.WriteString("\n" + header + " {\n")
+	for _, f := range fields {
+		sb.WriteString(f)
+	}
+	sb.WriteString("}\n")
+}
+
+// isQuery returns true if the given method name starts with "Get" or "List"
+// as a whole word (so "Getaway" and "Listen" are not queries).
+func isQuery(name protoreflect.Name) bool {
+	for _, prefix := range []string{"Get", "List"} {
+		rest, ok := strings.CutPrefix(string(name), prefix)
+		if !ok {
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(rest)
+		if rest == "" || unicode.IsUpper(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+type generator struct {
+	// names of all generated types, to detect conflicts
+	names     map[string]protoreflect.FullName
+	generated map[string]bool
+	defs      []string
+	usesJSON  bool
+	err       error
+}
+
+func (g *generator) rootField(md protoreflect.MethodDescriptor) string {
+	name := lowerFirst(string(md.Name()))
+	output := g.messageType(md.Output(), false)
+	if md.Input().Fields().Len() == 0 {
+		return fmt.Sprintf("  %s: %s\n", name, output)
+	}
+	return fmt.Sprintf("  %s(input: %s!): %s\n", name, g.messageType(md.Input(), true), output)
+}
+
+// reserve records that the given GraphQL type name is used for the given
+// element. It returns false if the name has already been generated.
+func (g *generator) reserve(name string, source protoreflect.FullName) bool {
+	if existing, ok := g.names[name]; ok {
+		if existing != source && g.err == nil {
+			g.err = fmt.Errorf("GraphQL type name %s is used for both %s and %s", name, existing, source)
+		}
+		return false
+	}
+	g.names[name] = source
+	return true
+}
+
+func (g *generator) messageType(md protoreflect.MessageDescriptor, input bool) string {
+	if name, ok := g.wellKnownType(md); ok {
+		return name
+	}
+	name := localName(md)
+	if input {
+		name += "Input"
+		if g.reserve(name, md.FullName()) {
+			g.inputType(name, md)
+		}
+	} else if g.reserve(name, md.FullName()) {
+		g.objectType(name, md)
+	}
+	return name
+}
+
+func (g *generator) objectType(name string, md protoreflect.MessageDescriptor) {
+	var sb strings.Builder
+	sb.WriteString(g.description(md, ""))
+	sb.WriteString("type " + name + " {\n")
+	fields := md.Fields()
+	numFields := 0
+	for i, length := 0, fields.Len(); i < length; i++ {
+		fld := fields.Get(i)
+		if ood := fld.ContainingOneof(); ood != nil && !ood.IsSynthetic() {
+			if ood.Fields().Get(0) == fld {
+				// the union is emitted in place of the first field in the oneof
+				sb.WriteString(g.description(ood, "  "))
+				_, _ = fmt.Fprintf(&sb, "  %s: %s\n", lowerFirst(camelCase(string(ood.Name()))), g.unionType(name, ood))
+				numFields++
+			}
+			continue
+		}
+		sb.WriteString(g.description(fld, "  "))
+		_, _ = fmt.Fprintf(&sb, "  %s: %s\n", fld.JSONName(), g.fieldType(fld, false))
+		numFields++
+	}
+	if numFields == 0 {
+		// types must have fields
+		sb.WriteString("  _: Boolean\n")
+	}
+	sb.WriteString("}\n")
+	g.defs = append(g.defs, sb.String())
+}
+
+func (g *generator) unionType(msgName string, ood protoreflect.OneofDescriptor) string {
+	name := msgName + "_" + camelCase(string(ood.Name()))
+	if !g.reserve(name, ood.FullName()) {
+		return name
+	}
+	fields := ood.Fields()
+	members := make([]string, fields.Len())
+	for i := range members {
+		fld := fields.Get(i)
+		members[i] = name + "_" + camelCase(string(fld.Name()))
+		if !g.reserve(members[i], fld.FullName()) {
+			continue
+		}
+		var sb strings.Builder
+		sb.WriteString(g.description(fld, ""))
+		_, _ = fmt.Fprintf(&sb, "type %s {\n  %s: %s\n}\n", members[i], fld.JSONName(), g.fieldType(fld, false))
+		g.defs = append(g.defs, sb.String())
+	}
+	g.defs = append(g.defs, g.description(ood, "")+fmt.Sprintf("union %s = %s\n", name, strings.Join(members, " | ")))
+	return name
+}
+
+func (g *generator) inputType(name string, md protoreflect.MessageDescriptor) {
+	var sb strings.Builder
+	sb.WriteString(g.description(md, ""))
+	sb.WriteString("input " + name + " {\n")
+	fields := md.Fields()
+	for i, length := 0, fields.Len(); i < length; i++ {
+		fld := fields.Get(i)
+		if ood := fld.ContainingOneof(); ood != nil && !ood.IsSynthetic() && ood.Fields().Get(0) == fld {
+			_, _ = fmt.Fprintf(&sb, "  # oneof %s: at most one of the following should be set: %s\n",
+				ood.Name(), oneofFieldNames(ood))
+		}
+		sb.WriteString(g.description(fld, "  "))
+		_, _ = fmt.Fprintf(&sb, "  %s: %s\n", fld.JSONName(), g.fieldType(fld, true))
+	}
+	if fields.Len() == 0 {
+		// types must have fields
+		sb.WriteString("  _: Boolean\n")
+	}
+	sb.WriteString("}\n")
+	g.defs = append(g.defs, sb.String())
+}
+
+func oneofFieldNames(ood protoreflect.OneofDescriptor) string {
+	fields := ood.Fields()
+	names := make([]string, fields.Len())
+	for i := range names {
+		names[i] = fields.Get(i).JSONName()
+	}
+	return strings.Join(names, ", ")
+}
+
+func (g *generator) enumType(ed protoreflect.EnumDescriptor) string {
+	if ed.FullName() == "google.protobuf.NullValue" {
+		g.usesJSON = true
+		return "JSON"
+	}
+	name := localName(ed)
+	if !g.reserve(name, ed.FullName()) {
+		return name
+	}
+	var sb strings.Builder
+	sb.WriteString(g.description(ed, ""))
+	sb.WriteString("enum " + name + " {\n")
+	vals := ed.Values()
+	for i, length := 0, vals.Len(); i < length; i++ {
+		val := vals.Get(i)
+		sb.WriteString(g.description(val, "  "))
+		sb.WriteString("  " + string(val.Name()) + "\n")
+	}
+	sb.WriteString("}\n")
+	g.defs = append(g.defs, sb.String())
+	return name
+}
+
+func (g *generator) fieldType(fld protoreflect.FieldDescriptor, input bool) string {
+	switch {
+	case fld.IsList() || fld.IsMap():
+		// for maps, fld.Message() is the map entry message
+		return "[" + g.singularType(fld, input) + "!]"
+	case fld.Cardinality() == protoreflect.Required:
+		return g.singularType(fld, input) + "!"
+	default:
+		return g.singularType(fld, input)
+	}
+}
+
+func (g *generator) singularType(fld protoreflect.FieldDescriptor, input bool) string {
+	switch fld.Kind() {
+	case protoreflect.EnumKind:
+		return g.enumType(fld.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return g.messageType(fld.Message(), input)
+	default:
+		return scalarType(fld.Kind())
+	}
+}
+
+func scalarType(kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.BoolKind:
+		return "Boolean"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "Int"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.FloatKind, protoreflect.DoubleKind:
+		// GraphQL's Int is a signed 32-bit integer, so too small for uint32
+		return "Float"
+	default:
+		// string and bytes, and 64-bit integers (which are strings in JSON)
+		return "String"
+	}
+}
+
+// wellKnownType returns the GraphQL type for well-known types that have a
+// special JSON representation. It returns false if md is not such a type.
+func (g *generator) wellKnownType(md protoreflect.MessageDescriptor) (string, bool) {
+	if md.ParentFile().Package() != "google.protobuf" {
+		return "", false
+	}
+	switch md.Name() {
+	case "Any", "Struct", "Value", "ListValue":
+		g.usesJSON = true
+		return "JSON", true
+	case "Timestamp", "Duration", "FieldMask":
+		return "String", true
+	case "BoolValue", "Int32Value", "Int64Value", "UInt32Value", "UInt64Value",
+		"FloatValue", "DoubleValue", "StringValue", "BytesValue":
+		if fld := md.Fields().ByName("value"); fld != nil {
+			return scalarType(fld.Kind()), true
+		}
+	}
+	return "", false
+}
+
+func (g *generator) description(d protoreflect.Descriptor, indent string) string {
+	loc := d.ParentFile().SourceLocations().ByDescriptor(d)
+	comments := strings.TrimSpace(loc.LeadingComments)
+	if comments == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(indent + `"""` + "\n")
+	for _, line := range strings.Split(comments, "\n") {
+		line = strings.TrimSpace(strings.ReplaceAll(line, `"""`, `\"""`))
+		if line == "" {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(indent + line + "\n")
+	}
+	sb.WriteString(indent + `"""` + "\n")
+	return sb.String()
+}
+
+// localName returns the GraphQL name for the given message or enum, which
+// includes the names of any enclosing messages.
+func localName(d protoreflect.Descriptor) string {
+	name := strings.TrimPrefix(string(d.FullName()), string(d.ParentFile().Package()))
+	return strings.ReplaceAll(strings.TrimPrefix(name, "."), ".", "_")
+}
+
+// camelCase converts a snake_case name to UpperCamelCase.
+func camelCase(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(part)
+		sb.WriteRune(unicode.ToUpper(r))
+		sb.WriteString(part[size:])
+	}
+	return sb.String()
+}
+
+func lowerFirst(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
@@ -0,0 +1,174 @@
+package protographql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceToGraphQL(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"test.proto": `
+					syntax = "proto3";
+					package test;
+					import "google/protobuf/empty.proto";
+					import "google/protobuf/struct.proto";
+					import "google/protobuf/timestamp.proto";
+					service Library {
+						// Gets a book.
+						rpc GetBook(GetBookRequest) returns (Book);
+						rpc ListBooks(google.protobuf.Empty) returns (Books);
+						rpc Listen(GetBookRequest) returns (Book);
+						rpc WatchBooks(GetBookRequest) returns (stream Book);
+						rpc UploadBooks(stream Book) returns (google.protobuf.Empty);
+					}
+					message GetBookRequest {
+						string name = 1;
+						oneof version {
+							int64 revision = 2;
+							google.protobuf.Timestamp as_of = 3;
+						}
+					}
+					message Books {
+						repeated Book books = 1;
+					}
+					// A book.
+					message Book {
+						string name = 1;
+						Genre genre = 2;
+						map<string, string> labels = 3;
+						google.protobuf.Struct metadata = 4;
+						oneof format {
+							Print print = 5;
+							bool ebook = 6;
+						}
+						message Print {
+							uint32 pages = 1;
+						}
+					}
+					enum Genre {
+						GENRE_UNSPECIFIED = 0;
+						GENRE_FICTION = 1;
+					}
+				`,
+			}),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+
+	schema, err := ServiceToGraphQL(files[0].Services().ByName("Library"))
+	require.NoError(t, err)
+	expected := `# Code generated from service test.Library. DO NOT EDIT.
+# method UploadBooks: client-streaming methods are not supported, so it is omitted
+
+type Query {
+  """
+  Gets a book.
+  """
+  getBook(input: GetBookRequestInput!): Book
+  listBooks: Books
+}
+
+type Mutation {
+  listen(input: GetBookRequestInput!): Book
+}
+
+type Subscription {
+  watchBooks(input: GetBookRequestInput!): Book
+}
+
+scalar JSON
+
+enum Genre {
+  GENRE_UNSPECIFIED
+  GENRE_FICTION
+}
+
+type Book_LabelsEntry {
+  key: String
+  value: String
+}
+
+type Book_Print {
+  pages: Float
+}
+
+type Book_Format_Print {
+  print: Book_Print
+}
+
+type Book_Format_Ebook {
+  ebook: Boolean
+}
+
+union Book_Format = Book_Format_Print | Book_Format_Ebook
+
+"""
+A book.
+"""
+type Book {
+  name: String
+  genre: Genre
+  labels: [Book_LabelsEntry!]
+  metadata: JSON
+  format: Book_Format
+}
+
+input GetBookRequestInput {
+  name: String
+  # oneof version: at most one of the following should be set: revision, asOf
+  revision: String
+  asOf: String
+}
+
+type Books {
+  books: [Book!]
+}
+`
+	assert.Equal(t, expected, schema)
+}
+
+func TestServiceToGraphQL_NameConflict(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"a.proto": `
+					syntax = "proto3";
+					package a;
+					message Msg {}
+				`,
+				"b.proto": `
+					syntax = "proto3";
+					package b;
+					import "a.proto";
+					message Msg {}
+					service Svc {
+						rpc Foo(Msg) returns (a.Msg);
+						rpc Bar(a.Msg) returns (Msg);
+					}
+				`,
+			}),
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "b.proto")
+	require.NoError(t, err)
+
+	_, err = ServiceToGraphQL(files[0].Services().ByName("Svc"))
+	require.EqualError(t, err, "GraphQL type name Msg is used for both a.Msg and b.Msg")
+}
+
+func TestIsQuery(t *testing.T) {
+	assert.True(t, isQuery("Get"))
+	assert.True(t, isQuery("GetFoo"))
+	assert.True(t, isQuery("ListFoos"))
+	assert.False(t, isQuery("Getaway"))
+	assert.False(t, isQuery("Listen"))
+	assert.False(t, isQuery("CreateFoo"))
+}
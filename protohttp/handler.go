@@ -0,0 +1,119 @@
+// Package protohttp provides an HTTP interface for querying protobuf descriptors.
+// This allows a resolver to be exposed as a simple schema registry, which can be
+// used by clients written in any language.
+//
+// It also provides helpers for reading "google.api.http" method options, which
+// describe how RPC methods are exposed as HTTP/JSON APIs. These are the building
+// blocks for REST transcoders and documentation generators.
+package protohttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+// NewDescriptorHTTPHandler returns an HTTP handler that serves file descriptors
+// from the given resolver. Responses are google.protobuf.FileDescriptorProto
+// messages, encoded as JSON. The handler supports the following requests:
+//
+//   - GET /files/{path}: returns the file with the given path.
+//   - GET /symbols/{fullName}: returns the file that defines the element
+//     with the given fully-qualified name.
+//   - GET /extensions/{message}/{number}: returns the file that defines
+//     the extension of the given message with the given field number.
+//
+// If the requested element cannot be found, the handler responds with a
+// 404 "Not Found" status.
+//
+// The handler can be mounted under a prefix using [http.StripPrefix].
+func NewDescriptorHTTPHandler(resolver protoresolve.Resolver) http.Handler {
+	return &descriptorHandler{res: resolver}
+}
+
+type descriptorHandler struct {
+	res protoresolve.Resolver
+}
+
+func (h *descriptorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	kind, arg, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if arg == "" {
+		http.NotFound(w, r)
+		return
+	}
+	var fd protoreflect.FileDescriptor
+	var err error
+	switch kind {
+	case "files":
+		fd, err = h.res.FindFileByPath(arg)
+	case "symbols":
+		var d protoreflect.Descriptor
+		d, err = h.res.FindDescriptorByName(protoreflect.FullName(arg))
+		if err == nil {
+			fd = d.ParentFile()
+		}
+	case "extensions":
+		fd, err = h.findExtensionFile(arg)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data, err := protojson.Marshal(protodesc.ToFileDescriptorProto(fd))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func (h *descriptorHandler) findExtensionFile(arg string) (protoreflect.FileDescriptor, error) {
+	msgName, numStr, ok := strings.Cut(arg, "/")
+	if !ok {
+		return nil, protoresolve.ErrNotFound
+	}
+	num, err := strconv.ParseInt(numStr, 10, 32)
+	if err != nil || num <= 0 {
+		return nil, badRequestError(fmt.Sprintf("invalid extension number %q", numStr))
+	}
+	ext, err := h.res.FindExtensionByNumber(protoreflect.FullName(msgName), protoreflect.FieldNumber(num))
+	if err != nil {
+		return nil, err
+	}
+	return ext.ParentFile(), nil
+}
+
+type badRequestError string
+
+func (e badRequestError) Error() string {
+	return string(e)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var badReq badRequestError
+	switch {
+	case errors.As(err, &badReq):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, protoresolve.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,65 @@
+package protohttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestDescriptorHTTPHandler(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	svr := httptest.NewServer(NewDescriptorHTTPHandler(&reg))
+	t.Cleanup(svr.Close)
+
+	testCases := []struct {
+		path       string
+		wantStatus int
+		wantFile   string
+	}{
+		{path: "/files/desc_test1.proto", wantStatus: http.StatusOK, wantFile: "desc_test1.proto"},
+		{path: "/symbols/testprotos.TestMessage.NestedMessage", wantStatus: http.StatusOK, wantFile: "desc_test1.proto"},
+		{path: "/symbols/testprotos.SOME_VAL", wantStatus: http.StatusOK, wantFile: "desc_test1.proto"},
+		{path: "/extensions/testprotos.AnotherTestMessage/102", wantStatus: http.StatusOK, wantFile: "desc_test1.proto"},
+		{path: "/files/does_not_exist.proto", wantStatus: http.StatusNotFound},
+		{path: "/symbols/foo.bar.Baz", wantStatus: http.StatusNotFound},
+		{path: "/extensions/testprotos.AnotherTestMessage/199", wantStatus: http.StatusNotFound},
+		{path: "/extensions/testprotos.AnotherTestMessage/abc", wantStatus: http.StatusBadRequest},
+		{path: "/extensions/testprotos.AnotherTestMessage", wantStatus: http.StatusNotFound},
+		{path: "/files/", wantStatus: http.StatusNotFound},
+		{path: "/foo/bar", wantStatus: http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			resp, err := http.Get(svr.URL + tc.path)
+			require.NoError(t, err)
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantStatus, resp.StatusCode, "unexpected status; body: %s", body)
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			var fdp descriptorpb.FileDescriptorProto
+			require.NoError(t, protojson.Unmarshal(body, &fdp))
+			assert.Equal(t, tc.wantFile, fdp.GetName())
+		})
+	}
+
+	resp, err := http.Post(svr.URL+"/files/desc_test1.proto", "application/json", nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -0,0 +1,79 @@
+package protohttp
+
+import (
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// GetHTTPRule returns the "google.api.http" option of the given method. This
+// option describes how the method is exposed as an HTTP/JSON API, such as with
+// gRPC transcoding. It returns false if the method does not have the option.
+//
+// The option is found even if the method's descriptor was built without the
+// option's extension being known, in which case the option is stored as an
+// unrecognized field of the options message.
+func GetHTTPRule(md protoreflect.MethodDescriptor) (*annotations.HttpRule, bool) {
+	if rule := findHTTPRule(md.Options()); rule != nil {
+		return rule, true
+	}
+	// Re-parse the options, so that the option can be found even if it is
+	// unrecognized, the options are a dynamic message, or the option is set
+	// using a different (such as dynamic) extension type. The extension is
+	// known to the global registry since this package links it in.
+	data, err := proto.Marshal(md.Options())
+	if err != nil {
+		return nil, false
+	}
+	opts := &descriptorpb.MethodOptions{}
+	if err := proto.Unmarshal(data, opts); err != nil {
+		return nil, false
+	}
+	if rule := findHTTPRule(opts); rule != nil {
+		return rule, true
+	}
+	return nil, false
+}
+
+// findHTTPRule returns the "google.api.http" option in the given options, if
+// it is present and its value is an *annotations.HttpRule.
+func findHTTPRule(opts proto.Message) *annotations.HttpRule {
+	var rule *annotations.HttpRule
+	opts.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		if fd.IsExtension() && fd.FullName() == annotations.E_Http.TypeDescriptor().FullName() {
+			rule, _ = val.Message().Interface().(*annotations.HttpRule)
+			return false
+		}
+		return true
+	})
+	return rule
+}
+
+// HTTPMethodAndPath returns the HTTP method and path template from the given
+// rule's pattern. For custom patterns, the method is the pattern's kind. The
+// path template may contain variables, like "/v1/{name=shelves/*}". If the rule
+// has no pattern, empty strings are returned.
+//
+// Only the rule's own pattern is returned. Any additional bindings must be
+// examined separately.
+func HTTPMethodAndPath(rule *annotations.HttpRule) (httpMethod, path string) {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		return pattern.Custom.GetKind(), pattern.Custom.GetPath()
+	default:
+		return "", ""
+	}
+}
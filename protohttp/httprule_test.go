@@ -0,0 +1,123 @@
+package protohttp
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestGetHTTPRule(t *testing.T) {
+	rule := &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{Get: "/v1/{name=books/*}"},
+		AdditionalBindings: []*annotations.HttpRule{
+			{Pattern: &annotations.HttpRule_Post{Post: "/v1/books:get"}, Body: "*"},
+		},
+	}
+	withRule := &descriptorpb.MethodOptions{}
+	proto.SetExtension(withRule, annotations.E_Http, rule)
+	ruleData, err := proto.Marshal(withRule)
+	require.NoError(t, err)
+	unrecognized := &descriptorpb.MethodOptions{}
+	unrecognized.ProtoReflect().SetUnknown(ruleData)
+	deprecated := &descriptorpb.MethodOptions{Deprecated: proto.Bool(true)}
+	// option set with a dynamic extension type, like when the descriptor is
+	// built by a compiler that does not link in the generated extension
+	dynExt := dynamicpb.NewExtensionType(annotations.E_Http.TypeDescriptor().Descriptor())
+	dynRule := dynamicpb.NewMessage(annotations.E_Http.TypeDescriptor().Message())
+	require.NoError(t, proto.Unmarshal(mustMarshal(t, rule), dynRule))
+	dynamicExt := &descriptorpb.MethodOptions{}
+	proto.SetExtension(dynamicExt, dynExt, dynRule)
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("test.proto"),
+		Package:     proto.String("test"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Msg")}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Svc"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("WithRule"), InputType: proto.String(".test.Msg"), OutputType: proto.String(".test.Msg"), Options: withRule},
+				{Name: proto.String("Unrecognized"), InputType: proto.String(".test.Msg"), OutputType: proto.String(".test.Msg"), Options: unrecognized},
+				{Name: proto.String("DynamicExtension"), InputType: proto.String(".test.Msg"), OutputType: proto.String(".test.Msg"), Options: dynamicExt},
+				{Name: proto.String("NoRule"), InputType: proto.String(".test.Msg"), OutputType: proto.String(".test.Msg"), Options: deprecated},
+				{Name: proto.String("NoOptions"), InputType: proto.String(".test.Msg"), OutputType: proto.String(".test.Msg")},
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	require.NoError(t, err)
+	methods := fd.Services().ByName("Svc").Methods()
+
+	for _, name := range []protoreflect.Name{"WithRule", "Unrecognized", "DynamicExtension"} {
+		t.Run(string(name), func(t *testing.T) {
+			actual, ok := GetHTTPRule(methods.ByName(name))
+			require.True(t, ok)
+			assert.True(t, proto.Equal(rule, actual))
+		})
+	}
+	for _, name := range []protoreflect.Name{"NoRule", "NoOptions"} {
+		t.Run(string(name), func(t *testing.T) {
+			_, ok := GetHTTPRule(methods.ByName(name))
+			require.False(t, ok)
+		})
+	}
+
+	t.Run("dynamic options", func(t *testing.T) {
+		md := methods.ByName("WithRule")
+		dynOpts := dynamicpb.NewMessage((&descriptorpb.MethodOptions{}).ProtoReflect().Descriptor())
+		require.NoError(t, proto.Unmarshal(ruleData, dynOpts))
+		md = dynamicOptionsMethod{MethodDescriptor: md, opts: dynOpts}
+		actual, ok := GetHTTPRule(md)
+		require.True(t, ok)
+		assert.True(t, proto.Equal(rule, actual))
+	})
+}
+
+func mustMarshal(t *testing.T, msg proto.Message) []byte {
+	t.Helper()
+	data, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	return data
+}
+
+type dynamicOptionsMethod struct {
+	protoreflect.MethodDescriptor
+	opts proto.Message
+}
+
+func (m dynamicOptionsMethod) Options() protoreflect.ProtoMessage {
+	return m.opts
+}
+
+func TestHTTPMethodAndPath(t *testing.T) {
+	testCases := []struct {
+		name       string
+		rule       *annotations.HttpRule
+		wantMethod string
+		wantPath   string
+	}{
+		{name: "get", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/a"}}, wantMethod: http.MethodGet, wantPath: "/a"},
+		{name: "put", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Put{Put: "/b"}}, wantMethod: http.MethodPut, wantPath: "/b"},
+		{name: "post", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/c"}}, wantMethod: http.MethodPost, wantPath: "/c"},
+		{name: "delete", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Delete{Delete: "/d"}}, wantMethod: http.MethodDelete, wantPath: "/d"},
+		{name: "patch", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Patch{Patch: "/e"}}, wantMethod: http.MethodPatch, wantPath: "/e"},
+		{name: "custom", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: "HEAD", Path: "/f"}}}, wantMethod: http.MethodHead, wantPath: "/f"},
+		{name: "no pattern", rule: &annotations.HttpRule{Body: "*"}},
+		{name: "nil", rule: nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method, path := HTTPMethodAndPath(tc.rule)
+			assert.Equal(t, tc.wantMethod, method)
+			assert.Equal(t, tc.wantPath, path)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Package protojsonschema generates JSON Schema documents that describe the
+// JSON format of protobuf messages.
+//
+// The generated schemas follow the mapping used by the
+// [google.golang.org/protobuf/encoding/protojson] package (which is the
+// canonical JSON mapping described in the Protobuf language guide), including
+// the special representations of well-known types.
+package protojsonschema
+
+import (
+	"encoding/json"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/jsonschema"
+)
+
+const draft07 = "http://json-schema.org/draft-07/schema#"
+
+// MessageToJSONSchema returns a JSON Schema (draft-07) document that describes
+// the JSON format of the given message. The message and every message and enum
+// it references are described in the document's "definitions", keyed by their
+// fully-qualified names. Message fields refer to these definitions via "$ref",
+// so recursive messages are supported.
+//
+// Field properties use the fields' JSON names. Members of a oneof produce a
+// "oneOf" constraint that allows at most one of them to be present. Leading
+// comments, if the message's file has source code info, are used as
+// descriptions.
+func MessageToJSONSchema(md protoreflect.MessageDescriptor) ([]byte, error) {
+	g := jsonschema.NewGenerator(jsonschema.Draft07, "#/definitions/")
+	root := &document{
+		Schema:      draft07,
+		Title:       string(md.FullName()),
+		Ref:         g.MessageRef(md).Ref,
+		Definitions: g.Defs,
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+type document struct {
+	Schema      string                        `json:"$schema"`
+	Ref         string                        `json:"$ref"`
+	Title       string                        `json:"title"`
+	Definitions map[string]*jsonschema.Schema `json:"definitions"`
+}
@@ -0,0 +1,121 @@
+package protojsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageToJSONSchema(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"test.proto": `
+					syntax = "proto3";
+					package test;
+					import "google/protobuf/timestamp.proto";
+					// A foo.
+					message Foo {
+						int32 id = 1;
+						string name = 2;
+						bytes data = 3;
+						uint64 big = 4;
+						repeated double scores = 5;
+						map<string, Foo> children = 6;
+						Kind kind = 7;
+						google.protobuf.Timestamp created_at = 8;
+						oneof choice {
+							string text = 9;
+							Foo next = 10;
+						}
+						optional bool flag = 11;
+					}
+					enum Kind {
+						KIND_UNSPECIFIED = 0;
+						KIND_A = 1;
+					}
+				`,
+			}),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	md := files[0].Messages().ByName("Foo")
+
+	data, err := MessageToJSONSchema(md)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", doc["$schema"])
+	assert.Equal(t, "#/definitions/test.Foo", doc["$ref"])
+	defs := doc["definitions"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string", "enum": []any{"KIND_UNSPECIFIED", "KIND_A"}}, defs["test.Kind"])
+	assert.Equal(t, map[string]any{"type": "string", "format": "date-time"}, defs["google.protobuf.Timestamp"])
+
+	foo := defs["test.Foo"].(map[string]any)
+	assert.Equal(t, "object", foo["type"])
+	assert.Equal(t, "A foo.", foo["description"])
+	props := foo["properties"].(map[string]any)
+	expectedProps := map[string]any{
+		"id":        map[string]any{"type": "integer"},
+		"name":      map[string]any{"type": "string"},
+		"data":      map[string]any{"type": "string", "contentEncoding": "base64"},
+		"big":       map[string]any{"type": []any{"integer", "string"}},
+		"scores":    map[string]any{"type": "array", "items": map[string]any{"type": []any{"number", "string"}}},
+		"children":  map[string]any{"type": "object", "additionalProperties": map[string]any{"$ref": "#/definitions/test.Foo"}},
+		"kind":      map[string]any{"$ref": "#/definitions/test.Kind"},
+		"createdAt": map[string]any{"$ref": "#/definitions/google.protobuf.Timestamp"},
+		"text":      map[string]any{"type": "string"},
+		"next":      map[string]any{"$ref": "#/definitions/test.Foo"},
+		"flag":      map[string]any{"type": "boolean"},
+	}
+	assert.Equal(t, expectedProps, props)
+
+	// only the real oneof results in a constraint, not the synthetic one for "flag"
+	assert.Equal(t, []any{
+		map[string]any{"required": []any{"text"}},
+		map[string]any{"required": []any{"next"}},
+		map[string]any{"not": map[string]any{"anyOf": []any{
+			map[string]any{"required": []any{"text"}},
+			map[string]any{"required": []any{"next"}},
+		}}},
+	}, foo["oneOf"])
+	assert.NotContains(t, foo, "allOf")
+}
+
+func TestMessageToJSONSchema_MultipleOneofs(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"test.proto": `
+					syntax = "proto3";
+					package test;
+					message Foo {
+						oneof a { string a1 = 1; string a2 = 2; }
+						oneof b { string b1 = 3; string b2 = 4; }
+					}
+				`,
+			}),
+		},
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+
+	data, err := MessageToJSONSchema(files[0].Messages().ByName("Foo"))
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	foo := doc["definitions"].(map[string]any)["test.Foo"].(map[string]any)
+	assert.NotContains(t, foo, "oneOf")
+	allOf := foo["allOf"].([]any)
+	require.Len(t, allOf, 2)
+	for _, item := range allOf {
+		assert.Len(t, item.(map[string]any)["oneOf"], 3)
+	}
+}
@@ -0,0 +1,239 @@
+// Package protomarkdown generates reference documentation, in Markdown format,
+// from protobuf file descriptors and their source code comments.
+package protomarkdown
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal"
+	"github.com/jhump/protoreflect/v2/sourceinfo"
+)
+
+// FileToMarkdown returns a Markdown reference page for the given file. The page
+// starts with the file's leading comment, which is the comment that precedes the
+// file's syntax statement. It then has sections for the file's services (with
+// the signature of each method), messages (with a table that describes their
+// fields), and enums (with a table that describes their values). Nested messages
+// and enums are included in these sections, after their enclosing message.
+//
+// Comments in the file's source code info are rendered as prose paragraphs above
+// each element, and in the tables for fields and enum values. If the file has no
+// source code info but is a generated file that was processed with the
+// protoc-gen-gosrcinfo plugin, the source code info registered by that plugin is
+// used. (See [sourceinfo.AddSourceInfoToFile].)
+func FileToMarkdown(fd protoreflect.FileDescriptor) (string, error) {
+	fd, err := sourceinfo.AddSourceInfoToFile(fd)
+	if err != nil {
+		return "", err
+	}
+	p := printer{file: fd}
+	p.printFile()
+	return p.sb.String(), nil
+}
+
+type printer struct {
+	file protoreflect.FileDescriptor
+	sb   strings.Builder
+}
+
+func (p *printer) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(&p.sb, format, args...)
+}
+
+// printComment prints the given comment as a paragraph, if non-empty.
+func (p *printer) printComment(comment string) {
+	if comment != "" {
+		p.printf("%s\n\n", comment)
+	}
+}
+
+func (p *printer) printFile() {
+	p.printf("# %s\n\n", p.file.Path())
+	// file comments are attached to the syntax statement
+	syntaxLoc := p.file.SourceLocations().ByPath(protoreflect.SourcePath{internal.FileSyntaxTag})
+	for _, detached := range syntaxLoc.LeadingDetachedComments {
+		p.printComment(cleanComment(detached))
+	}
+	p.printComment(cleanComment(syntaxLoc.LeadingComments))
+	if pkg := p.file.Package(); pkg != "" {
+		p.printf("Package: `%s`\n\n", pkg)
+	}
+
+	svcs := p.file.Services()
+	if svcs.Len() > 0 {
+		p.printf("## Services\n\n")
+		for i, length := 0, svcs.Len(); i < length; i++ {
+			p.printService(svcs.Get(i))
+		}
+	}
+
+	var msgs []protoreflect.MessageDescriptor
+	var enums []protoreflect.EnumDescriptor
+	collectTypes(p.file, &msgs, &enums)
+	if len(msgs) > 0 {
+		p.printf("## Messages\n\n")
+		for _, md := range msgs {
+			p.printMessage(md)
+		}
+	}
+	if len(enums) > 0 {
+		p.printf("## Enums\n\n")
+		for _, ed := range enums {
+			p.printEnum(ed)
+		}
+	}
+}
+
+type typeContainer interface {
+	Messages() protoreflect.MessageDescriptors
+	Enums() protoreflect.EnumDescriptors
+}
+
+func collectTypes(container typeContainer, msgs *[]protoreflect.MessageDescriptor, enums *[]protoreflect.EnumDescriptor) {
+	for i, length := 0, container.Messages().Len(); i < length; i++ {
+		md := container.Messages().Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+		*msgs = append(*msgs, md)
+		collectTypes(md, msgs, enums)
+	}
+	for i, length := 0, container.Enums().Len(); i < length; i++ {
+		*enums = append(*enums, container.Enums().Get(i))
+	}
+}
+
+func (p *printer) printService(sd protoreflect.ServiceDescriptor) {
+	p.printf("### %s\n\n", sd.FullName())
+	p.printComment(p.comment(sd))
+	methods := sd.Methods()
+	for i, length := 0, methods.Len(); i < length; i++ {
+		md := methods.Get(i)
+		p.printf("#### %s\n\n", md.Name())
+		var reqStream, respStream string
+		if md.IsStreamingClient() {
+			reqStream = "stream "
+		}
+		if md.IsStreamingServer() {
+			respStream = "stream "
+		}
+		p.printf("```proto\nrpc %s(%s%s) returns (%s%s)\n```\n\n",
+			md.Name(), reqStream, md.Input().FullName(), respStream, md.Output().FullName())
+		p.printComment(p.comment(md))
+	}
+}
+
+func (p *printer) printMessage(md protoreflect.MessageDescriptor) {
+	p.printf("### %s\n\n", md.FullName())
+	p.printComment(p.comment(md))
+	fields := md.Fields()
+	if fields.Len() == 0 {
+		p.printf("This message has no fields.\n\n")
+		return
+	}
+	p.printf("| Field | Number | Type | Label | Description |\n")
+	p.printf("| ----- | ------ | ---- | ----- | ----------- |\n")
+	for i, length := 0, fields.Len(); i < length; i++ {
+		fld := fields.Get(i)
+		desc := tableCell(p.comment(fld))
+		if ood := fld.ContainingOneof(); ood != nil && !ood.IsSynthetic() {
+			desc = strings.TrimSpace(fmt.Sprintf("Part of oneof `%s`. %s", ood.Name(), desc))
+		}
+		p.printf("| `%s` | %d | `%s` | %s | %s |\n", fld.Name(), fld.Number(), fieldType(fld), fieldLabel(fld), desc)
+	}
+	p.printf("\n")
+}
+
+func (p *printer) printEnum(ed protoreflect.EnumDescriptor) {
+	p.printf("### %s\n\n", ed.FullName())
+	p.printComment(p.comment(ed))
+	p.printf("| Name | Number | Description |\n")
+	p.printf("| ---- | ------ | ----------- |\n")
+	vals := ed.Values()
+	for i, length := 0, vals.Len(); i < length; i++ {
+		val := vals.Get(i)
+		p.printf("| `%s` | %d | %s |\n", val.Name(), val.Number(), tableCell(p.comment(val)))
+	}
+	p.printf("\n")
+}
+
+// comment returns the comment for the given element: its leading comment if
+// present, otherwise its trailing comment.
+func (p *printer) comment(d protoreflect.Descriptor) string {
+	loc := p.file.SourceLocations().ByDescriptor(d)
+	if comment := cleanComment(loc.LeadingComments); comment != "" {
+		return comment
+	}
+	return cleanComment(loc.TrailingComments)
+}
+
+func fieldType(fld protoreflect.FieldDescriptor) string {
+	switch {
+	case fld.IsMap():
+		return fmt.Sprintf("map<%s, %s>", singularType(fld.MapKey()), singularType(fld.MapValue()))
+	default:
+		return singularType(fld)
+	}
+}
+
+func singularType(fld protoreflect.FieldDescriptor) string {
+	switch fld.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(fld.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(fld.Enum().FullName())
+	default:
+		return fld.Kind().String()
+	}
+}
+
+func fieldLabel(fld protoreflect.FieldDescriptor) string {
+	switch {
+	case fld.IsMap():
+		return ""
+	case fld.IsList():
+		return "repeated"
+	case fld.Cardinality() == protoreflect.Required:
+		return "required"
+	case fld.HasOptionalKeyword():
+		return "optional"
+	default:
+		return ""
+	}
+}
+
+// tableCell formats the given text for use in a table cell, which must be
+// on a single line and cannot contain unescaped pipe characters.
+func tableCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+// cleanComment removes the trailing newline and the leading space at the start
+// of each line of the given comment. For block comments where every line starts
+// with an asterisk, the asterisks are also removed, so they are not rendered as
+// list items.
+func cleanComment(comment string) string {
+	comment = strings.TrimSuffix(comment, "\n")
+	if comment == "" {
+		return ""
+	}
+	lines := strings.Split(comment, "\n")
+	starred := true
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, " ")
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "*") {
+			starred = false
+		}
+	}
+	if starred {
+		for i, line := range lines {
+			line = strings.TrimPrefix(strings.TrimSpace(line), "*")
+			lines[i] = strings.TrimPrefix(line, " ")
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,132 @@
+package protomarkdown
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+func TestFileToMarkdown(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"test.proto": `// The test API.
+
+// Defines the library.
+syntax = "proto3";
+package test;
+
+// Manages books.
+service Library {
+  // Gets a book.
+  rpc GetBook(Book) returns (Book);
+  rpc WatchBooks(Book) returns (stream Book);
+}
+
+// A book.
+message Book {
+  string name = 1; // The book's name | title.
+  // The kind
+  // of book.
+  Kind kind = 2;
+  map<string, int64> counts = 3;
+  repeated string tags = 4;
+  optional bool read = 5;
+  oneof format {
+    // Page count.
+    int32 pages = 6;
+    bool ebook = 7;
+  }
+  // Book kinds.
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+    // Fiction.
+    KIND_FICTION = 1;
+  }
+  message Empty {}
+}
+`,
+			}),
+		},
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+
+	md, err := FileToMarkdown(files[0])
+	require.NoError(t, err)
+	expected := "# test.proto\n" +
+		"\n" +
+		"The test API.\n" +
+		"\n" +
+		"Defines the library.\n" +
+		"\n" +
+		"Package: `test`\n" +
+		"\n" +
+		"## Services\n" +
+		"\n" +
+		"### test.Library\n" +
+		"\n" +
+		"Manages books.\n" +
+		"\n" +
+		"#### GetBook\n" +
+		"\n" +
+		"```proto\n" +
+		"rpc GetBook(test.Book) returns (test.Book)\n" +
+		"```\n" +
+		"\n" +
+		"Gets a book.\n" +
+		"\n" +
+		"#### WatchBooks\n" +
+		"\n" +
+		"```proto\n" +
+		"rpc WatchBooks(test.Book) returns (stream test.Book)\n" +
+		"```\n" +
+		"\n" +
+		"## Messages\n" +
+		"\n" +
+		"### test.Book\n" +
+		"\n" +
+		"A book.\n" +
+		"\n" +
+		"| Field | Number | Type | Label | Description |\n" +
+		"| ----- | ------ | ---- | ----- | ----------- |\n" +
+		"| `name` | 1 | `string` |  | The book's name \\| title. |\n" +
+		"| `kind` | 2 | `test.Book.Kind` |  | The kind of book. |\n" +
+		"| `counts` | 3 | `map<string, int64>` |  |  |\n" +
+		"| `tags` | 4 | `string` | repeated |  |\n" +
+		"| `read` | 5 | `bool` | optional |  |\n" +
+		"| `pages` | 6 | `int32` |  | Part of oneof `format`. Page count. |\n" +
+		"| `ebook` | 7 | `bool` |  | Part of oneof `format`. |\n" +
+		"\n" +
+		"### test.Book.Empty\n" +
+		"\n" +
+		"This message has no fields.\n" +
+		"\n" +
+		"## Enums\n" +
+		"\n" +
+		"### test.Book.Kind\n" +
+		"\n" +
+		"Book kinds.\n" +
+		"\n" +
+		"| Name | Number | Description |\n" +
+		"| ---- | ------ | ----------- |\n" +
+		"| `KIND_UNSPECIFIED` | 0 |  |\n" +
+		"| `KIND_FICTION` | 1 | Fiction. |\n" +
+		"\n"
+	assert.Equal(t, expected, md)
+}
+
+func TestFileToMarkdown_GeneratedFile(t *testing.T) {
+	// Comments come from source info registered by protoc-gen-gosrcinfo.
+	md, err := FileToMarkdown(testprotos.File_desc_test_comments_proto)
+	require.NoError(t, err)
+	assert.Contains(t, md, "This is the first detached comment for the syntax.\n\nThis is a second detached comment.\n\n")
+	assert.Contains(t, md, "### foo.bar.Request\n\nWe need a request for our RPC service below.\n\n")
+	assert.Contains(t, md, "| `ids` | 1 | `int32` | repeated | A field comment |\n")
+}
@@ -0,0 +1,41 @@
+package protomessage
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+const anyMessageName = "google.protobuf.Any"
+
+// UnmarshalAny unmarshals the contents of the given google.protobuf.Any message
+// into dest. The given anyMsg may be an *anypb.Any or any other message whose
+// type is google.protobuf.Any, such as a dynamic message. This is like
+// anypb.UnmarshalTo, except that it does not require the Any to be a
+// generated message.
+//
+// The type URL in anyMsg must refer to the same message type as dest, or else
+// an error is returned. As with [proto.Unmarshal], dest is reset before the
+// value is unmarshalled into it.
+func UnmarshalAny(anyMsg proto.Message, dest proto.Message) error {
+	msg := anyMsg.ProtoReflect()
+	md := msg.Descriptor()
+	if md.FullName() != anyMessageName {
+		return fmt.Errorf("message is %q, not %q", md.FullName(), anyMessageName)
+	}
+	typeURLField := md.Fields().ByName("type_url")
+	valueField := md.Fields().ByName("value")
+	if typeURLField == nil || typeURLField.Kind() != protoreflect.StringKind ||
+		valueField == nil || valueField.Kind() != protoreflect.BytesKind {
+		return fmt.Errorf("descriptor for %q is malformed", anyMessageName)
+	}
+	typeURL := msg.Get(typeURLField).String()
+	destName := dest.ProtoReflect().Descriptor().FullName()
+	if name := protoresolve.TypeNameFromURL(typeURL); name != destName {
+		return fmt.Errorf("type URL %q does not match destination message type %q", typeURL, destName)
+	}
+	return proto.Unmarshal(msg.Get(valueField).Bytes(), dest)
+}
@@ -0,0 +1,37 @@
+package protomessage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+func TestUnmarshalAny(t *testing.T) {
+	orig := &testprotos.TestRequest{Bar: "bar", Flags: map[string]bool{"a": true}}
+	anyMsg, err := anypb.New(orig)
+	require.NoError(t, err)
+	dynAny := dynamicpb.NewMessage(anyMsg.ProtoReflect().Descriptor())
+	proto.Merge(dynAny, anyMsg)
+
+	for _, src := range []proto.Message{anyMsg, dynAny} {
+		// into generated message
+		var dest testprotos.TestRequest
+		require.NoError(t, UnmarshalAny(src, &dest))
+		require.True(t, proto.Equal(orig, &dest))
+		// into dynamic message
+		dynDest := dynamicpb.NewMessage(orig.ProtoReflect().Descriptor())
+		require.NoError(t, UnmarshalAny(src, dynDest))
+		require.True(t, proto.Equal(orig, dynDest))
+	}
+
+	err = UnmarshalAny(anyMsg, &testprotos.TestResponse{})
+	require.ErrorContains(t, err, `type URL "type.googleapis.com/testprotos.TestRequest" does not match destination message type "testprotos.TestResponse"`)
+	err = UnmarshalAny(wrapperspb.String("abc"), &testprotos.TestRequest{})
+	require.ErrorContains(t, err, `message is "google.protobuf.StringValue", not "google.protobuf.Any"`)
+}
@@ -0,0 +1,135 @@
+package protomessage
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/jhump/protoreflect/v2/internal"
+)
+
+// ApplyFieldMask returns a copy of the given message that contains only the
+// fields named in the given mask. The given message is not modified.
+//
+// Each path in the mask is a dot-separated sequence of field names, where all
+// but the last component must refer to singular (non-repeated, non-map) message
+// fields. So a path like "author.name" will retain only the "name" field of the
+// "author" field, clearing all other sub-fields of "author". If a path refers to
+// a message field without naming any sub-fields, the entire message value is
+// retained.
+//
+// Unknown fields and extensions are never retained since they cannot be named
+// by a field mask. If any path in the mask cannot be resolved against the
+// message's descriptor, an error is returned that enumerates all such paths.
+func ApplyFieldMask[M proto.Message](msg M, mask *fieldmaskpb.FieldMask) (M, error) {
+	md := msg.ProtoReflect().Descriptor()
+	tree := fieldMaskTree{}
+	var invalid []string
+	for _, path := range mask.GetPaths() {
+		if !tree.add(md, strings.Split(path, ".")) {
+			invalid = append(invalid, path)
+		}
+	}
+	if len(invalid) > 0 {
+		var zero M
+		return zero, fmt.Errorf("field mask has invalid paths for message %s: %q", md.FullName(), invalid)
+	}
+	clone := proto.Clone(msg).(M)
+	tree.prune(clone.ProtoReflect())
+	return clone, nil
+}
+
+// AllFieldMaskPaths returns all paths that are valid in a field mask for the
+// given message type. This includes paths for every field of the message as
+// well as, for singular message fields, paths for every field of the nested
+// message, and so on. Paths are returned in depth-first order, following the
+// order in which fields are declared.
+//
+// Repeated and map fields are leaves since field mask paths cannot traverse
+// them. Recursive message types are also not expanded more than once along a
+// path, so the result is always finite.
+func AllFieldMaskPaths(md protoreflect.MessageDescriptor) []string {
+	var paths []string
+	inProgress := map[protoreflect.FullName]struct{}{}
+	var addPaths func(md protoreflect.MessageDescriptor, prefix string)
+	addPaths = func(md protoreflect.MessageDescriptor, prefix string) {
+		inProgress[md.FullName()] = struct{}{}
+		defer delete(inProgress, md.FullName())
+		fields := md.Fields()
+		for i, length := 0, fields.Len(); i < length; i++ {
+			fd := fields.Get(i)
+			path := prefix + string(fd.Name())
+			paths = append(paths, path)
+			if fd.Cardinality() == protoreflect.Repeated || !internal.IsMessageKind(fd.Kind()) {
+				continue
+			}
+			if _, ok := inProgress[fd.Message().FullName()]; ok {
+				// recursive type
+				continue
+			}
+			addPaths(fd.Message(), path+".")
+		}
+	}
+	addPaths(md, "")
+	return paths
+}
+
+// fieldMaskTree is a trie of field names. A nil value means that the
+// named field is a leaf, so its entire value is retained.
+type fieldMaskTree map[protoreflect.Name]fieldMaskTree
+
+func (t fieldMaskTree) add(md protoreflect.MessageDescriptor, path []string) bool {
+	fd := md.Fields().ByName(protoreflect.Name(path[0]))
+	if fd == nil {
+		return false
+	}
+	if len(path) == 1 {
+		// entire field is included; discard any narrower paths
+		t[fd.Name()] = nil
+		return true
+	}
+	if fd.Cardinality() == protoreflect.Repeated || !internal.IsMessageKind(fd.Kind()) {
+		return false
+	}
+	sub, exists := t[fd.Name()]
+	if exists && sub == nil {
+		// entire field already included; need to validate the rest of the
+		// path but otherwise nothing to do
+		return fieldMaskTree{}.add(fd.Message(), path[1:])
+	}
+	if sub == nil {
+		sub = fieldMaskTree{}
+	}
+	if !sub.add(fd.Message(), path[1:]) {
+		return false
+	}
+	t[fd.Name()] = sub
+	return true
+}
+
+func (t fieldMaskTree) prune(msg protoreflect.Message) {
+	var toClear []protoreflect.FieldDescriptor
+	msg.Range(func(fd protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		if fd.IsExtension() {
+			toClear = append(toClear, fd)
+			return true
+		}
+		sub, ok := t[fd.Name()]
+		switch {
+		case !ok:
+			toClear = append(toClear, fd)
+		case sub != nil:
+			sub.prune(val.Message())
+		}
+		return true
+	})
+	for _, fd := range toClear {
+		msg.Clear(fd)
+	}
+	if len(msg.GetUnknown()) > 0 {
+		msg.SetUnknown(nil)
+	}
+}
@@ -0,0 +1,93 @@
+package protomessage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+func TestApplyFieldMask(t *testing.T) {
+	msg := &testprotos.TestMessage{
+		Nm: &testprotos.TestMessage_NestedMessage{
+			Anm: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage{
+				Yanm: []*testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{
+					{Foo: proto.String("foo")},
+				},
+			},
+			Yanm: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{
+				Foo: proto.String("abc"),
+				Bar: proto.Int32(123),
+			},
+		},
+		Yanm: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{
+			Baz: []byte("xyz"),
+		},
+		Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE1},
+	}
+	orig := proto.Clone(msg)
+
+	masked, err := ApplyFieldMask(msg, &fieldmaskpb.FieldMask{Paths: []string{"nm.yanm.bar", "ne"}})
+	require.NoError(t, err)
+	expected := &testprotos.TestMessage{
+		Nm: &testprotos.TestMessage_NestedMessage{
+			Yanm: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{
+				Bar: proto.Int32(123),
+			},
+		},
+		Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE1},
+	}
+	require.True(t, proto.Equal(expected, masked), "%v != %v", expected, masked)
+	// original is unchanged
+	require.True(t, proto.Equal(orig, msg))
+
+	// broader path subsumes narrower one, regardless of order
+	masked, err = ApplyFieldMask(msg, &fieldmaskpb.FieldMask{Paths: []string{"nm.yanm.bar", "nm", "yanm.foo"}})
+	require.NoError(t, err)
+	expected = &testprotos.TestMessage{
+		Nm:   msg.Nm,
+		Yanm: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{},
+	}
+	require.True(t, proto.Equal(expected, masked), "%v != %v", expected, masked)
+
+	// works with dynamic messages, too
+	dyn := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+	proto.Merge(dyn, msg)
+	dynMasked, err := ApplyFieldMask(dyn, &fieldmaskpb.FieldMask{Paths: []string{"yanm"}})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(&testprotos.TestMessage{Yanm: msg.Yanm}, dynMasked))
+
+	// invalid paths
+	_, err = ApplyFieldMask(msg, &fieldmaskpb.FieldMask{Paths: []string{"nm.yanm.bar", "nm.xyz", "ne.foo", "anm.yanm.foo", "abc"}})
+	require.EqualError(t, err, `field mask has invalid paths for message testprotos.TestMessage: ["nm.xyz" "ne.foo" "anm.yanm.foo" "abc"]`)
+}
+
+func TestAllFieldMaskPaths(t *testing.T) {
+	md := (&testprotos.TestMessage{}).ProtoReflect().Descriptor()
+	paths := AllFieldMaskPaths(md)
+	// recursive types (like nm.yanm.nm and nm.yanm.tm) are not expanded
+	require.Equal(t, []string{
+		"nm", "nm.anm", "nm.anm.yanm",
+		"nm.yanm", "nm.yanm.foo", "nm.yanm.bar", "nm.yanm.baz", "nm.yanm.dne",
+		"nm.yanm.anm", "nm.yanm.anm.yanm", "nm.yanm.nm", "nm.yanm.tm",
+		"anm", "anm.yanm",
+		"yanm", "yanm.foo", "yanm.bar", "yanm.baz", "yanm.dne",
+		"yanm.anm", "yanm.anm.yanm",
+		"yanm.nm", "yanm.nm.anm", "yanm.nm.anm.yanm", "yanm.nm.yanm",
+		"yanm.tm",
+		"ne",
+	}, paths)
+	// all paths are valid
+	_, err := ApplyFieldMask(&testprotos.TestMessage{}, &fieldmaskpb.FieldMask{Paths: paths})
+	require.NoError(t, err)
+
+	// repeated and map fields are leaves
+	paths = AllFieldMaskPaths((&testprotos.TestRequest{}).ProtoReflect().Descriptor())
+	require.Contains(t, paths, "snafu.yanm")
+	require.Contains(t, paths, "others")
+	require.NotContains(t, paths, "others.ne")
+}
@@ -0,0 +1,411 @@
+package protomessage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal"
+)
+
+// PathError is returned by the *FieldByPath functions when the given path
+// is malformed or cannot be resolved against a message.
+type PathError struct {
+	// The complete path that was supplied.
+	Path string
+	// The segment of the path that is invalid. This is empty if the path
+	// could not be parsed at all.
+	Segment string
+	// A description of why the segment is invalid.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *PathError) Error() string {
+	if e.Segment == "" {
+		return fmt.Sprintf("invalid path %q: %s", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("invalid path %q: segment %q: %s", e.Path, e.Segment, e.Reason)
+}
+
+// GetFieldByPath returns the value at the given path in msg. The path is a
+// dot-separated sequence of field names, like "order.customer.name". A
+// segment that refers to a list field may include an index in brackets, like
+// "order.items[2].sku", and a segment that refers to a map field may include
+// a key in brackets, like `labels["foo"]`. Quoted keys use Go string literal
+// syntax, so they may contain brackets and escape sequences, like
+// `labels["a]\"b"]`. String keys may be unquoted, as in "labels[foo]", as long
+// as they do not contain brackets.
+//
+// If a message field along the path is not set, its default value (an empty,
+// read-only message) is used to resolve the rest of the path. So the value
+// returned is the same as if msg.ProtoReflect().Get were called on the last
+// message in the path. If the path includes a list index that is out of range
+// or a map key that is not present, a *PathError is returned.
+func GetFieldByPath(msg proto.Message, path string) (protoreflect.Value, error) {
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	current := msg.ProtoReflect()
+	for i, seg := range segments {
+		fd, err := seg.resolve(path, current.Descriptor(), i < len(segments)-1)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		val, err := seg.get(path, fd, current.Get(fd))
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		if i == len(segments)-1 {
+			return val, nil
+		}
+		current = val.Message()
+	}
+	panic("unreachable")
+}
+
+// SetFieldByPath sets the value at the given path in msg. See GetFieldByPath
+// for the syntax of path.
+//
+// Any message fields along the path that are not set will be set to empty
+// messages. If the last segment of the path includes a list index, it must be
+// in range and will replace the element at that index. If the last segment
+// includes a map key, the map entry for that key will be added or replaced.
+//
+// Like [protoreflect.Message.Set], this will panic if val is not the right type
+// for the referenced field.
+func SetFieldByPath(msg proto.Message, path string, val protoreflect.Value) error {
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return err
+	}
+	current, err := mutableParent(msg.ProtoReflect(), path, segments)
+	if err != nil {
+		return err
+	}
+	last := segments[len(segments)-1]
+	fd, err := last.resolve(path, current.Descriptor(), false)
+	if err != nil {
+		return err
+	}
+	switch {
+	case last.key == nil:
+		current.Set(fd, val)
+	case fd.IsList():
+		l := current.Mutable(fd).List()
+		index, err := last.listIndex(path, l.Len())
+		if err != nil {
+			return err
+		}
+		l.Set(index, val)
+	default:
+		key, err := last.mapKey(path, fd)
+		if err != nil {
+			return err
+		}
+		current.Mutable(fd).Map().Set(key, val)
+	}
+	return nil
+}
+
+// ClearFieldByPath clears the value at the given path in msg. See
+// GetFieldByPath for the syntax of path.
+//
+// If the last segment of the path includes a list index, the element at that
+// index is removed from the list, and subsequent elements are shifted down. If
+// the last segment includes a map key, the entry for that key is removed.
+// If any message field along the path is not set, this is a no-op, since there
+// is nothing to clear.
+func ClearFieldByPath(msg proto.Message, path string) error {
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return err
+	}
+	current := msg.ProtoReflect()
+	for i, seg := range segments[:len(segments)-1] {
+		fd, err := seg.resolve(path, current.Descriptor(), true)
+		if err != nil {
+			return err
+		}
+		if !current.Has(fd) || (seg.key != nil && fd.IsMap() && !hasMapKey(path, seg, fd, current.Get(fd).Map())) {
+			// Nothing to clear. But we still validate the rest of the path.
+			return checkFieldPath(path, fieldMessage(fd), segments[i+1:])
+		}
+		val, err := seg.get(path, fd, current.Get(fd))
+		if err != nil {
+			return err
+		}
+		current = val.Message()
+	}
+	last := segments[len(segments)-1]
+	fd, err := last.resolve(path, current.Descriptor(), false)
+	if err != nil {
+		return err
+	}
+	switch {
+	case last.key == nil:
+		current.Clear(fd)
+	case fd.IsList():
+		if !current.Has(fd) {
+			_, err := last.listIndex(path, 0)
+			return err
+		}
+		l := current.Mutable(fd).List()
+		index, err := last.listIndex(path, l.Len())
+		if err != nil {
+			return err
+		}
+		for j := index + 1; j < l.Len(); j++ {
+			l.Set(j-1, l.Get(j))
+		}
+		l.Truncate(l.Len() - 1)
+	default:
+		key, err := last.mapKey(path, fd)
+		if err != nil {
+			return err
+		}
+		if current.Has(fd) {
+			current.Mutable(fd).Map().Clear(key)
+		}
+	}
+	return nil
+}
+
+func mutableParent(msg protoreflect.Message, path string, segments []pathSegment) (protoreflect.Message, error) {
+	current := msg
+	for _, seg := range segments[:len(segments)-1] {
+		fd, err := seg.resolve(path, current.Descriptor(), true)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case seg.key == nil:
+			current = current.Mutable(fd).Message()
+		case fd.IsList():
+			l := current.Mutable(fd).List()
+			index, err := seg.listIndex(path, l.Len())
+			if err != nil {
+				return nil, err
+			}
+			current = l.Get(index).Message()
+		default:
+			key, err := seg.mapKey(path, fd)
+			if err != nil {
+				return nil, err
+			}
+			current = current.Mutable(fd).Map().Mutable(key).Message()
+		}
+	}
+	return current, nil
+}
+
+func checkFieldPath(path string, md protoreflect.MessageDescriptor, segments []pathSegment) error {
+	for i, seg := range segments {
+		fd, err := seg.resolve(path, md, i < len(segments)-1)
+		if err != nil {
+			return err
+		}
+		if seg.key != nil && fd.IsMap() {
+			if _, err := seg.mapKey(path, fd); err != nil {
+				return err
+			}
+		}
+		md = fieldMessage(fd)
+	}
+	return nil
+}
+
+func hasMapKey(path string, seg pathSegment, fd protoreflect.FieldDescriptor, m protoreflect.Map) bool {
+	key, err := seg.mapKey(path, fd)
+	if err != nil {
+		// let caller report the error
+		return true
+	}
+	return m.Has(key)
+}
+
+// fieldMessage returns the message type of the given field. For map
+// fields, this is the type of the map values, not the map entry.
+func fieldMessage(fd protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	if fd.IsMap() {
+		return fd.MapValue().Message()
+	}
+	return fd.Message()
+}
+
+type pathSegment struct {
+	text string
+	name protoreflect.Name
+	// non-nil if the segment includes a list index or map key
+	key *string
+}
+
+func parseFieldPath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, &PathError{Path: path, Reason: "path is empty"}
+	}
+	var segments []pathSegment
+	remaining := path
+	for {
+		var seg pathSegment
+		end := strings.IndexAny(remaining, ".[")
+		if end == -1 {
+			end = len(remaining)
+		}
+		seg.name = protoreflect.Name(remaining[:end])
+		if !seg.name.IsValid() {
+			return nil, &PathError{Path: path, Segment: remaining[:end], Reason: "not a valid field name"}
+		}
+		rest := remaining[end:]
+		if strings.HasPrefix(rest, "[") {
+			closeBracket := strings.IndexByte(rest, ']')
+			if strings.HasPrefix(rest, `["`) {
+				// the key is quoted, so it may contain a closing bracket
+				closeQuote := closingQuote(rest[1:])
+				if closeQuote == -1 {
+					return nil, &PathError{Path: path, Segment: remaining, Reason: "missing closing quote"}
+				}
+				closeBracket = closeQuote + 2
+				if !strings.HasPrefix(rest[closeBracket:], "]") {
+					closeBracket = -1
+				}
+			}
+			if closeBracket == -1 {
+				return nil, &PathError{Path: path, Segment: remaining, Reason: "missing closing bracket"}
+			}
+			key := rest[1:closeBracket]
+			seg.key = &key
+			rest = rest[closeBracket+1:]
+			end = len(remaining) - len(rest)
+		}
+		seg.text = remaining[:end]
+		segments = append(segments, seg)
+		if rest == "" {
+			return segments, nil
+		}
+		if !strings.HasPrefix(rest, ".") {
+			return nil, &PathError{Path: path, Segment: seg.text, Reason: "expecting '.' after closing bracket"}
+		}
+		remaining = rest[1:]
+	}
+}
+
+// closingQuote returns the index of the double-quote that terminates the
+// quoted string at the start of s, skipping over any escaped characters. It
+// returns -1 if the string is not terminated.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// resolve returns the field in md that this segment refers to. If
+// intermediate is true, the segment (including any index or key) must
+// refer to a message value, so that the path can continue past it.
+func (s pathSegment) resolve(path string, md protoreflect.MessageDescriptor, intermediate bool) (protoreflect.FieldDescriptor, error) {
+	fd := md.Fields().ByName(s.name)
+	if fd == nil {
+		return nil, &PathError{Path: path, Segment: s.text, Reason: fmt.Sprintf("message %s has no field named %q", md.FullName(), s.name)}
+	}
+	if s.key != nil && !fd.IsList() && !fd.IsMap() {
+		return nil, &PathError{Path: path, Segment: s.text, Reason: "index or key given for field that is neither a list nor a map"}
+	}
+	if !intermediate {
+		return fd, nil
+	}
+	if (fd.IsList() || fd.IsMap()) && s.key == nil {
+		return nil, &PathError{Path: path, Segment: s.text, Reason: "list or map field must have an index or key to be followed by more path segments"}
+	}
+	valField := fd
+	if fd.IsMap() {
+		valField = fd.MapValue()
+	}
+	if !internal.IsMessageKind(valField.Kind()) {
+		return nil, &PathError{Path: path, Segment: s.text, Reason: "value is not a message, so cannot be followed by more path segments"}
+	}
+	return fd, nil
+}
+
+func (s pathSegment) get(path string, fd protoreflect.FieldDescriptor, val protoreflect.Value) (protoreflect.Value, error) {
+	switch {
+	case s.key == nil:
+		return val, nil
+	case fd.IsList():
+		l := val.List()
+		index, err := s.listIndex(path, l.Len())
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return l.Get(index), nil
+	default:
+		key, err := s.mapKey(path, fd)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		m := val.Map()
+		if !m.Has(key) {
+			return protoreflect.Value{}, &PathError{Path: path, Segment: s.text, Reason: "map has no entry for key"}
+		}
+		return m.Get(key), nil
+	}
+}
+
+func (s pathSegment) listIndex(path string, length int) (int, error) {
+	index, err := strconv.Atoi(*s.key)
+	if err != nil {
+		return 0, &PathError{Path: path, Segment: s.text, Reason: fmt.Sprintf("invalid list index %q", *s.key)}
+	}
+	if index < 0 || index >= length {
+		return 0, &PathError{Path: path, Segment: s.text, Reason: fmt.Sprintf("index %d out of range; list has %d elements", index, length)}
+	}
+	return index, nil
+}
+
+func (s pathSegment) mapKey(path string, fd protoreflect.FieldDescriptor) (protoreflect.MapKey, error) {
+	str := *s.key
+	var val protoreflect.Value
+	var err error
+	switch kind := fd.MapKey().Kind(); kind {
+	case protoreflect.StringKind:
+		if strings.HasPrefix(str, `"`) {
+			str, err = strconv.Unquote(str)
+		}
+		val = protoreflect.ValueOfString(str)
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = strconv.ParseBool(str)
+		val = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var i int64
+		i, err = strconv.ParseInt(str, 10, 32)
+		val = protoreflect.ValueOfInt32(int32(i))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var i int64
+		i, err = strconv.ParseInt(str, 10, 64)
+		val = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var u uint64
+		u, err = strconv.ParseUint(str, 10, 32)
+		val = protoreflect.ValueOfUint32(uint32(u))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var u uint64
+		u, err = strconv.ParseUint(str, 10, 64)
+		val = protoreflect.ValueOfUint64(u)
+	default:
+		return protoreflect.MapKey{}, &PathError{Path: path, Segment: s.text, Reason: fmt.Sprintf("unsupported map key kind %v", kind)}
+	}
+	if err != nil {
+		return protoreflect.MapKey{}, &PathError{Path: path, Segment: s.text, Reason: fmt.Sprintf("invalid map key %q", *s.key)}
+	}
+	return val.MapKey(), nil
+}
@@ -0,0 +1,124 @@
+package protomessage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+func TestFieldByPath(t *testing.T) {
+	msg := &testprotos.TestRequest{
+		Foo: []testprotos.Proto3Enum{testprotos.Proto3Enum_VALUE1, testprotos.Proto3Enum_VALUE2},
+		Bar: "bar",
+		Snafu: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage{
+			Yanm: []*testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{
+				{Foo: proto.String("abc")},
+				{Foo: proto.String("def")},
+			},
+		},
+		Flags: map[string]bool{"a": true},
+		Others: map[string]*testprotos.TestMessage{
+			"x.y": {Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE2}},
+		},
+	}
+
+	val, err := GetFieldByPath(msg, "bar")
+	require.NoError(t, err)
+	require.Equal(t, "bar", val.String())
+	val, err = GetFieldByPath(msg, "foo[1]")
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.EnumNumber(testprotos.Proto3Enum_VALUE2), val.Enum())
+	val, err = GetFieldByPath(msg, "snafu.yanm[1].foo")
+	require.NoError(t, err)
+	require.Equal(t, "def", val.String())
+	val, err = GetFieldByPath(msg, "flags[a]")
+	require.NoError(t, err)
+	require.True(t, val.Bool())
+	val, err = GetFieldByPath(msg, `others["x.y"].ne[0]`)
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.EnumNumber(testprotos.TestMessage_VALUE2), val.Enum())
+	// unset message field along the way yields default value
+	val, err = GetFieldByPath(msg, "baz.nm.yanm.bar")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), val.Int())
+
+	require.NoError(t, SetFieldByPath(msg, "snafu.yanm[0].bar", protoreflect.ValueOfInt32(42)))
+	require.Equal(t, int32(42), msg.Snafu.Yanm[0].GetBar())
+	require.NoError(t, SetFieldByPath(msg, "baz.nm.yanm.foo", protoreflect.ValueOfString("xyz")))
+	require.Equal(t, "xyz", msg.Baz.GetNm().GetYanm().GetFoo())
+	require.NoError(t, SetFieldByPath(msg, `others["new"].nm.yanm.foo`, protoreflect.ValueOfString("new")))
+	require.Equal(t, "new", msg.Others["new"].GetNm().GetYanm().GetFoo())
+	require.NoError(t, SetFieldByPath(msg, "flags[b]", protoreflect.ValueOfBool(false)))
+	require.Equal(t, map[string]bool{"a": true, "b": false}, msg.Flags)
+
+	require.NoError(t, ClearFieldByPath(msg, "snafu.yanm[0]"))
+	require.Len(t, msg.Snafu.Yanm, 1)
+	require.Equal(t, "def", msg.Snafu.Yanm[0].GetFoo())
+	require.NoError(t, ClearFieldByPath(msg, "flags[a]"))
+	require.Equal(t, map[string]bool{"b": false}, msg.Flags)
+	require.NoError(t, ClearFieldByPath(msg, "baz.nm"))
+	require.NotNil(t, msg.Baz)
+	require.Nil(t, msg.Baz.Nm)
+	// no-op since intermediate fields are absent
+	require.NoError(t, ClearFieldByPath(msg, "baz.nm.anm.yanm"))
+	require.NoError(t, ClearFieldByPath(msg, "others[nope].nm"))
+
+	// quoted keys may contain brackets and escaped quotes
+	require.NoError(t, SetFieldByPath(msg, `flags["a]b"]`, protoreflect.ValueOfBool(true)))
+	require.NoError(t, SetFieldByPath(msg, `flags["c\"]d"]`, protoreflect.ValueOfBool(true)))
+	require.Equal(t, map[string]bool{"b": false, "a]b": true, `c"]d`: true}, msg.Flags)
+	val, err = GetFieldByPath(msg, `flags["a]b"]`)
+	require.NoError(t, err)
+	require.True(t, val.Bool())
+	val, err = GetFieldByPath(msg, `flags["c\"]d"]`)
+	require.NoError(t, err)
+	require.True(t, val.Bool())
+	require.NoError(t, ClearFieldByPath(msg, `flags["a]b"]`))
+	require.NoError(t, ClearFieldByPath(msg, `flags["c\"]d"]`))
+	require.Equal(t, map[string]bool{"b": false}, msg.Flags)
+
+	// also works with dynamic messages
+	dyn := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+	require.NoError(t, SetFieldByPath(dyn, `others["abc"].yanm.bar`, protoreflect.ValueOfInt32(101)))
+	val, err = GetFieldByPath(dyn, "others[abc].yanm.bar")
+	require.NoError(t, err)
+	require.Equal(t, int64(101), val.Int())
+
+	testCases := []struct {
+		path, segment, reason string
+	}{
+		{"", "", "path is empty"},
+		{"bar.", "", "not a valid field name"},
+		{"foo[1", "foo[1", "missing closing bracket"},
+		{"foo[1]x", "foo[1]", "expecting '.' after closing bracket"},
+		{`flags["a]`, `flags["a]`, "missing closing quote"},
+		{`flags["a\"]`, `flags["a\"]`, "missing closing quote"},
+		{`flags["a"b]`, `flags["a"b]`, "missing closing bracket"},
+		{"blah", "blah", `message testprotos.TestRequest has no field named "blah"`},
+		{"bar[0]", "bar[0]", "index or key given for field that is neither a list nor a map"},
+		{"bar.baz", "bar", "value is not a message, so cannot be followed by more path segments"},
+		{"snafu.yanm.foo", "yanm", "list or map field must have an index or key to be followed by more path segments"},
+		{"snafu.yanm[5].foo", "yanm[5]", "index 5 out of range; list has 1 elements"},
+		{"snafu.yanm[-1].foo", "yanm[-1]", "index -1 out of range; list has 1 elements"},
+		{"snafu.yanm[one].foo", "yanm[one]", `invalid list index "one"`},
+		{"others[abc].ne", "others[abc]", "map has no entry for key"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			_, err := GetFieldByPath(msg, tc.path)
+			var pathErr *PathError
+			require.True(t, errors.As(err, &pathErr), "unexpected error: %v", err)
+			require.Equal(t, tc.path, pathErr.Path)
+			if tc.segment != "" {
+				require.Equal(t, tc.segment, pathErr.Segment)
+			}
+			require.Equal(t, tc.reason, pathErr.Reason)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package protomessage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ApplyJSONMergePatch applies the given JSON Merge Patch, as described in
+// RFC 7396, to msg. The patch must be a JSON object whose keys are the JSON
+// names or proto names of fields in msg. This is commonly used to implement
+// partial updates, such as PATCH requests in a gRPC gateway.
+//
+// Fields in the patch are applied as follows:
+//   - A null value clears the field. This is true even for fields whose type
+//     is google.protobuf.Value, which would otherwise use null to represent
+//     a NullValue.
+//   - An object value for a singular message field is recursively merged into
+//     the message, creating the message if the field is not set.
+//   - An object value for a map field is merged into the map: a null value for
+//     a key removes that entry, an object value for a key whose value type is
+//     a message is recursively merged into the existing entry, and any other
+//     value replaces the entry.
+//   - All other values, including arrays for repeated fields, replace the
+//     current value of the field. Values are interpreted using the same rules
+//     as [protojson.Unmarshal].
+//
+// Well-known types in the google.protobuf package, such as Timestamp and
+// Struct, are never merged. They are always replaced, since many of them have
+// special JSON representations.
+//
+// If an error occurs, msg may have been partially modified.
+func ApplyJSONMergePatch(msg proto.Message, patch []byte) error {
+	return applyJSONMergePatch(msg.ProtoReflect(), patch)
+}
+
+func applyJSONMergePatch(msg protoreflect.Message, patch []byte) error {
+	if !isJSONObject(patch) {
+		return fmt.Errorf("patch for %s must be a JSON object", msg.Descriptor().FullName())
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		return err
+	}
+	md := msg.Descriptor()
+	for name, val := range fields {
+		fd := md.Fields().ByJSONName(name)
+		if fd == nil {
+			fd = md.Fields().ByName(protoreflect.Name(name))
+		}
+		if fd == nil {
+			return fmt.Errorf("%s has no field named %q", md.FullName(), name)
+		}
+		switch {
+		case isJSONNull(val):
+			msg.Clear(fd)
+		case fd.IsMap() && isJSONObject(val):
+			if err := applyJSONMergePatchToMap(msg, fd, name, val); err != nil {
+				return err
+			}
+		case !fd.IsList() && isMergeableMessage(fd) && isJSONObject(val):
+			if err := applyJSONMergePatch(msg.Mutable(fd).Message(), val); err != nil {
+				return err
+			}
+		default:
+			tmp, err := unmarshalField(msg, fd, name, val)
+			if err != nil {
+				return err
+			}
+			if !tmp.Has(fd) {
+				// The value is the field's default, such as an empty list. The
+				// default value of a list, map, or message field is read-only,
+				// so it cannot be set.
+				msg.Clear(fd)
+			} else {
+				msg.Set(fd, tmp.Get(fd))
+			}
+		}
+	}
+	return nil
+}
+
+func applyJSONMergePatchToMap(msg protoreflect.Message, fd protoreflect.FieldDescriptor, name string, patch json.RawMessage) error {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &entries); err != nil {
+		return err
+	}
+	m := msg.Mutable(fd).Map()
+	for k, val := range entries {
+		key, err := parseMapKey(fd.MapKey(), k)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		switch {
+		case isJSONNull(val):
+			m.Clear(key)
+		case isMergeableMessage(fd.MapValue()) && isJSONObject(val):
+			if err := applyJSONMergePatch(m.Mutable(key).Message(), val); err != nil {
+				return err
+			}
+		default:
+			entry, err := json.Marshal(map[string]json.RawMessage{k: val})
+			if err != nil {
+				return err
+			}
+			tmp, err := unmarshalField(msg, fd, name, entry)
+			if err != nil {
+				return err
+			}
+			m.Set(key, tmp.Get(fd).Map().Get(key))
+		}
+	}
+	return nil
+}
+
+// unmarshalField uses protojson to unmarshal the given value for the given
+// field. It unmarshals into a new message of the same type as msg and returns
+// that new message, from which the field's value can be retrieved.
+func unmarshalField(msg protoreflect.Message, fd protoreflect.FieldDescriptor, name string, val json.RawMessage) (protoreflect.Message, error) {
+	data, err := json.Marshal(map[string]json.RawMessage{name: val})
+	if err != nil {
+		return nil, err
+	}
+	tmp := msg.Type().New()
+	if err := protojson.Unmarshal(data, tmp.Interface()); err != nil {
+		return nil, fmt.Errorf("field %s: %w", fd.FullName(), err)
+	}
+	return tmp, nil
+}
+
+func parseMapKey(fd protoreflect.FieldDescriptor, key string) (protoreflect.MapKey, error) {
+	var val protoreflect.Value
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		val = protoreflect.ValueOfString(key)
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("invalid map key %q: %w", key, err)
+		}
+		val = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := strconv.ParseInt(key, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("invalid map key %q: %w", key, err)
+		}
+		val = protoreflect.ValueOfInt32(int32(i))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("invalid map key %q: %w", key, err)
+		}
+		val = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("invalid map key %q: %w", key, err)
+		}
+		val = protoreflect.ValueOfUint32(uint32(u))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("invalid map key %q: %w", key, err)
+		}
+		val = protoreflect.ValueOfUint64(u)
+	default:
+		return protoreflect.MapKey{}, fmt.Errorf("invalid map key kind: %s", fd.Kind())
+	}
+	return val.MapKey(), nil
+}
+
+// isMergeableMessage returns true if fd is a message field whose JSON format
+// is an object of fields, so that it can be recursively merged.
+func isMergeableMessage(fd protoreflect.FieldDescriptor) bool {
+	if fd.Message() == nil {
+		return false
+	}
+	return fd.Message().ParentFile().Package() != "google.protobuf"
+}
+
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+func isJSONObject(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
@@ -0,0 +1,131 @@
+package protomessage
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+func TestApplyJSONMergePatch(t *testing.T) {
+	msg := &testprotos.TestRequest{
+		Foo:   []testprotos.Proto3Enum{testprotos.Proto3Enum_VALUE1},
+		Bar:   "bar",
+		Flags: map[string]bool{"a": true, "b": true, "c": true},
+		Others: map[string]*testprotos.TestMessage{
+			"abc": {
+				Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE1},
+				Nm: &testprotos.TestMessage_NestedMessage{},
+			},
+			"def": {Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE1}},
+		},
+		Baz: &testprotos.TestMessage{
+			Ne:   []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE2},
+			Yanm: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{Foo: proto.String("foo")},
+		},
+	}
+	err := ApplyJSONMergePatch(msg, []byte(`{
+		"foo": ["VALUE2", "VALUE_NEG1"],
+		"bar": null,
+		"flags": {"a": false, "b": null, "d": true},
+		"others": {
+			"abc": {"ne": ["VALUE2"], "nm": null},
+			"def": null,
+			"ghi": {"ne": ["VALUE1"]}
+		},
+		"baz": {"yanm": {"bar": 123}},
+		"snafu": {}
+	}`))
+	require.NoError(t, err)
+	expected := &testprotos.TestRequest{
+		Foo:   []testprotos.Proto3Enum{testprotos.Proto3Enum_VALUE2, testprotos.Proto3Enum_VALUE_NEG1},
+		Flags: map[string]bool{"a": false, "c": true, "d": true},
+		Others: map[string]*testprotos.TestMessage{
+			"abc": {Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE2}},
+			"ghi": {Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE1}},
+		},
+		Baz: &testprotos.TestMessage{
+			Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE2},
+			Yanm: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{
+				Foo: proto.String("foo"),
+				Bar: proto.Int32(123),
+			},
+		},
+		Snafu: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage{},
+	}
+	require.Empty(t, cmp.Diff(expected, msg, protocmp.Transform()))
+
+	// well-known types are replaced, not merged
+	wkt := &testprotos.TestWellKnownTypes{StartTime: &timestamppb.Timestamp{Seconds: 100, Nanos: 100}}
+	require.NoError(t, ApplyJSONMergePatch(wkt, []byte(`{"startTime": "1970-01-01T00:00:10Z"}`)))
+	require.Empty(t, cmp.Diff(&timestamppb.Timestamp{Seconds: 10}, wkt.StartTime, protocmp.Transform()))
+}
+
+func TestApplyJSONMergePatch_EmptyValues(t *testing.T) {
+	testCases := []struct {
+		name          string
+		msg, expected proto.Message
+		patch         string
+	}{
+		{
+			name: "empty arrays",
+			msg: &testprotos.TestRequest{
+				Foo: []testprotos.Proto3Enum{testprotos.Proto3Enum_VALUE1},
+				Bar: "bar",
+			},
+			patch:    `{"foo": [], "bar": ""}`,
+			expected: &testprotos.TestRequest{},
+		},
+		{
+			name: "empty array of well-known type",
+			msg: &testprotos.TestWellKnownTypes{
+				Json: []*structpb.Value{structpb.NewStringValue("abc")},
+			},
+			patch:    `{"json": []}`,
+			expected: &testprotos.TestWellKnownTypes{},
+		},
+		{
+			name: "empty objects",
+			msg: &testprotos.TestRequest{
+				Flags: map[string]bool{"a": true},
+			},
+			patch: `{"flags": {}, "others": {}, "baz": {}}`,
+			expected: &testprotos.TestRequest{
+				Flags: map[string]bool{"a": true},
+				Baz:   &testprotos.TestMessage{},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("generated", func(t *testing.T) {
+				msg := proto.Clone(tc.msg)
+				require.NoError(t, ApplyJSONMergePatch(msg, []byte(tc.patch)))
+				require.Empty(t, cmp.Diff(tc.expected, msg, protocmp.Transform()))
+			})
+			t.Run("dynamic", func(t *testing.T) {
+				msg := dynamicpb.NewMessage(tc.msg.ProtoReflect().Descriptor())
+				proto.Merge(msg, tc.msg)
+				require.NoError(t, ApplyJSONMergePatch(msg, []byte(tc.patch)))
+				expected := dynamicpb.NewMessage(tc.expected.ProtoReflect().Descriptor())
+				proto.Merge(expected, tc.expected)
+				require.True(t, proto.Equal(expected, msg), "expected %v, got %v", expected, msg)
+			})
+		})
+	}
+}
+
+func TestApplyJSONMergePatch_Errors(t *testing.T) {
+	msg := &testprotos.TestRequest{Bar: "bar"}
+	require.ErrorContains(t, ApplyJSONMergePatch(msg, []byte(`[]`)), "patch for testprotos.TestRequest must be a JSON object")
+	require.ErrorContains(t, ApplyJSONMergePatch(msg, []byte(`{"nope": 1}`)), `testprotos.TestRequest has no field named "nope"`)
+	require.ErrorContains(t, ApplyJSONMergePatch(msg, []byte(`{"bar": 1}`)), "field testprotos.TestRequest.bar:")
+	require.ErrorContains(t, ApplyJSONMergePatch(msg, []byte(`{"baz": {"ne": 1}}`)), "field testprotos.TestMessage.ne:")
+}
@@ -0,0 +1,408 @@
+package protomessage
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MessageToStruct copies the fields of msg into target, which must be a
+// non-nil pointer to a struct. This is useful for integrating messages,
+// particularly dynamic messages, with libraries that operate on plain Go
+// structs, like ORMs and configuration systems.
+//
+// Exported struct fields are matched to message fields by name. The name of
+// a struct field is the name in its "json" struct tag, if present, or the Go
+// field name otherwise. Struct fields whose tag name is "-" are ignored. A
+// struct field matches a message field if its name is the same as the message
+// field's JSON name or its proto name, ignoring case. Message fields that have
+// no corresponding struct field, as well as struct fields that have no
+// corresponding message field, are ignored. Unset message fields are skipped,
+// leaving the struct field unchanged.
+//
+// Message fields are converted to struct fields as follows:
+//   - Numeric fields can be stored in struct fields of any numeric type with
+//     the same signedness (integer fields) or in any float type (floating
+//     point fields), as long as the value does not overflow.
+//   - Bool, string, and bytes fields are stored in struct fields of kind
+//     bool, string, and []byte, respectively.
+//   - Enum fields can be stored in struct fields of any signed integer type,
+//     in which case the numeric value is stored, or of kind string, in which
+//     case the name of the enum value is stored.
+//   - Message fields are stored in struct fields whose type is a struct by
+//     recursively calling MessageToStruct.
+//   - Repeated fields are stored in struct fields whose type is a slice, and
+//     map fields are stored in struct fields whose type is a map, whose keys
+//     and elements are converted per the rules above.
+//
+// Any struct field may also be a pointer to one of the above types, in which
+// case a new value is allocated if the pointer is nil.
+func MessageToStruct(msg proto.Message, target any) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("target must be a non-nil pointer to a struct; instead got %T", target)
+	}
+	return messageToStruct(msg.ProtoReflect(), rv.Elem())
+}
+
+// StructToMessage creates a new message of the given type and populates it
+// from the fields of src, which must be a struct or a non-nil pointer to a
+// struct. To create a dynamic message from a message descriptor, use
+// [dynamicpb.NewMessageType] to create the message type.
+//
+// Fields are matched and converted using the inverse of the rules described
+// in MessageToStruct. Struct fields that contain zero values, such as nil
+// pointers and empty strings, are skipped, leaving the corresponding message
+// field unset. To set a field with explicit presence to its zero value, use a
+// struct field whose type is a pointer.
+func StructToMessage(src any, mt protoreflect.MessageType) (proto.Message, error) {
+	rv := reflect.ValueOf(src)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("src must be a struct or a non-nil pointer to a struct; instead got %T", src)
+	}
+	msg := mt.New()
+	if err := structToMessage(rv, msg); err != nil {
+		return nil, err
+	}
+	return msg.Interface(), nil
+}
+
+// structFields returns the fields of the given struct type that correspond
+// to fields of the given message, as a map of message field to struct field
+// index.
+func structFields(t reflect.Type, md protoreflect.MessageDescriptor) map[protoreflect.FieldDescriptor]int {
+	byName := map[string]protoreflect.FieldDescriptor{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		byName[strings.ToLower(string(fd.Name()))] = fd
+	}
+	// JSON names take precedence over proto names
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		byName[strings.ToLower(fd.JSONName())] = fd
+	}
+	result := map[protoreflect.FieldDescriptor]int{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		if fd, ok := byName[strings.ToLower(name)]; ok {
+			if _, exists := result[fd]; !exists {
+				result[fd] = i
+			}
+		}
+	}
+	return result
+}
+
+func messageToStruct(msg protoreflect.Message, dest reflect.Value) error {
+	for fd, index := range structFields(dest.Type(), msg.Descriptor()) {
+		if !msg.Has(fd) {
+			continue
+		}
+		if err := fieldToGo(fd, msg.Get(fd), dest.Field(index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fieldToGo(fd protoreflect.FieldDescriptor, val protoreflect.Value, dest reflect.Value) error {
+	dest = allocIfPointer(dest)
+	switch {
+	case fd.IsList():
+		if dest.Kind() != reflect.Slice {
+			return fmt.Errorf("field %s: cannot store repeated field in %s", fd.FullName(), dest.Type())
+		}
+		list := val.List()
+		slice := reflect.MakeSlice(dest.Type(), list.Len(), list.Len())
+		for i := 0; i < list.Len(); i++ {
+			if err := valueToGo(fd, list.Get(i), slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		dest.Set(slice)
+		return nil
+	case fd.IsMap():
+		if dest.Kind() != reflect.Map {
+			return fmt.Errorf("field %s: cannot store map field in %s", fd.FullName(), dest.Type())
+		}
+		m := reflect.MakeMapWithSize(dest.Type(), val.Map().Len())
+		var err error
+		val.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			key := reflect.New(dest.Type().Key()).Elem()
+			if err = valueToGo(fd.MapKey(), k.Value(), key); err != nil {
+				return false
+			}
+			elem := reflect.New(dest.Type().Elem()).Elem()
+			if err = valueToGo(fd.MapValue(), v, elem); err != nil {
+				return false
+			}
+			m.SetMapIndex(key, elem)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		dest.Set(m)
+		return nil
+	default:
+		return valueToGo(fd, val, dest)
+	}
+}
+
+func valueToGo(fd protoreflect.FieldDescriptor, val protoreflect.Value, dest reflect.Value) error {
+	dest = allocIfPointer(dest)
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if dest.Kind() == reflect.Bool {
+			dest.SetBool(val.Bool())
+			return nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if isInt(dest.Kind()) {
+			return setInt(fd, val.Int(), dest)
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if isUint(dest.Kind()) {
+			if dest.OverflowUint(val.Uint()) {
+				return fmt.Errorf("field %s: value %d overflows %s", fd.FullName(), val.Uint(), dest.Type())
+			}
+			dest.SetUint(val.Uint())
+			return nil
+		}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		if dest.Kind() == reflect.Float32 || dest.Kind() == reflect.Float64 {
+			dest.SetFloat(val.Float())
+			return nil
+		}
+	case protoreflect.StringKind:
+		if dest.Kind() == reflect.String {
+			dest.SetString(val.String())
+			return nil
+		}
+	case protoreflect.BytesKind:
+		if dest.Kind() == reflect.Slice && dest.Type().Elem().Kind() == reflect.Uint8 {
+			dest.SetBytes(append([]byte(nil), val.Bytes()...))
+			return nil
+		}
+	case protoreflect.EnumKind:
+		if isInt(dest.Kind()) {
+			return setInt(fd, int64(val.Enum()), dest)
+		}
+		if dest.Kind() == reflect.String {
+			enumVal := fd.Enum().Values().ByNumber(val.Enum())
+			if enumVal == nil {
+				return fmt.Errorf("field %s: %d is not a known value of %s", fd.FullName(), val.Enum(), fd.Enum().FullName())
+			}
+			dest.SetString(string(enumVal.Name()))
+			return nil
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if dest.Kind() == reflect.Struct {
+			return messageToStruct(val.Message(), dest)
+		}
+	}
+	return fmt.Errorf("field %s: cannot store %s value in %s", fd.FullName(), fd.Kind(), dest.Type())
+}
+
+func structToMessage(src reflect.Value, msg protoreflect.Message) error {
+	for fd, index := range structFields(src.Type(), msg.Descriptor()) {
+		field := src.Field(index)
+		if field.IsZero() {
+			continue
+		}
+		val, err := fieldFromGo(fd, field, msg)
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, val)
+	}
+	return nil
+}
+
+func fieldFromGo(fd protoreflect.FieldDescriptor, src reflect.Value, msg protoreflect.Message) (protoreflect.Value, error) {
+	if src.Kind() == reflect.Pointer {
+		src = src.Elem()
+	}
+	switch {
+	case fd.IsList():
+		if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+			return protoreflect.Value{}, fmt.Errorf("field %s: cannot convert %s to repeated field", fd.FullName(), src.Type())
+		}
+		val := msg.NewField(fd)
+		list := val.List()
+		for i := 0; i < src.Len(); i++ {
+			elem, err := valueFromGo(fd, src.Index(i), list.NewElement)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			list.Append(elem)
+		}
+		return val, nil
+	case fd.IsMap():
+		if src.Kind() != reflect.Map {
+			return protoreflect.Value{}, fmt.Errorf("field %s: cannot convert %s to map field", fd.FullName(), src.Type())
+		}
+		val := msg.NewField(fd)
+		m := val.Map()
+		iter := src.MapRange()
+		for iter.Next() {
+			key, err := valueFromGo(fd.MapKey(), iter.Key(), nil)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			elem, err := valueFromGo(fd.MapValue(), iter.Value(), m.NewValue)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			m.Set(key.MapKey(), elem)
+		}
+		return val, nil
+	default:
+		return valueFromGo(fd, src, func() protoreflect.Value { return msg.NewField(fd) })
+	}
+}
+
+// valueFromGo converts the given Go value into a value for the given field.
+// The newMessage function is used to create values for message fields; it is
+// not used for other kinds of fields.
+func valueFromGo(fd protoreflect.FieldDescriptor, src reflect.Value, newMessage func() protoreflect.Value) (protoreflect.Value, error) {
+	if src.Kind() == reflect.Pointer || src.Kind() == reflect.Interface {
+		if src.IsNil() {
+			return protoreflect.Value{}, fmt.Errorf("field %s: cannot convert nil %s", fd.FullName(), src.Type())
+		}
+		src = src.Elem()
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if src.Kind() == reflect.Bool {
+			return protoreflect.ValueOfBool(src.Bool()), nil
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if v, ok, err := intFromGo(fd, src, 32); ok {
+			return protoreflect.ValueOfInt32(int32(v)), err
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if v, ok, err := intFromGo(fd, src, 64); ok {
+			return protoreflect.ValueOfInt64(v), err
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if isUint(src.Kind()) {
+			if src.Uint() > 1<<32-1 {
+				return protoreflect.Value{}, fmt.Errorf("field %s: value %d overflows %s", fd.FullName(), src.Uint(), fd.Kind())
+			}
+			return protoreflect.ValueOfUint32(uint32(src.Uint())), nil
+		}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if isUint(src.Kind()) {
+			return protoreflect.ValueOfUint64(src.Uint()), nil
+		}
+	case protoreflect.FloatKind:
+		if src.Kind() == reflect.Float32 || src.Kind() == reflect.Float64 {
+			return protoreflect.ValueOfFloat32(float32(src.Float())), nil
+		}
+	case protoreflect.DoubleKind:
+		if src.Kind() == reflect.Float32 || src.Kind() == reflect.Float64 {
+			return protoreflect.ValueOfFloat64(src.Float()), nil
+		}
+	case protoreflect.StringKind:
+		if src.Kind() == reflect.String {
+			return protoreflect.ValueOfString(src.String()), nil
+		}
+	case protoreflect.BytesKind:
+		if src.Kind() == reflect.Slice && src.Type().Elem().Kind() == reflect.Uint8 {
+			return protoreflect.ValueOfBytes(append([]byte(nil), src.Bytes()...)), nil
+		}
+	case protoreflect.EnumKind:
+		if v, ok, err := intFromGo(fd, src, 32); ok {
+			return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), err
+		}
+		if src.Kind() == reflect.String {
+			enumVal := fd.Enum().Values().ByName(protoreflect.Name(src.String()))
+			if enumVal == nil {
+				return protoreflect.Value{}, fmt.Errorf("field %s: %q is not a known value of %s", fd.FullName(), src.String(), fd.Enum().FullName())
+			}
+			return protoreflect.ValueOfEnum(enumVal.Number()), nil
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if src.Kind() == reflect.Struct && newMessage != nil {
+			val := newMessage()
+			if err := structToMessage(src, val.Message()); err != nil {
+				return protoreflect.Value{}, err
+			}
+			return val, nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("field %s: cannot convert %s to %s value", fd.FullName(), src.Type(), fd.Kind())
+}
+
+// intFromGo returns the value of src as a signed integer, if it is a signed
+// integer type. The returned bool is false if src is not a signed integer. An
+// error is returned if the value does not fit in the given number of bits.
+func intFromGo(fd protoreflect.FieldDescriptor, src reflect.Value, bits int) (int64, bool, error) {
+	if !isInt(src.Kind()) {
+		return 0, false, nil
+	}
+	v := src.Int()
+	if bits == 32 && int64(int32(v)) != v {
+		return 0, true, fmt.Errorf("field %s: value %d overflows %s", fd.FullName(), v, fd.Kind())
+	}
+	return v, true, nil
+}
+
+func setInt(fd protoreflect.FieldDescriptor, v int64, dest reflect.Value) error {
+	if dest.OverflowInt(v) {
+		return fmt.Errorf("field %s: value %d overflows %s", fd.FullName(), v, dest.Type())
+	}
+	dest.SetInt(v)
+	return nil
+}
+
+func allocIfPointer(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Pointer {
+		return v
+	}
+	if v.IsNil() {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	return v.Elem()
+}
+
+func isInt(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isUint(k reflect.Kind) bool {
+	switch k {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
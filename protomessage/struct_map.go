@@ -0,0 +1,128 @@
+package protomessage
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	structMessageName = "google.protobuf.Struct"
+	valueMessageName  = "google.protobuf.Value"
+)
+
+// StructAsMap converts the given google.protobuf.Struct message into a Go map.
+// The given msg may be a *structpb.Struct or any other message whose type is
+// google.protobuf.Struct, such as a dynamic message. This is like
+// [structpb.Struct.AsMap], except that it does not require the Struct to be a
+// generated message.
+//
+// The map has the same shape as the Struct's JSON representation: values are
+// nil, bool, float64, string, []any, or map[string]any.
+func StructAsMap(msg proto.Message) (map[string]any, error) {
+	if s, ok := msg.(*structpb.Struct); ok {
+		return s.AsMap(), nil
+	}
+	if name := msg.ProtoReflect().Descriptor().FullName(); name != structMessageName {
+		return nil, fmt.Errorf("message is %q, not %q", name, structMessageName)
+	}
+	var s structpb.Struct
+	if err := convertMessage(msg, &s); err != nil {
+		return nil, err
+	}
+	return s.AsMap(), nil
+}
+
+// NewStructFromMap converts the given Go map into a google.protobuf.Struct
+// message of the given type. The given message type may be that of
+// *structpb.Struct or any other type for google.protobuf.Struct, such as a
+// dynamic message type. If it is nil, a *structpb.Struct is returned. This is
+// like [structpb.NewStruct], except that it does not require the Struct to be
+// a generated message.
+//
+// The values in the map are converted as described by [structpb.NewValue].
+func NewStructFromMap(m map[string]any, mt protoreflect.MessageType) (proto.Message, error) {
+	if mt != nil {
+		if name := mt.Descriptor().FullName(); name != structMessageName {
+			return nil, fmt.Errorf("message type is %q, not %q", name, structMessageName)
+		}
+	}
+	s, err := structpb.NewStruct(m)
+	if err != nil {
+		return nil, err
+	}
+	if mt == nil {
+		return s, nil
+	}
+	msg := mt.New().Interface()
+	if _, ok := msg.(*structpb.Struct); ok {
+		return s, nil
+	}
+	if err := convertMessage(s, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// ValueAsInterface converts the given google.protobuf.Value message into a Go
+// value. The given msg may be a *structpb.Value or any other message whose type
+// is google.protobuf.Value, such as a dynamic message. This is like
+// [structpb.Value.AsInterface], except that it does not require the Value to be
+// a generated message.
+//
+// The result has the same shape as the Value's JSON representation: it is nil,
+// a bool, float64, string, []any, or map[string]any.
+func ValueAsInterface(msg proto.Message) (any, error) {
+	if v, ok := msg.(*structpb.Value); ok {
+		return v.AsInterface(), nil
+	}
+	if name := msg.ProtoReflect().Descriptor().FullName(); name != valueMessageName {
+		return nil, fmt.Errorf("message is %q, not %q", name, valueMessageName)
+	}
+	var v structpb.Value
+	if err := convertMessage(msg, &v); err != nil {
+		return nil, err
+	}
+	return v.AsInterface(), nil
+}
+
+// NewValueFromInterface converts the given Go value into a google.protobuf.Value
+// message of the given type. The given message type may be that of
+// *structpb.Value or any other type for google.protobuf.Value, such as a dynamic
+// message type. If it is nil, a *structpb.Value is returned. This is like
+// [structpb.NewValue], except that it does not require the Value to be a
+// generated message.
+func NewValueFromInterface(v any, mt protoreflect.MessageType) (proto.Message, error) {
+	if mt != nil {
+		if name := mt.Descriptor().FullName(); name != valueMessageName {
+			return nil, fmt.Errorf("message type is %q, not %q", name, valueMessageName)
+		}
+	}
+	val, err := structpb.NewValue(v)
+	if err != nil {
+		return nil, err
+	}
+	if mt == nil {
+		return val, nil
+	}
+	msg := mt.New().Interface()
+	if _, ok := msg.(*structpb.Value); ok {
+		return val, nil
+	}
+	if err := convertMessage(val, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// convertMessage copies src into dest, which may have a different Go type but
+// must have the same message type, by serializing and then de-serializing.
+func convertMessage(src, dest proto.Message) error {
+	data, err := proto.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, dest)
+}
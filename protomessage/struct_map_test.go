@@ -0,0 +1,113 @@
+package protomessage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestStructMap(t *testing.T) {
+	m := map[string]any{
+		"null":   nil,
+		"bool":   true,
+		"number": 1.5,
+		"string": "abc",
+		"list":   []any{"a", 2.0, false, nil},
+		"struct": map[string]any{"nested": "value"},
+	}
+	const expectedJSON = `{
+		"null": null,
+		"bool": true,
+		"number": 1.5,
+		"string": "abc",
+		"list": ["a", 2, false, null],
+		"struct": {"nested": "value"}
+	}`
+	dynType := dynamicpb.NewMessageType((&structpb.Struct{}).ProtoReflect().Descriptor())
+
+	generated, err := NewStructFromMap(m, nil)
+	require.NoError(t, err)
+	require.IsType(t, (*structpb.Struct)(nil), generated)
+	generatedWithType, err := NewStructFromMap(m, (&structpb.Struct{}).ProtoReflect().Type())
+	require.NoError(t, err)
+	require.True(t, proto.Equal(generated, generatedWithType))
+	dyn, err := NewStructFromMap(m, dynType)
+	require.NoError(t, err)
+	require.IsType(t, (*dynamicpb.Message)(nil), dyn)
+	require.True(t, proto.Equal(generated, dyn))
+
+	for _, msg := range []proto.Message{generated, dyn} {
+		actual, err := StructAsMap(msg)
+		require.NoError(t, err)
+		require.Equal(t, m, actual)
+
+		// the JSON format is the canonical one for Struct, even for dynamic messages
+		data, err := protojson.Marshal(msg)
+		require.NoError(t, err)
+		require.JSONEq(t, expectedJSON, string(data))
+		roundTripped := msg.ProtoReflect().Type().New().Interface()
+		require.NoError(t, protojson.Unmarshal([]byte(expectedJSON), roundTripped))
+		require.True(t, proto.Equal(msg, roundTripped))
+	}
+
+	_, err = StructAsMap(wrapperspb.String("abc"))
+	require.ErrorContains(t, err, `message is "google.protobuf.StringValue", not "google.protobuf.Struct"`)
+	_, err = NewStructFromMap(m, (&wrapperspb.StringValue{}).ProtoReflect().Type())
+	require.ErrorContains(t, err, `message type is "google.protobuf.StringValue", not "google.protobuf.Struct"`)
+	_, err = NewStructFromMap(map[string]any{"chan": make(chan int)}, dynType)
+	require.ErrorContains(t, err, "invalid type: chan int")
+}
+
+func TestValueInterface(t *testing.T) {
+	dynType := dynamicpb.NewMessageType((&structpb.Value{}).ProtoReflect().Descriptor())
+	testCases := []struct {
+		name         string
+		val          any
+		expectedJSON string
+	}{
+		{name: "null", val: nil, expectedJSON: `null`},
+		{name: "bool", val: true, expectedJSON: `true`},
+		{name: "number", val: -2.5, expectedJSON: `-2.5`},
+		{name: "string", val: "abc", expectedJSON: `"abc"`},
+		{name: "list", val: []any{"a", 1.0, nil, []any{true}}, expectedJSON: `["a", 1, null, [true]]`},
+		{name: "struct", val: map[string]any{"a": map[string]any{"b": "c"}, "d": []any{}}, expectedJSON: `{"a": {"b": "c"}, "d": []}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generated, err := NewValueFromInterface(tc.val, nil)
+			require.NoError(t, err)
+			require.IsType(t, (*structpb.Value)(nil), generated)
+			dyn, err := NewValueFromInterface(tc.val, dynType)
+			require.NoError(t, err)
+			require.IsType(t, (*dynamicpb.Message)(nil), dyn)
+			require.True(t, proto.Equal(generated, dyn))
+
+			for _, msg := range []proto.Message{generated, dyn} {
+				actual, err := ValueAsInterface(msg)
+				require.NoError(t, err)
+				require.Equal(t, tc.val, actual)
+
+				// the JSON format is the canonical one for Value, not {"kind": ...},
+				// even for dynamic messages
+				data, err := protojson.Marshal(msg)
+				require.NoError(t, err)
+				require.JSONEq(t, tc.expectedJSON, string(data))
+				roundTripped := msg.ProtoReflect().Type().New().Interface()
+				require.NoError(t, protojson.Unmarshal([]byte(tc.expectedJSON), roundTripped))
+				require.True(t, proto.Equal(msg, roundTripped))
+			}
+		})
+	}
+
+	_, err := ValueAsInterface(wrapperspb.String("abc"))
+	require.ErrorContains(t, err, `message is "google.protobuf.StringValue", not "google.protobuf.Value"`)
+	_, err = NewValueFromInterface("abc", (&wrapperspb.StringValue{}).ProtoReflect().Type())
+	require.ErrorContains(t, err, `message type is "google.protobuf.StringValue", not "google.protobuf.Value"`)
+	_, err = NewValueFromInterface(make(chan int), dynType)
+	require.ErrorContains(t, err, "invalid type: chan int")
+}
@@ -0,0 +1,121 @@
+package protomessage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+type yetAnotherNestedStruct struct {
+	Foo *string
+	Bar int64
+	Baz []byte `json:"baz"`
+	Dne string
+}
+
+type anotherNestedStruct struct {
+	Yanm []*yetAnotherNestedStruct
+}
+
+type testMessageStruct struct {
+	Ne  []int32 `json:"ne"`
+	Anm *anotherNestedStruct
+}
+
+type testRequestStruct struct {
+	Foo    []string `json:"foo"`
+	Name   string   `json:"bar"`
+	Flags  map[string]bool
+	Others map[string]testMessageStruct
+	Ignore string `json:"-"`
+}
+
+func TestMessageToStruct(t *testing.T) {
+	foo := "foo"
+	msg := &testprotos.TestRequest{
+		Foo:   []testprotos.Proto3Enum{testprotos.Proto3Enum_VALUE1, testprotos.Proto3Enum_VALUE_NEG1},
+		Bar:   "bar",
+		Flags: map[string]bool{"a": true, "b": false},
+		Others: map[string]*testprotos.TestMessage{
+			"abc": {
+				Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE2},
+				Anm: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage{
+					Yanm: []*testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{
+						{
+							Foo: proto.String(foo),
+							Bar: proto.Int32(-42),
+							Baz: []byte{1, 2, 3},
+							Dne: testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage_VALUE2.Enum(),
+						},
+					},
+				},
+			},
+		},
+	}
+	expected := testRequestStruct{
+		Foo:   []string{"VALUE1", "VALUE_NEG1"},
+		Name:  "bar",
+		Flags: map[string]bool{"a": true, "b": false},
+		Others: map[string]testMessageStruct{
+			"abc": {
+				Ne: []int32{2},
+				Anm: &anotherNestedStruct{
+					Yanm: []*yetAnotherNestedStruct{
+						{Foo: &foo, Bar: -42, Baz: []byte{1, 2, 3}, Dne: "VALUE2"},
+					},
+				},
+			},
+		},
+	}
+
+	// works with generated and dynamic messages
+	dyn := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+	data, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	require.NoError(t, proto.Unmarshal(data, dyn))
+	for _, m := range []proto.Message{msg, dyn} {
+		actual := testRequestStruct{Ignore: "untouched"}
+		require.NoError(t, MessageToStruct(m, &actual))
+		require.Equal(t, "untouched", actual.Ignore)
+		actual.Ignore = ""
+		require.Equal(t, expected, actual)
+	}
+
+	// and back again
+	for _, mt := range []protoreflect.MessageType{msg.ProtoReflect().Type(), dynamicpb.NewMessageType(msg.ProtoReflect().Descriptor())} {
+		roundTripped, err := StructToMessage(&expected, mt)
+		require.NoError(t, err)
+		require.Equal(t, mt, roundTripped.ProtoReflect().Type())
+		data, err := proto.Marshal(roundTripped)
+		require.NoError(t, err)
+		var actual testprotos.TestRequest
+		require.NoError(t, proto.Unmarshal(data, &actual))
+		require.True(t, proto.Equal(msg, &actual))
+	}
+}
+
+func TestMessageToStruct_Errors(t *testing.T) {
+	msg := &testprotos.TestRequest{Bar: "bar"}
+	var notStruct string
+	require.ErrorContains(t, MessageToStruct(msg, &notStruct), "target must be a non-nil pointer to a struct")
+	require.ErrorContains(t, MessageToStruct(msg, testRequestStruct{}), "target must be a non-nil pointer to a struct")
+
+	var wrongType struct{ Bar int }
+	require.ErrorContains(t, MessageToStruct(msg, &wrongType), "field testprotos.TestRequest.bar: cannot store string value in int")
+
+	tooSmall := struct{ Bar int8 }{Bar: 0}
+	nested := &testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{Bar: proto.Int32(1000)}
+	require.ErrorContains(t, MessageToStruct(nested, &tooSmall), "value 1000 overflows int8")
+
+	_, err := StructToMessage(notStruct, msg.ProtoReflect().Type())
+	require.ErrorContains(t, err, "src must be a struct or a non-nil pointer to a struct")
+	_, err = StructToMessage(testRequestStruct{Foo: []string{"NOPE"}}, msg.ProtoReflect().Type())
+	require.ErrorContains(t, err, `field testprotos.TestRequest.foo: "NOPE" is not a known value of testprotos.Proto3Enum`)
+	_, err = StructToMessage(struct{ Bar int64 }{Bar: 1 << 40}, nested.ProtoReflect().Type())
+	require.ErrorContains(t, err, "value 1099511627776 overflows int32")
+}
@@ -0,0 +1,24 @@
+package protomessage
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ClearUnknownFields removes all unrecognized fields from the given message
+// and from all messages contained therein (including elements of list and map
+// fields). This can be used, for example, by a proxy that wants to strip unknown
+// fields before forwarding a message, so that it does not leak data that the
+// schema does not describe.
+//
+// This does not affect extensions that have been recognized. To also discard
+// those, reset the message after clearing them or unmarshal the message with
+// a resolver that does not know about any extensions.
+func ClearUnknownFields(msg proto.Message) {
+	Walk(msg.ProtoReflect(), func(_ []any, m protoreflect.Message) bool {
+		if len(m.GetUnknown()) > 0 {
+			m.SetUnknown(nil)
+		}
+		return true
+	})
+}
@@ -0,0 +1,39 @@
+package protomessage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestClearUnknownFields(t *testing.T) {
+	fileDescriptor := protodesc.ToFileDescriptorProto(testprotos.File_desc_test_complex_proto)
+	// serialize to bytes and back, but use empty resolver when
+	// de-serializing so that custom options are unrecognized
+	data, err := proto.Marshal(fileDescriptor)
+	require.NoError(t, err)
+	opts := proto.UnmarshalOptions{Resolver: (&protoresolve.Registry{}).AsTypeResolver()}
+	err = opts.Unmarshal(data, fileDescriptor)
+	require.NoError(t, err)
+	dyn := dynamicpb.NewMessage(fileDescriptor.ProtoReflect().Descriptor())
+	err = opts.Unmarshal(data, dyn)
+	require.NoError(t, err)
+
+	require.True(t, hasUnrecognized(fileDescriptor.ProtoReflect()))
+	require.True(t, hasUnrecognized(dyn))
+
+	ClearUnknownFields(fileDescriptor)
+	require.False(t, hasUnrecognized(fileDescriptor.ProtoReflect()))
+	ClearUnknownFields(dyn)
+	require.False(t, hasUnrecognized(dyn))
+
+	// known fields are unaffected
+	require.Equal(t, "desc_test_complex.proto", fileDescriptor.GetName())
+	require.True(t, proto.Equal(fileDescriptor, dyn))
+}
@@ -0,0 +1,234 @@
+package protomessage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal"
+)
+
+const (
+	// Range of valid google.protobuf.Timestamp seconds: 0001-01-01T00:00:00Z
+	// to 9999-12-31T23:59:59Z, inclusive.
+	minTimestampSeconds = -62135596800
+	maxTimestampSeconds = 253402300799
+	// Range of valid google.protobuf.Duration seconds: approximately +/- 10,000 years.
+	maxDurationSeconds = 315576000000
+	maxNanos           = 999999999
+)
+
+// ValidationError is the error returned by Validate. It accumulates all
+// problems found in a message, instead of only reporting the first.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Violation describes a single problem found by Validate.
+type Violation struct {
+	// Path is the location of the offending value, relative to the message
+	// that was validated. It is a dot-separated sequence of field names.
+	// Elements of list fields and entries of map fields are indicated with
+	// an index or key in brackets. Extension names are in parentheses.
+	Path string
+	// Field is the field that contains the offending value.
+	Field protoreflect.FieldDescriptor
+	// Reason describes the problem.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	var buf strings.Builder
+	for i, v := range e.Violations {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		buf.WriteString(v.Path)
+		buf.WriteString(": ")
+		buf.WriteString(v.Reason)
+	}
+	return buf.String()
+}
+
+// Validate performs a strict validation of the given message and returns
+// a *ValidationError if any problems are found. In addition to verifying that
+// all required fields are present (like [proto.CheckInitialized]), it also
+// verifies the following:
+//   - Enum fields hold a value that corresponds to a declared enum value, even
+//     if the enum is open.
+//   - String fields contain valid UTF-8, even if the field does not otherwise
+//     require UTF-8 validation.
+//   - Values of the well-known types google.protobuf.Timestamp and
+//     google.protobuf.Duration are within their documented valid ranges.
+//   - Extension fields are within the extension ranges declared by the
+//     extended message.
+//
+// The validation is recursive, so it examines all message values contained
+// within the given message.
+func Validate(msg proto.Message) error {
+	var v validator
+	v.validate(msg.ProtoReflect(), "")
+	if len(v.violations) > 0 {
+		return &ValidationError{Violations: v.violations}
+	}
+	return nil
+}
+
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) report(path string, fd protoreflect.FieldDescriptor, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Path: path, Field: fd, Reason: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(msg protoreflect.Message, prefix string) {
+	md := msg.Descriptor()
+	fields := md.Fields()
+	for i, length := 0, fields.Len(); i < length; i++ {
+		fd := fields.Get(i)
+		if fd.Cardinality() == protoreflect.Required && !msg.Has(fd) {
+			v.report(fieldPath(prefix, fd), fd, "required field is not set")
+		}
+	}
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		v.validateTimestamp(msg, prefix)
+	case "google.protobuf.Duration":
+		v.validateDuration(msg, prefix)
+	}
+	// Range visits fields in an undefined order, so we sort them to make
+	// the order of violations deterministic.
+	var present []protoreflect.FieldDescriptor
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		present = append(present, fd)
+		return true
+	})
+	sort.Slice(present, func(i, j int) bool {
+		return present[i].Number() < present[j].Number()
+	})
+	for _, fd := range present {
+		path := fieldPath(prefix, fd)
+		if fd.IsExtension() && !md.ExtensionRanges().Has(fd.Number()) {
+			v.report(path, fd, "extension number %d is not in an extension range of %s", fd.Number(), md.FullName())
+		}
+		val := msg.Get(fd)
+		switch {
+		case fd.IsList():
+			l := val.List()
+			for i, length := 0, l.Len(); i < length; i++ {
+				v.validateValue(fmt.Sprintf("%s[%d]", path, i), fd, l.Get(i))
+			}
+		case fd.IsMap():
+			keyField, valField := fd.MapKey(), fd.MapValue()
+			m := val.Map()
+			for _, key := range sortedMapKeys(m) {
+				entryPath := fmt.Sprintf("%s[%s]", path, formatMapKey(key))
+				v.validateValue(entryPath, keyField, key.Value())
+				v.validateValue(entryPath, valField, m.Get(key))
+			}
+		default:
+			v.validateValue(path, fd, val)
+		}
+	}
+}
+
+func (v *validator) validateValue(path string, fd protoreflect.FieldDescriptor, val protoreflect.Value) {
+	switch {
+	case internal.IsMessageKind(fd.Kind()):
+		v.validate(val.Message(), path)
+	case fd.Kind() == protoreflect.EnumKind:
+		if fd.Enum().Values().ByNumber(val.Enum()) == nil {
+			v.report(path, fd, "value %d is not a declared value of enum %s", val.Enum(), fd.Enum().FullName())
+		}
+	case fd.Kind() == protoreflect.StringKind:
+		if !utf8.ValidString(val.String()) {
+			v.report(path, fd, "value is not valid UTF-8")
+		}
+	}
+}
+
+func (v *validator) validateTimestamp(msg protoreflect.Message, prefix string) {
+	secsField, secs, nanosField, nanos, ok := secondsAndNanos(msg)
+	if !ok {
+		return
+	}
+	if secs < minTimestampSeconds || secs > maxTimestampSeconds {
+		v.report(fieldPath(prefix, secsField), secsField, "seconds %d is out of range for a timestamp", secs)
+	}
+	if nanos < 0 || nanos > maxNanos {
+		v.report(fieldPath(prefix, nanosField), nanosField, "nanos %d is out of range for a timestamp", nanos)
+	}
+}
+
+func (v *validator) validateDuration(msg protoreflect.Message, prefix string) {
+	secsField, secs, nanosField, nanos, ok := secondsAndNanos(msg)
+	if !ok {
+		return
+	}
+	if secs < -maxDurationSeconds || secs > maxDurationSeconds {
+		v.report(fieldPath(prefix, secsField), secsField, "seconds %d is out of range for a duration", secs)
+	}
+	switch {
+	case nanos < -maxNanos || nanos > maxNanos:
+		v.report(fieldPath(prefix, nanosField), nanosField, "nanos %d is out of range for a duration", nanos)
+	case (secs < 0 && nanos > 0) || (secs > 0 && nanos < 0):
+		v.report(fieldPath(prefix, nanosField), nanosField, "nanos %d has a different sign than seconds %d", nanos, secs)
+	}
+}
+
+func secondsAndNanos(msg protoreflect.Message) (secsField protoreflect.FieldDescriptor, secs int64, nanosField protoreflect.FieldDescriptor, nanos int64, ok bool) {
+	fields := msg.Descriptor().Fields()
+	secsField = fields.ByName("seconds")
+	nanosField = fields.ByName("nanos")
+	if secsField == nil || secsField.Kind() != protoreflect.Int64Kind || secsField.IsList() ||
+		nanosField == nil || nanosField.Kind() != protoreflect.Int32Kind || nanosField.IsList() {
+		// not the well-known type we were expecting
+		return nil, 0, nil, 0, false
+	}
+	return secsField, msg.Get(secsField).Int(), nanosField, msg.Get(nanosField).Int(), true
+}
+
+func fieldPath(prefix string, fd protoreflect.FieldDescriptor) string {
+	name := string(fd.Name())
+	if fd.IsExtension() {
+		name = "(" + string(fd.FullName()) + ")"
+	}
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func formatMapKey(key protoreflect.MapKey) string {
+	if s, ok := key.Interface().(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return key.String()
+}
+
+func sortedMapKeys(m protoreflect.Map) []protoreflect.MapKey {
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		switch k := keys[i].Interface().(type) {
+		case bool:
+			return !k && keys[j].Bool()
+		case int32, int64:
+			return keys[i].Int() < keys[j].Int()
+		case uint32, uint64:
+			return keys[i].Uint() < keys[j].Uint()
+		default:
+			return keys[i].String() < keys[j].String()
+		}
+	})
+	return keys
+}
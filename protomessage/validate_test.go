@@ -0,0 +1,101 @@
+package protomessage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+func TestValidate(t *testing.T) {
+	// valid messages
+	require.NoError(t, Validate(&testprotos.TestRequest{
+		Foo: []testprotos.Proto3Enum{testprotos.Proto3Enum_VALUE1, testprotos.Proto3Enum_VALUE_NEG1},
+		Bar: "bar",
+		Others: map[string]*testprotos.TestMessage{
+			"abc": {Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE2}},
+		},
+	}))
+	require.NoError(t, Validate(&testprotos.TestWellKnownTypes{
+		StartTime: timestamppb.Now(),
+		Elapsed:   &durationpb.Duration{Seconds: -10, Nanos: -500},
+	}))
+
+	// invalid messages
+	err := Validate(&testprotos.TestRequest{
+		Foo: []testprotos.Proto3Enum{testprotos.Proto3Enum_VALUE1, 99},
+		Bar: "\xff\xfe",
+		Others: map[string]*testprotos.TestMessage{
+			"abc": {Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE2, 5}},
+		},
+	})
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Violations, 3)
+	require.EqualError(t, err, `foo[1]: value 99 is not a declared value of enum testprotos.Proto3Enum; `+
+		`bar: value is not valid UTF-8; `+
+		`others["abc"].ne[1]: value 5 is not a declared value of enum testprotos.TestMessage.NestedEnum`)
+	require.Equal(t, "foo", string(validationErr.Violations[0].Field.Name()))
+
+	err = Validate(&testprotos.TestWellKnownTypes{
+		StartTime: &timestamppb.Timestamp{Seconds: 253402300800, Nanos: -1},
+		Elapsed:   &durationpb.Duration{Seconds: 10, Nanos: -500},
+	})
+	require.EqualError(t, err, `start_time.seconds: seconds 253402300800 is out of range for a timestamp; `+
+		`start_time.nanos: nanos -1 is out of range for a timestamp; `+
+		`elapsed.nanos: nanos -500 has a different sign than seconds 10`)
+
+	err = Validate(&testprotos.Whatchamacallit{})
+	require.EqualError(t, err, `foos: required field is not set`)
+}
+
+func TestValidate_ExtensionRanges(t *testing.T) {
+	// A compiler will not allow an extension outside the extendee's ranges, so
+	// the out-of-range extension is declared against a different version of
+	// the message, with a larger extension range.
+	compile := func(extRange, extNumber string) protoreflect.FileDescriptor {
+		compiler := protocompile.Compiler{
+			Resolver: &protocompile.SourceResolver{
+				Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+					"test.proto": `
+						syntax = "proto2";
+						package test;
+						message Foo {
+							extensions ` + extRange + `;
+						}
+						extend Foo {
+							optional string ext = ` + extNumber + `;
+						}
+					`,
+				}),
+			},
+		}
+		files, err := compiler.Compile(context.Background(), "test.proto")
+		require.NoError(t, err)
+		return files[0]
+	}
+	file := compile("100 to 200", "150")
+	otherFile := compile("100 to 300", "250")
+
+	msg := dynamicpb.NewMessage(file.Messages().ByName("Foo"))
+	inRange := dynamicpb.NewExtensionType(file.Extensions().ByName("ext"))
+	msg.Set(inRange.TypeDescriptor(), protoreflect.ValueOfString("abc"))
+	require.NoError(t, Validate(msg))
+
+	outOfRange := dynamicpb.NewExtensionType(otherFile.Extensions().ByName("ext"))
+	msg.Set(outOfRange.TypeDescriptor(), protoreflect.ValueOfString("xyz"))
+	err := Validate(msg)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	require.Len(t, validationErr.Violations, 1)
+	require.EqualError(t, err, `(test.ext): extension number 250 is not in an extension range of test.Foo`)
+	require.Equal(t, protoreflect.FieldNumber(250), validationErr.Violations[0].Field.Number())
+}
@@ -0,0 +1,409 @@
+// Package protomsgpack marshals and unmarshals protobuf messages to and from
+// MessagePack, a compact binary serialization format.
+//
+// Messages are encoded as MessagePack maps. By default, the keys of these maps
+// are field numbers, which is compact. Alternatively, the keys can be the JSON
+// names of the fields, which makes the data self-describing for consumers that
+// do not have the message's schema. In the latter case, extensions use keys
+// that are the extension's fully-qualified name in brackets, like in the JSON
+// format.
+//
+// Values are encoded as follows:
+//   - Nested messages are MessagePack maps, encoded recursively.
+//   - Repeated fields are MessagePack arrays.
+//   - Map fields are MessagePack maps, whose keys have the field's key type.
+//   - Integers, including enums, are MessagePack integers, using the most
+//     compact representation for each value.
+//   - Floats and doubles are MessagePack float32 and float64 values.
+//   - Bools, strings, and bytes are MessagePack bool, str, and bin values.
+//
+// Well-known types are encoded just like any other message; they do not use
+// the special representations defined by the JSON format.
+//
+// This works with both generated messages and dynamic messages.
+package protomsgpack
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// MarshalOptions configures how messages are marshalled to MessagePack.
+type MarshalOptions struct {
+	// If true, fields are keyed by their JSON names instead of by their
+	// field numbers.
+	UseJSONNames bool
+}
+
+// Marshal returns the MessagePack encoding of the given message, using
+// default options.
+func Marshal(msg proto.Message) ([]byte, error) {
+	return MarshalOptions{}.Marshal(msg)
+}
+
+// Marshal returns the MessagePack encoding of the given message. The output is
+// deterministic: fields are always written in field number order and map
+// entries are sorted by key.
+func (o MarshalOptions) Marshal(msg proto.Message) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := o.encodeMessage(enc, msg.ProtoReflect()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (o MarshalOptions) encodeMessage(enc *msgpack.Encoder, msg protoreflect.Message) error {
+	var fields []protoreflect.FieldDescriptor
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fields = append(fields, fd)
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Number() < fields[j].Number()
+	})
+	if err := enc.EncodeMapLen(len(fields)); err != nil {
+		return err
+	}
+	for _, fd := range fields {
+		var err error
+		switch {
+		case !o.UseJSONNames:
+			err = enc.EncodeInt(int64(fd.Number()))
+		case fd.IsExtension():
+			err = enc.EncodeString("[" + string(fd.FullName()) + "]")
+		default:
+			err = enc.EncodeString(fd.JSONName())
+		}
+		if err != nil {
+			return err
+		}
+		if err := o.encodeField(enc, fd, msg.Get(fd)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o MarshalOptions) encodeField(enc *msgpack.Encoder, fd protoreflect.FieldDescriptor, val protoreflect.Value) error {
+	switch {
+	case fd.IsList():
+		list := val.List()
+		if err := enc.EncodeArrayLen(list.Len()); err != nil {
+			return err
+		}
+		for i := 0; i < list.Len(); i++ {
+			if err := o.encodeSingular(enc, fd, list.Get(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case fd.IsMap():
+		m := val.Map()
+		if err := enc.EncodeMapLen(m.Len()); err != nil {
+			return err
+		}
+		for _, key := range sortedMapKeys(m) {
+			if err := o.encodeSingular(enc, fd.MapKey(), key.Value()); err != nil {
+				return err
+			}
+			if err := o.encodeSingular(enc, fd.MapValue(), m.Get(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return o.encodeSingular(enc, fd, val)
+	}
+}
+
+func (o MarshalOptions) encodeSingular(enc *msgpack.Encoder, fd protoreflect.FieldDescriptor, val protoreflect.Value) error {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return enc.EncodeBool(val.Bool())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return enc.EncodeInt(val.Int())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return enc.EncodeUint(val.Uint())
+	case protoreflect.EnumKind:
+		return enc.EncodeInt(int64(val.Enum()))
+	case protoreflect.FloatKind:
+		return enc.EncodeFloat32(float32(val.Float()))
+	case protoreflect.DoubleKind:
+		return enc.EncodeFloat64(val.Float())
+	case protoreflect.StringKind:
+		return enc.EncodeString(val.String())
+	case protoreflect.BytesKind:
+		return enc.EncodeBytes(val.Bytes())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return o.encodeMessage(enc, val.Message())
+	default:
+		return fmt.Errorf("field %s: unsupported kind %s", fd.FullName(), fd.Kind())
+	}
+}
+
+// Unmarshal parses the given MessagePack data into msg. The message is reset
+// before the data is unmarshalled into it.
+//
+// The data may use either field numbers or field names as keys, or even a mix
+// of both. Field names may be JSON names or proto names. Extensions are
+// resolved using [protoregistry.GlobalTypes]. Enum values may be given as
+// numbers or as the names of the values. A nil value is the same as omitting
+// the field.
+func Unmarshal(data []byte, msg proto.Message) error {
+	proto.Reset(msg)
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	return decodeMessage(dec, msg.ProtoReflect())
+}
+
+func decodeMessage(dec *msgpack.Decoder, msg protoreflect.Message) error {
+	md := msg.Descriptor()
+	n, err := dec.DecodeMapLen()
+	if err != nil {
+		return fmt.Errorf("message %s: %w", md.FullName(), err)
+	}
+	for i := 0; i < n; i++ {
+		key, err := dec.DecodeInterfaceLoose()
+		if err != nil {
+			return fmt.Errorf("message %s: %w", md.FullName(), err)
+		}
+		fd, err := findField(md, key)
+		if err != nil {
+			return err
+		}
+		if isNil, err := decodeNil(dec); err != nil {
+			return err
+		} else if isNil {
+			continue
+		}
+		if err := decodeField(dec, fd, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findField(md protoreflect.MessageDescriptor, key any) (protoreflect.FieldDescriptor, error) {
+	var fd protoreflect.FieldDescriptor
+	switch key := key.(type) {
+	case int64:
+		fd = findFieldByNumber(md, protoreflect.FieldNumber(key))
+	case uint64:
+		fd = findFieldByNumber(md, protoreflect.FieldNumber(key))
+	case string:
+		if strings.HasPrefix(key, "[") && strings.HasSuffix(key, "]") {
+			xt, err := protoregistry.GlobalTypes.FindExtensionByName(protoreflect.FullName(key[1 : len(key)-1]))
+			if err != nil {
+				return nil, fmt.Errorf("message %s: could not resolve extension %s: %w", md.FullName(), key, err)
+			}
+			fd = xt.TypeDescriptor()
+			if fd.ContainingMessage().FullName() != md.FullName() {
+				return nil, fmt.Errorf("message %s: extension %s extends %s", md.FullName(), key, fd.ContainingMessage().FullName())
+			}
+			return fd, nil
+		}
+		fd = md.Fields().ByJSONName(key)
+		if fd == nil {
+			fd = md.Fields().ByName(protoreflect.Name(key))
+		}
+	default:
+		return nil, fmt.Errorf("message %s: keys must be integers or strings, got %T", md.FullName(), key)
+	}
+	if fd == nil {
+		return nil, fmt.Errorf("message %s has no field %v", md.FullName(), key)
+	}
+	return fd, nil
+}
+
+func findFieldByNumber(md protoreflect.MessageDescriptor, num protoreflect.FieldNumber) protoreflect.FieldDescriptor {
+	if fd := md.Fields().ByNumber(num); fd != nil {
+		return fd
+	}
+	if md.ExtensionRanges().Has(num) {
+		if xt, err := protoregistry.GlobalTypes.FindExtensionByNumber(md.FullName(), num); err == nil {
+			return xt.TypeDescriptor()
+		}
+	}
+	return nil
+}
+
+// decodeNil consumes the next value and returns true if it is nil. Otherwise,
+// it consumes nothing and returns false.
+func decodeNil(dec *msgpack.Decoder) (bool, error) {
+	code, err := dec.PeekCode()
+	if err != nil {
+		return false, err
+	}
+	if code != msgpcode.Nil {
+		return false, nil
+	}
+	return true, dec.DecodeNil()
+}
+
+func decodeField(dec *msgpack.Decoder, fd protoreflect.FieldDescriptor, msg protoreflect.Message) error {
+	switch {
+	case fd.IsList():
+		n, err := dec.DecodeArrayLen()
+		if err != nil {
+			return fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		list := msg.Mutable(fd).List()
+		for i := 0; i < n; i++ {
+			val, err := decodeSingular(dec, fd, list.NewElement)
+			if err != nil {
+				return err
+			}
+			list.Append(val)
+		}
+		return nil
+	case fd.IsMap():
+		n, err := dec.DecodeMapLen()
+		if err != nil {
+			return fmt.Errorf("field %s: %w", fd.FullName(), err)
+		}
+		m := msg.Mutable(fd).Map()
+		for i := 0; i < n; i++ {
+			key, err := decodeSingular(dec, fd.MapKey(), nil)
+			if err != nil {
+				return err
+			}
+			val, err := decodeSingular(dec, fd.MapValue(), m.NewValue)
+			if err != nil {
+				return err
+			}
+			m.Set(key.MapKey(), val)
+		}
+		return nil
+	default:
+		val, err := decodeSingular(dec, fd, func() protoreflect.Value { return msg.NewField(fd) })
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, val)
+		return nil
+	}
+}
+
+// decodeSingular decodes a single value for the given field. The newMessage
+// function is used to create values for message fields; it is not used for
+// other kinds of fields.
+func decodeSingular(dec *msgpack.Decoder, fd protoreflect.FieldDescriptor, newMessage func() protoreflect.Value) (protoreflect.Value, error) {
+	var val protoreflect.Value
+	var err error
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = dec.DecodeBool()
+		val = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var i int64
+		i, err = dec.DecodeInt64()
+		if err == nil && int64(int32(i)) != i {
+			err = fmt.Errorf("value %d overflows int32", i)
+		}
+		val = protoreflect.ValueOfInt32(int32(i))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var i int64
+		i, err = dec.DecodeInt64()
+		val = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var u uint64
+		u, err = dec.DecodeUint64()
+		if err == nil && uint64(uint32(u)) != u {
+			err = fmt.Errorf("value %d overflows uint32", u)
+		}
+		val = protoreflect.ValueOfUint32(uint32(u))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var u uint64
+		u, err = dec.DecodeUint64()
+		val = protoreflect.ValueOfUint64(u)
+	case protoreflect.EnumKind:
+		var num protoreflect.EnumNumber
+		num, err = decodeEnum(dec, fd)
+		val = protoreflect.ValueOfEnum(num)
+	case protoreflect.FloatKind:
+		var f float32
+		f, err = dec.DecodeFloat32()
+		val = protoreflect.ValueOfFloat32(f)
+	case protoreflect.DoubleKind:
+		var f float64
+		f, err = dec.DecodeFloat64()
+		val = protoreflect.ValueOfFloat64(f)
+	case protoreflect.StringKind:
+		var s string
+		s, err = dec.DecodeString()
+		val = protoreflect.ValueOfString(s)
+	case protoreflect.BytesKind:
+		var b []byte
+		b, err = dec.DecodeBytes()
+		val = protoreflect.ValueOfBytes(b)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		val = newMessage()
+		if err := decodeMessage(dec, val.Message()); err != nil {
+			return protoreflect.Value{}, err
+		}
+	default:
+		err = fmt.Errorf("unsupported kind %s", fd.Kind())
+	}
+	if err != nil {
+		return protoreflect.Value{}, fmt.Errorf("field %s: %w", fd.FullName(), err)
+	}
+	return val, nil
+}
+
+func decodeEnum(dec *msgpack.Decoder, fd protoreflect.FieldDescriptor) (protoreflect.EnumNumber, error) {
+	code, err := dec.PeekCode()
+	if err != nil {
+		return 0, err
+	}
+	if msgpcode.IsString(code) {
+		name, err := dec.DecodeString()
+		if err != nil {
+			return 0, err
+		}
+		enumVal := fd.Enum().Values().ByName(protoreflect.Name(name))
+		if enumVal == nil {
+			return 0, fmt.Errorf("%q is not a known value of %s", name, fd.Enum().FullName())
+		}
+		return enumVal.Number(), nil
+	}
+	i, err := dec.DecodeInt64()
+	if err != nil {
+		return 0, err
+	}
+	if int64(int32(i)) != i {
+		return 0, fmt.Errorf("value %d overflows int32", i)
+	}
+	return protoreflect.EnumNumber(i), nil
+}
+
+func sortedMapKeys(m protoreflect.Map) []protoreflect.MapKey {
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		switch k := keys[i].Interface().(type) {
+		case bool:
+			return !k && keys[j].Bool()
+		case int32, int64:
+			return keys[i].Int() < keys[j].Int()
+		case uint32, uint64:
+			return keys[i].Uint() < keys[j].Uint()
+		default:
+			return keys[i].String() < keys[j].String()
+		}
+	})
+	return keys
+}
@@ -0,0 +1,139 @@
+package protomsgpack
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+func TestMarshal_Format(t *testing.T) {
+	msg := &testprotos.TestResponse{Vs: []int32{1, -2}}
+	data, err := Marshal(msg)
+	require.NoError(t, err)
+	// map with 1 entry, key 2, array of 2 elements: 1, -2
+	require.Equal(t, []byte{0x81, 0x02, 0x92, 0x01, 0xfe}, data)
+
+	data, err = MarshalOptions{UseJSONNames: true}.Marshal(msg)
+	require.NoError(t, err)
+	// map with 1 entry, key "vs", array of 2 elements: 1, -2
+	require.Equal(t, []byte{0x81, 0xa2, 'v', 's', 0x92, 0x01, 0xfe}, data)
+
+	// can be decoded without a schema when using JSON names
+	var raw map[string]any
+	require.NoError(t, msgpack.Unmarshal(data, &raw))
+	require.Equal(t, map[string]any{"vs": []any{int8(1), int8(-2)}}, raw)
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	unary := &testprotos.UnaryFields{
+		I: proto.Int32(math.MinInt32),
+		J: proto.Int64(math.MaxInt64),
+		K: proto.Int32(-3),
+		L: proto.Int64(-4),
+		M: proto.Uint32(math.MaxUint32),
+		N: proto.Uint64(math.MaxUint64),
+		O: proto.Uint32(7),
+		P: proto.Uint64(8),
+		Q: proto.Int32(-9),
+		R: proto.Int64(-10),
+		S: proto.Float32(1.5),
+		T: proto.Float64(math.Inf(-1)),
+		U: []byte{0, 1, 2, 3},
+		V: proto.String("abc"),
+		W: proto.Bool(true),
+		X: &testprotos.RepeatedFields{
+			I: []int32{1, 2, 3},
+			U: [][]byte{{1}, {}},
+			Z: []testprotos.TestEnum{testprotos.TestEnum_FIRST, 99},
+		},
+		Groupy: &testprotos.UnaryFields_GroupY{Ya: proto.String("ya")},
+		Z:      testprotos.TestEnum_SECOND.Enum(),
+	}
+	maps := &testprotos.AnotherTestMessage{
+		MapField1: map[int32]string{1: "one", -2: "minus two"},
+		MapField2: map[int64]float32{4: 4.5},
+		MapField3: map[uint32]bool{3: true},
+		MapField4: map[string]*testprotos.AnotherTestMessage{
+			"abc": {MapField2: map[int64]float32{4: 4.5}},
+		},
+	}
+	proto.SetExtension(maps, testprotos.E_Xtm, &testprotos.TestMessage{
+		Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE2},
+	})
+	wkt := &testprotos.TestWellKnownTypes{
+		StartTime: &timestamppb.Timestamp{Seconds: 1700000000, Nanos: 500000000},
+	}
+
+	for _, msg := range []proto.Message{unary, maps, wkt} {
+		for _, opts := range []MarshalOptions{{}, {UseJSONNames: true}} {
+			data, err := opts.Marshal(msg)
+			require.NoError(t, err)
+			// deterministic
+			again, err := opts.Marshal(msg)
+			require.NoError(t, err)
+			require.Equal(t, data, again)
+
+			roundTripped := msg.ProtoReflect().Type().New().Interface()
+			require.NoError(t, Unmarshal(data, roundTripped))
+			require.True(t, proto.Equal(msg, roundTripped))
+
+			dyn := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+			require.NoError(t, Unmarshal(data, dyn))
+			require.True(t, proto.Equal(msg, dyn))
+			dynData, err := opts.Marshal(dyn)
+			require.NoError(t, err)
+			require.Equal(t, data, dynData)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	// mixed keys, proto names, enum names, and nil values
+	data, err := msgpack.Marshal(map[any]any{
+		1:          int32(-1),
+		"v":        "abc",
+		"groupy":   map[string]any{"yb": 2},
+		"z":        "THIRD",
+		"w":        nil,
+		uint64(13): []byte{1, 2},
+	})
+	require.NoError(t, err)
+	var msg testprotos.UnaryFields
+	require.NoError(t, Unmarshal(data, &msg))
+	expected := &testprotos.UnaryFields{
+		I:      proto.Int32(-1),
+		V:      proto.String("abc"),
+		Groupy: &testprotos.UnaryFields_GroupY{Yb: proto.Int32(2)},
+		Z:      testprotos.TestEnum_THIRD.Enum(),
+		U:      []byte{1, 2},
+	}
+	require.True(t, proto.Equal(expected, &msg))
+
+	testCases := []struct {
+		name   string
+		data   any
+		errMsg string
+	}{
+		{name: "not a map", data: []any{1}, errMsg: "message testprotos.UnaryFields: msgpack: unexpected code"},
+		{name: "unknown field number", data: map[int]any{99: 1}, errMsg: "message testprotos.UnaryFields has no field 99"},
+		{name: "unknown field name", data: map[string]any{"nope": 1}, errMsg: "message testprotos.UnaryFields has no field nope"},
+		{name: "wrong type", data: map[string]any{"v": 1}, errMsg: "field testprotos.UnaryFields.v: msgpack: invalid code"},
+		{name: "overflow", data: map[string]any{"i": math.MaxInt32 + 1}, errMsg: "field testprotos.UnaryFields.i: value 2147483648 overflows int32"},
+		{name: "unknown enum", data: map[string]any{"z": "NOPE"}, errMsg: `field testprotos.UnaryFields.z: "NOPE" is not a known value of testprotos.TestEnum`},
+		{name: "unknown extension", data: map[string]any{"[foo.bar]": 1}, errMsg: "message testprotos.UnaryFields: could not resolve extension [foo.bar]"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := msgpack.Marshal(tc.data)
+			require.NoError(t, err)
+			require.ErrorContains(t, Unmarshal(data, &msg), tc.errMsg)
+		})
+	}
+}
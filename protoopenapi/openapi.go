@@ -0,0 +1,329 @@
+// Package protoopenapi generates OpenAPI documents that describe gRPC services
+// exposed as HTTP/JSON APIs, such as with gRPC transcoding.
+package protoopenapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/httprule"
+	"github.com/jhump/protoreflect/v2/internal/jsonschema"
+)
+
+// ServiceToOpenAPI returns an OpenAPI 3.0 document, encoded as JSON, that
+// describes the methods of the given service. The request and response bodies
+// are described by schemas for the methods' input and output messages, which
+// follow the protobuf JSON mapping. These schemas, and the schemas for all
+// messages and enums they reference, are defined in the document's components.
+// As in [github.com/jhump/protoreflect/v2/protojsonschema.MessageToJSONSchema],
+// members of a oneof produce a "oneOf" constraint that allows at most one of
+// them to be present.
+//
+// HTTP methods and paths are determined by the "google.api.http" method option,
+// if present, as returned by
+// [github.com/jhump/protoreflect/v2/protohttp.GetHTTPRule]. When a method has
+// no such option, it is bound to the HTTP POST method and the path
+// "/{package}.{Service}/{Method}", with the entire input message as the request
+// body.
+//
+// Server-streaming methods are documented as returning a stream of server-sent
+// events, using the "text/event-stream" media type. Client-streaming methods
+// are documented as accepting a stream of newline-delimited JSON messages, using
+// the "application/x-ndjson" media type.
+func ServiceToOpenAPI(sd protoreflect.ServiceDescriptor) ([]byte, error) {
+	g := generator{
+		Generator: jsonschema.NewGenerator(jsonschema.OpenAPI30, "#/components/schemas/"),
+		paths:     map[string]map[string]*operation{},
+	}
+	methods := sd.Methods()
+	for i, length := 0, methods.Len(); i < length; i++ {
+		md := methods.Get(i)
+		bindings, err := httpBindings(md)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", md.FullName(), err)
+		}
+		for j, b := range bindings {
+			op, err := g.operation(md, b)
+			if err != nil {
+				return nil, fmt.Errorf("method %s: %w", md.FullName(), err)
+			}
+			if j > 0 {
+				// operation IDs must be unique
+				op.OperationID = fmt.Sprintf("%s_%d", op.OperationID, j)
+			}
+			pathItem := g.paths[b.path]
+			if pathItem == nil {
+				pathItem = map[string]*operation{}
+				g.paths[b.path] = pathItem
+			}
+			method := strings.ToLower(b.method)
+			if _, exists := pathItem[method]; exists {
+				return nil, fmt.Errorf("method %s: %s %s is already bound to another method", md.FullName(), b.method, b.path)
+			}
+			pathItem[method] = op
+		}
+	}
+	doc := &document{
+		OpenAPI: "3.0.3",
+		Info: info{
+			Title:       string(sd.FullName()),
+			Description: jsonschema.Description(sd),
+			Version:     "1.0.0",
+		},
+		Paths:      g.paths,
+		Components: components{Schemas: g.Defs},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+type info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type components struct {
+	Schemas map[string]*jsonschema.Schema `json:"schemas,omitempty"`
+}
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*parameter         `json:"parameters,omitempty"`
+	RequestBody *requestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *jsonschema.Schema `json:"schema"`
+}
+
+// binding is an HTTP method and path for an RPC method.
+type binding struct {
+	method string
+	// path in OpenAPI form, with only variable names inside braces
+	path       string
+	pathParams []string
+	// "" for no body, "*" for the entire input message, or a field name
+	body         string
+	responseBody string
+}
+
+type generator struct {
+	*jsonschema.Generator
+	paths map[string]map[string]*operation
+}
+
+func (g *generator) operation(md protoreflect.MethodDescriptor, b binding) (*operation, error) {
+	svc := md.Parent().(protoreflect.ServiceDescriptor)
+	op := &operation{
+		OperationID: fmt.Sprintf("%s_%s", svc.Name(), md.Name()),
+		Description: jsonschema.Description(md),
+		Tags:        []string{string(svc.Name())},
+		Responses:   map[string]*response{},
+	}
+
+	boundFields := map[string]struct{}{}
+	for _, param := range b.pathParams {
+		fields, err := resolveFieldPath(md.Input(), param)
+		if err != nil {
+			return nil, fmt.Errorf("path %s: %w", b.path, err)
+		}
+		fld := fields[len(fields)-1]
+		op.Parameters = append(op.Parameters, &parameter{
+			Name:        param,
+			In:          "path",
+			Description: jsonschema.Description(fld),
+			Required:    true,
+			Schema:      g.FieldSchema(fld),
+		})
+		boundFields[string(fields[0].Name())] = struct{}{}
+	}
+
+	switch b.body {
+	case "":
+		// remaining fields are query parameters
+		fields := md.Input().Fields()
+		for i, length := 0, fields.Len(); i < length; i++ {
+			fld := fields.Get(i)
+			if _, ok := boundFields[string(fld.Name())]; ok || !g.isQueryParam(fld) {
+				continue
+			}
+			op.Parameters = append(op.Parameters, &parameter{
+				Name:        fld.JSONName(),
+				In:          "query",
+				Description: jsonschema.Description(fld),
+				Schema:      g.FieldSchema(fld),
+			})
+		}
+	case "*":
+		op.RequestBody = g.requestBody(md, g.MessageRef(md.Input()))
+	default:
+		fld := md.Input().Fields().ByName(protoreflect.Name(b.body))
+		if fld == nil {
+			return nil, fmt.Errorf("body field %q not found in %s", b.body, md.Input().FullName())
+		}
+		op.RequestBody = g.requestBody(md, g.FieldSchema(fld))
+	}
+
+	respSchema := g.MessageRef(md.Output())
+	if b.responseBody != "" {
+		fld := md.Output().Fields().ByName(protoreflect.Name(b.responseBody))
+		if fld == nil {
+			return nil, fmt.Errorf("response body field %q not found in %s", b.responseBody, md.Output().FullName())
+		}
+		respSchema = g.FieldSchema(fld)
+	}
+	mediaTypeName := "application/json"
+	if md.IsStreamingServer() {
+		mediaTypeName = "text/event-stream"
+	}
+	op.Responses["200"] = &response{
+		Description: "A successful response.",
+		Content:     map[string]*mediaType{mediaTypeName: {Schema: respSchema}},
+	}
+	op.Responses["default"] = &response{
+		Description: "An error response.",
+		Content:     map[string]*mediaType{"application/json": {Schema: &jsonschema.Schema{Type: "object"}}},
+	}
+	return op, nil
+}
+
+func (g *generator) requestBody(md protoreflect.MethodDescriptor, s *jsonschema.Schema) *requestBody {
+	mediaTypeName := "application/json"
+	if md.IsStreamingClient() {
+		mediaTypeName = "application/x-ndjson"
+	}
+	return &requestBody{
+		Required: true,
+		Content:  map[string]*mediaType{mediaTypeName: {Schema: s}},
+	}
+}
+
+func resolveFieldPath(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	parts := strings.Split(path, ".")
+	fields := make([]protoreflect.FieldDescriptor, len(parts))
+	for i, part := range parts {
+		if md == nil {
+			return nil, fmt.Errorf("field path %q: %s is not a message", path, fields[i-1].Name())
+		}
+		fld := md.Fields().ByName(protoreflect.Name(part))
+		if fld == nil {
+			return nil, fmt.Errorf("field path %q: field %q not found in %s", path, part, md.FullName())
+		}
+		fields[i] = fld
+		md = nil
+		if fld.Message() != nil && !fld.IsList() && !fld.IsMap() {
+			md = fld.Message()
+		}
+	}
+	return fields, nil
+}
+
+func (g *generator) isQueryParam(fld protoreflect.FieldDescriptor) bool {
+	if fld.IsMap() {
+		return false
+	}
+	if fld.Message() == nil {
+		return true
+	}
+	// only well-known types that are represented as JSON scalars
+	s := g.WellKnownSchema(fld.Message())
+	return s != nil && s.Type != nil && s.Type != "object" && s.Type != "array"
+}
+
+// httpBindings returns the HTTP bindings for the given method, as defined by
+// its google.api.http option. If the method has no such option, a default
+// binding is returned.
+func httpBindings(md protoreflect.MethodDescriptor) ([]binding, error) {
+	rules := httprule.Bindings(md)
+	if len(rules) == 0 {
+		svc := md.Parent().(protoreflect.ServiceDescriptor)
+		return []binding{{
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/%s/%s", svc.FullName(), md.Name()),
+			body:   "*",
+		}}, nil
+	}
+	bindings := make([]binding, len(rules))
+	for i, rule := range rules {
+		path, pathParams, err := convertPathTemplate(rule.Path)
+		if err != nil {
+			return nil, err
+		}
+		bindings[i] = binding{
+			method:       rule.Method,
+			path:         path,
+			pathParams:   pathParams,
+			body:         rule.Body,
+			responseBody: rule.ResponseBody,
+		}
+	}
+	return bindings, nil
+}
+
+// convertPathTemplate converts a google.api.http path template, like
+// "/v1/{name=shelves/*}/books", into an OpenAPI path, like
+// "/v1/{name}/books". It also returns the names of the path's variables.
+func convertPathTemplate(tmpl string) (string, []string, error) {
+	if !strings.HasPrefix(tmpl, "/") {
+		return "", nil, fmt.Errorf("path template %q must start with '/'", tmpl)
+	}
+	var sb strings.Builder
+	var params []string
+	rest := tmpl
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			if strings.IndexByte(rest, '}') >= 0 {
+				return "", nil, fmt.Errorf("path template %q has unbalanced braces", tmpl)
+			}
+			sb.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 || strings.IndexByte(rest[:start], '}') >= 0 {
+			return "", nil, fmt.Errorf("path template %q has unbalanced braces", tmpl)
+		}
+		end += start
+		name, _, _ := strings.Cut(rest[start+1:end], "=")
+		if name == "" {
+			return "", nil, fmt.Errorf("path template %q has variable with no name", tmpl)
+		}
+		sb.WriteString(rest[:start])
+		sb.WriteString("{" + name + "}")
+		params = append(params, name)
+		rest = rest[end+1:]
+	}
+	return sb.String(), params, nil
+}
@@ -0,0 +1,184 @@
+package protoopenapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const httpProto = `
+	syntax = "proto3";
+	package google.api;
+	import "google/protobuf/descriptor.proto";
+	extend google.protobuf.MethodOptions {
+		HttpRule http = 72295728;
+	}
+	message HttpRule {
+		string selector = 1;
+		oneof pattern {
+			string get = 2;
+			string put = 3;
+			string post = 4;
+			string delete = 5;
+			string patch = 6;
+			CustomHttpPattern custom = 8;
+		}
+		string body = 7;
+		string response_body = 12;
+		repeated HttpRule additional_bindings = 11;
+	}
+	message CustomHttpPattern {
+		string kind = 1;
+		string path = 2;
+	}
+`
+
+const testProto = `
+	syntax = "proto3";
+	package test;
+	import "google/api/http.proto";
+	// The library service.
+	service Library {
+		// Gets a book.
+		rpc GetBook(GetBookRequest) returns (Book) {
+			option (google.api.http) = {
+				get: "/v1/{name=shelves/*/books/*}"
+				additional_bindings { custom { kind: "HEAD" path: "/v1/{name=shelves/*/books/*}" } }
+			};
+		}
+		rpc UpdateBook(UpdateBookRequest) returns (Book) {
+			option (google.api.http) = {
+				patch: "/v1/{book.name=shelves/*/books/*}"
+				body: "book"
+			};
+		}
+		rpc CreateBook(Book) returns (Book);
+		rpc WatchBooks(GetBookRequest) returns (stream Book);
+	}
+	message GetBookRequest {
+		string name = 1;
+		int64 version = 2;
+		repeated string fields = 3;
+		Book filter = 4;
+	}
+	message UpdateBookRequest {
+		Book book = 1;
+	}
+	message Book {
+		string name = 1;
+		Genre genre = 2;
+		bytes cover = 3;
+		repeated Book related = 4;
+	}
+	enum Genre {
+		GENRE_UNSPECIFIED = 0;
+		GENRE_FICTION = 1;
+	}
+`
+
+func TestServiceToOpenAPI(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"google/api/http.proto": httpProto,
+				"test.proto":            testProto,
+			}),
+		}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	sd := files[0].Services().ByName("Library")
+
+	data, err := ServiceToOpenAPI(sd)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	assert.Equal(t, map[string]any{"title": "test.Library", "description": "The library service.", "version": "1.0.0"}, doc["info"])
+
+	paths := doc["paths"].(map[string]any)
+	assert.ElementsMatch(t,
+		[]string{"/v1/{name}", "/v1/{book.name}", "/test.Library/CreateBook", "/test.Library/WatchBooks"},
+		keys(paths))
+
+	getPath := paths["/v1/{name}"].(map[string]any)
+	assert.ElementsMatch(t, []string{"get", "head"}, keys(getPath))
+	getBook := getPath["get"].(map[string]any)
+	assert.Equal(t, "Library_GetBook", getBook["operationId"])
+	assert.Equal(t, "Library_GetBook_1", getPath["head"].(map[string]any)["operationId"])
+	assert.Equal(t, "Gets a book.", getBook["description"])
+	assert.NotContains(t, getBook, "requestBody")
+	assert.Equal(t, []any{
+		map[string]any{"name": "name", "in": "path", "required": true, "schema": map[string]any{"type": "string"}},
+		map[string]any{"name": "version", "in": "query", "schema": map[string]any{"type": "string", "format": "int64"}},
+		map[string]any{"name": "fields", "in": "query", "schema": map[string]any{"type": "array", "items": map[string]any{"type": "string"}}},
+	}, getBook["parameters"])
+	assert.Equal(t,
+		map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/test.Book"}}},
+		getBook["responses"].(map[string]any)["200"].(map[string]any)["content"])
+
+	updateBook := paths["/v1/{book.name}"].(map[string]any)["patch"].(map[string]any)
+	assert.Equal(t, []any{
+		map[string]any{"name": "book.name", "in": "path", "required": true, "schema": map[string]any{"type": "string"}},
+	}, updateBook["parameters"])
+	assert.Equal(t, map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/test.Book"}}},
+	}, updateBook["requestBody"])
+
+	// default binding
+	createBook := paths["/test.Library/CreateBook"].(map[string]any)["post"].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"required": true,
+		"content":  map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/test.Book"}}},
+	}, createBook["requestBody"])
+
+	// streaming
+	watchBooks := paths["/test.Library/WatchBooks"].(map[string]any)["post"].(map[string]any)
+	assert.Equal(t,
+		map[string]any{"text/event-stream": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/test.Book"}}},
+		watchBooks["responses"].(map[string]any)["200"].(map[string]any)["content"])
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	assert.ElementsMatch(t, []string{"test.Book", "test.Genre", "test.GetBookRequest"}, keys(schemas))
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":    map[string]any{"type": "string"},
+			"genre":   map[string]any{"$ref": "#/components/schemas/test.Genre"},
+			"cover":   map[string]any{"type": "string", "format": "byte"},
+			"related": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/components/schemas/test.Book"}},
+		},
+	}, schemas["test.Book"])
+	assert.Equal(t, map[string]any{"type": "string", "enum": []any{"GENRE_UNSPECIFIED", "GENRE_FICTION"}}, schemas["test.Genre"])
+}
+
+func TestConvertPathTemplate(t *testing.T) {
+	path, params, err := convertPathTemplate("/v1/{parent=shelves/*}/books/{book_id}:publish")
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/{parent}/books/{book_id}:publish", path)
+	assert.Equal(t, []string{"parent", "book_id"}, params)
+
+	_, _, err = convertPathTemplate("v1/foo")
+	require.ErrorContains(t, err, "must start with '/'")
+	_, _, err = convertPathTemplate("/v1/{name")
+	require.ErrorContains(t, err, "unbalanced braces")
+	_, _, err = convertPathTemplate("/v1/name}")
+	require.ErrorContains(t, err, "unbalanced braces")
+	_, _, err = convertPathTemplate("/v1/{=foo}")
+	require.ErrorContains(t, err, "no name")
+}
+
+func keys(m map[string]any) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
@@ -0,0 +1,236 @@
+package protoresolve
+
+import (
+	"container/list"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CachingResolver is a Resolver that caches the results of another resolver.
+// This is useful when the underlying resolver is expensive, such as one that
+// must query a remote source for each lookup.
+//
+// Descriptors are cached by full name and files are cached by path, each in
+// a separate LRU cache. Failed lookups are not cached, so a subsequent query
+// for the same element will again consult the underlying resolver. Methods
+// that enumerate elements or resolve extensions by number are not cached and
+// always delegate to the underlying resolver.
+//
+// It is safe to use a CachingResolver concurrently from multiple goroutines
+// as long as the underlying resolver is also thread-safe.
+type CachingResolver struct {
+	inner Resolver
+	files *lruCache[string, protoreflect.FileDescriptor]
+	descs *lruCache[protoreflect.FullName, protoreflect.Descriptor]
+}
+
+var _ Resolver = (*CachingResolver)(nil)
+
+// NewCachingResolver returns a resolver that caches the results of the
+// given resolver. The maxSize parameter is the maximum number of entries
+// in each cache (one for files by path, another for descriptors by name).
+// When a cache is full, the least recently used entry is evicted. If
+// maxSize is zero (or negative), the caches are unbounded.
+func NewCachingResolver(inner Resolver, maxSize int) *CachingResolver {
+	return &CachingResolver{
+		inner: inner,
+		files: newLRUCache[string, protoreflect.FileDescriptor](maxSize),
+		descs: newLRUCache[protoreflect.FullName, protoreflect.Descriptor](maxSize),
+	}
+}
+
+// Invalidate removes the descriptor with the given name from the cache.
+// The next query for that name will consult the underlying resolver.
+func (c *CachingResolver) Invalidate(name protoreflect.FullName) {
+	c.descs.remove(name)
+}
+
+// InvalidateFile removes the file with the given path from the cache.
+// The next query for that path will consult the underlying resolver.
+func (c *CachingResolver) InvalidateFile(path string) {
+	c.files.remove(path)
+}
+
+// InvalidateAll removes all entries from the cache.
+func (c *CachingResolver) InvalidateAll() {
+	c.files.clear()
+	c.descs.clear()
+}
+
+// FindFileByPath implements part of the Resolver interface.
+func (c *CachingResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, ok := c.files.get(path); ok {
+		return fd, nil
+	}
+	fd, err := c.inner.FindFileByPath(path)
+	if err != nil {
+		return nil, err
+	}
+	c.files.put(path, fd)
+	return fd, nil
+}
+
+// NumFiles implements part of the Resolver interface.
+func (c *CachingResolver) NumFiles() int {
+	return c.inner.NumFiles()
+}
+
+// RangeFiles implements part of the Resolver interface.
+func (c *CachingResolver) RangeFiles(fn func(protoreflect.FileDescriptor) bool) {
+	c.inner.RangeFiles(fn)
+}
+
+// NumFilesByPackage implements part of the Resolver interface.
+func (c *CachingResolver) NumFilesByPackage(name protoreflect.FullName) int {
+	return c.inner.NumFilesByPackage(name)
+}
+
+// RangeFilesByPackage implements part of the Resolver interface.
+func (c *CachingResolver) RangeFilesByPackage(name protoreflect.FullName, fn func(protoreflect.FileDescriptor) bool) {
+	c.inner.RangeFilesByPackage(name, fn)
+}
+
+// FindDescriptorByName implements part of the Resolver interface.
+func (c *CachingResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, ok := c.descs.get(name); ok {
+		return d, nil
+	}
+	d, err := c.inner.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	c.descs.put(name, d)
+	return d, nil
+}
+
+// FindMessageByName implements part of the Resolver interface.
+func (c *CachingResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
+	if d, ok := c.descs.get(name); ok {
+		msg, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, NewUnexpectedTypeError(DescriptorKindMessage, d, "")
+		}
+		return msg, nil
+	}
+	msg, err := c.inner.FindMessageByName(name)
+	if err != nil {
+		return nil, err
+	}
+	c.descs.put(name, msg)
+	return msg, nil
+}
+
+// FindMessageByURL implements part of the Resolver interface.
+func (c *CachingResolver) FindMessageByURL(url string) (protoreflect.MessageDescriptor, error) {
+	name := TypeNameFromURL(url)
+	if d, ok := c.descs.get(name); ok {
+		msg, ok := d.(protoreflect.MessageDescriptor)
+		if !ok {
+			return nil, NewUnexpectedTypeError(DescriptorKindMessage, d, url)
+		}
+		return msg, nil
+	}
+	msg, err := c.inner.FindMessageByURL(url)
+	if err != nil {
+		return nil, err
+	}
+	c.descs.put(msg.FullName(), msg)
+	return msg, nil
+}
+
+// FindExtensionByName implements part of the Resolver interface.
+func (c *CachingResolver) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionDescriptor, error) {
+	if d, ok := c.descs.get(name); ok {
+		ext, ok := d.(protoreflect.FieldDescriptor)
+		if !ok || !ext.IsExtension() {
+			return nil, NewUnexpectedTypeError(DescriptorKindExtension, d, "")
+		}
+		return ext, nil
+	}
+	ext, err := c.inner.FindExtensionByName(name)
+	if err != nil {
+		return nil, err
+	}
+	c.descs.put(name, ext)
+	return ext, nil
+}
+
+// FindExtensionByNumber implements part of the Resolver interface.
+func (c *CachingResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionDescriptor, error) {
+	return c.inner.FindExtensionByNumber(message, field)
+}
+
+// RangeExtensionsByMessage implements part of the Resolver interface.
+func (c *CachingResolver) RangeExtensionsByMessage(message protoreflect.FullName, fn func(protoreflect.ExtensionDescriptor) bool) {
+	c.inner.RangeExtensionsByMessage(message, fn)
+}
+
+// AsTypeResolver implements part of the Resolver interface.
+func (c *CachingResolver) AsTypeResolver() TypeResolver {
+	return TypesFromResolver(c)
+}
+
+// lruCache is a simple, thread-safe LRU cache. If maxSize is
+// zero or negative, the cache is unbounded.
+type lruCache[K comparable, V any] struct {
+	maxSize int
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	// most recently used entries are at the front
+	order list.List
+}
+
+type lruEntry[K comparable, V any] struct {
+	key K
+	val V
+}
+
+func newLRUCache[K comparable, V any](maxSize int) *lruCache[K, V] {
+	return &lruCache[K, V]{maxSize: maxSize, entries: map[K]*list.Element{}}
+}
+
+func (c *lruCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry[K, V]).val, true
+}
+
+func (c *lruCache[K, V]) put(key K, val V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*lruEntry[K, V]).val = val
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, val: val})
+	if c.maxSize > 0 && c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+func (c *lruCache[K, V]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+func (c *lruCache[K, V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[K]*list.Element{}
+	c.order.Init()
+}
@@ -0,0 +1,100 @@
+package protoresolve_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+type countingResolver struct {
+	protoresolve.Resolver
+	fileQueries, descQueries int
+}
+
+func (r *countingResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	r.fileQueries++
+	return r.Resolver.FindFileByPath(path)
+}
+
+func (r *countingResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	r.descQueries++
+	return r.Resolver.FindDescriptorByName(name)
+}
+
+func (r *countingResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
+	r.descQueries++
+	return r.Resolver.FindMessageByName(name)
+}
+
+func TestCachingResolver(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	inner := &countingResolver{Resolver: &reg}
+	res := protoresolve.NewCachingResolver(inner, 2)
+
+	for i := 0; i < 3; i++ {
+		fd, err := res.FindFileByPath("desc_test1.proto")
+		require.NoError(t, err)
+		assert.Equal(t, testprotos.File_desc_test1_proto, fd)
+	}
+	assert.Equal(t, 1, inner.fileQueries)
+
+	for i := 0; i < 3; i++ {
+		md, err := res.FindMessageByName("testprotos.TestMessage")
+		require.NoError(t, err)
+		assert.Equal(t, protoreflect.FullName("testprotos.TestMessage"), md.FullName())
+		d, err := res.FindDescriptorByName("testprotos.TestMessage")
+		require.NoError(t, err)
+		assert.Equal(t, md, d)
+	}
+	assert.Equal(t, 1, inner.descQueries)
+
+	// cached entries are still type-checked
+	_, err := res.FindDescriptorByName("testprotos.TestMessage.nm")
+	require.NoError(t, err)
+	_, err = res.FindMessageByName("testprotos.TestMessage.nm")
+	var unexpectedType *protoresolve.ErrUnexpectedType
+	require.True(t, errors.As(err, &unexpectedType))
+	assert.Equal(t, 2, inner.descQueries)
+
+	// failures are not cached
+	_, err = res.FindDescriptorByName("foo.bar.Baz")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	_, err = res.FindDescriptorByName("foo.bar.Baz")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	assert.Equal(t, 4, inner.descQueries)
+
+	// least recently used entry, TestMessage, is evicted
+	_, err = res.FindDescriptorByName("testprotos.TestMessage.NestedMessage")
+	require.NoError(t, err)
+	assert.Equal(t, 5, inner.descQueries)
+	_, err = res.FindDescriptorByName("testprotos.TestMessage.nm")
+	require.NoError(t, err)
+	assert.Equal(t, 5, inner.descQueries)
+	_, err = res.FindDescriptorByName("testprotos.TestMessage")
+	require.NoError(t, err)
+	assert.Equal(t, 6, inner.descQueries)
+
+	// invalidation
+	res.Invalidate("testprotos.TestMessage")
+	_, err = res.FindDescriptorByName("testprotos.TestMessage")
+	require.NoError(t, err)
+	assert.Equal(t, 7, inner.descQueries)
+	res.InvalidateFile("desc_test1.proto")
+	_, err = res.FindFileByPath("desc_test1.proto")
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.fileQueries)
+	res.InvalidateAll()
+	_, err = res.FindFileByPath("desc_test1.proto")
+	require.NoError(t, err)
+	assert.Equal(t, 3, inner.fileQueries)
+	_, err = res.FindDescriptorByName("testprotos.TestMessage")
+	require.NoError(t, err)
+	assert.Equal(t, 8, inner.descQueries)
+}
@@ -10,6 +10,10 @@ import (
 // Combine returns a resolver that iterates through the given resolvers to find elements.
 // The first resolver given is the first one checked, so will always be the preferred resolver.
 // When that returns a protoregistry.NotFound error, the next resolver will be checked, and so on.
+// If a resolver returns any other error, that error is returned immediately, without checking
+// subsequent resolvers. If all resolvers return protoregistry.NotFound, so does the returned
+// resolver. This makes it suitable for falling back from a local, in-process pool to a remote
+// source, like a server that supports gRPC server reflection.
 //
 // The NumFiles and NumFilesByPackage methods only return the number of files reported by the first
 // resolver. (Computing an accurate number of files across all resolvers could be an expensive
@@ -67,6 +71,60 @@ func CombinePools(res ...interface {
 	return &combinedWithPool{combined: combined(baseRes), pool: combinedPool(pools)}
 }
 
+// UnionPool returns a pool that contains the files and descriptors of both of
+// the given pools. Queries are first sent to primary. Only if primary returns an
+// error that wraps ErrNotFound is secondary consulted. So if both pools contain
+// a file with the same path, or a descriptor with the same name, the one from
+// primary wins, even if their contents differ. If primary returns any other
+// error, that error is returned without consulting secondary.
+//
+// This is the same as UnionPools(primary, secondary).
+func UnionPool(primary, secondary DescriptorPool) DescriptorPool {
+	return UnionPools(primary, secondary)
+}
+
+// UnionPools returns a pool that contains the files and descriptors of all of
+// the given pools. Queries are sent to each pool in the order given, until one
+// returns a result or an error other than one that wraps ErrNotFound. So when a
+// file or descriptor is present in more than one pool, the first pool always
+// wins.
+//
+// When enumerating files, the files of the first pool are emitted first. Files
+// in subsequent pools whose paths are the same as files already emitted are
+// skipped. Unlike Combine, the NumFiles and NumFilesByPackage methods of the
+// returned pool are accurate: they count the distinct file paths across all
+// pools. But this requires enumerating the files of all pools, so these methods
+// are linear in the number of files.
+func UnionPools(pools ...DescriptorPool) DescriptorPool {
+	res := make(combined, len(pools))
+	for i, pool := range pools {
+		res[i] = ResolverFromPool(pool)
+	}
+	return unionPool{combined: res}
+}
+
+type unionPool struct {
+	combined
+}
+
+func (u unionPool) NumFiles() int {
+	var count int
+	u.RangeFiles(func(protoreflect.FileDescriptor) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+func (u unionPool) NumFilesByPackage(name protoreflect.FullName) int {
+	var count int
+	u.RangeFilesByPackage(name, func(protoreflect.FileDescriptor) bool {
+		count++
+		return true
+	})
+	return count
+}
+
 type combined []Resolver
 
 func (c combined) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
@@ -90,13 +148,18 @@ func (c combined) NumFiles() int {
 func (c combined) RangeFiles(f func(protoreflect.FileDescriptor) bool) {
 	observed := map[string]struct{}{}
 	for _, res := range c {
+		keepGoing := true
 		res.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
 			if _, ok := observed[fd.Path()]; ok {
 				return true
 			}
 			observed[fd.Path()] = struct{}{}
-			return f(fd)
+			keepGoing = f(fd)
+			return keepGoing
 		})
+		if !keepGoing {
+			return
+		}
 	}
 }
 
@@ -110,13 +173,18 @@ func (c combined) NumFilesByPackage(name protoreflect.FullName) int {
 func (c combined) RangeFilesByPackage(name protoreflect.FullName, f func(protoreflect.FileDescriptor) bool) {
 	observed := map[string]struct{}{}
 	for _, res := range c {
+		keepGoing := true
 		res.RangeFilesByPackage(name, func(fd protoreflect.FileDescriptor) bool {
 			if _, ok := observed[fd.Path()]; ok {
 				return true
 			}
 			observed[fd.Path()] = struct{}{}
-			return f(fd)
+			keepGoing = f(fd)
+			return keepGoing
 		})
+		if !keepGoing {
+			return
+		}
 	}
 }
 
@@ -167,11 +235,12 @@ func (c combined) FindExtensionByNumber(message protoreflect.FullName, number pr
 func (c combined) RangeExtensionsByMessage(message protoreflect.FullName, fn func(protoreflect.ExtensionDescriptor) bool) {
 	seen := map[protoreflect.FieldNumber]struct{}{}
 	for _, res := range c {
-		var keepGoing bool
+		keepGoing := true
 		res.RangeExtensionsByMessage(message, func(ext protoreflect.ExtensionDescriptor) bool {
 			if _, ok := seen[ext.Number()]; ok {
 				return true
 			}
+			seen[ext.Number()] = struct{}{}
 			keepGoing = fn(ext)
 			return keepGoing
 		})
@@ -256,11 +325,12 @@ func (c combinedPool) FindEnumByName(name protoreflect.FullName) (protoreflect.E
 func (c combinedPool) RangeMessages(fn func(protoreflect.MessageType) bool) {
 	seen := map[protoreflect.FullName]struct{}{}
 	for _, res := range c {
-		var keepGoing bool
+		keepGoing := true
 		res.RangeMessages(func(msg protoreflect.MessageType) bool {
 			if _, ok := seen[msg.Descriptor().FullName()]; ok {
 				return true
 			}
+			seen[msg.Descriptor().FullName()] = struct{}{}
 			keepGoing = fn(msg)
 			return keepGoing
 		})
@@ -273,11 +343,12 @@ func (c combinedPool) RangeMessages(fn func(protoreflect.MessageType) bool) {
 func (c combinedPool) RangeEnums(fn func(protoreflect.EnumType) bool) {
 	seen := map[protoreflect.FullName]struct{}{}
 	for _, res := range c {
-		var keepGoing bool
+		keepGoing := true
 		res.RangeEnums(func(en protoreflect.EnumType) bool {
 			if _, ok := seen[en.Descriptor().FullName()]; ok {
 				return true
 			}
+			seen[en.Descriptor().FullName()] = struct{}{}
 			keepGoing = fn(en)
 			return keepGoing
 		})
@@ -290,11 +361,12 @@ func (c combinedPool) RangeEnums(fn func(protoreflect.EnumType) bool) {
 func (c combinedPool) RangeExtensions(fn func(protoreflect.ExtensionType) bool) {
 	seen := map[protoreflect.FullName]struct{}{}
 	for _, res := range c {
-		var keepGoing bool
+		keepGoing := true
 		res.RangeExtensions(func(ext protoreflect.ExtensionType) bool {
 			if _, ok := seen[ext.TypeDescriptor().FullName()]; ok {
 				return true
 			}
+			seen[ext.TypeDescriptor().FullName()] = struct{}{}
 			keepGoing = fn(ext)
 			return keepGoing
 		})
@@ -307,11 +379,12 @@ func (c combinedPool) RangeExtensions(fn func(protoreflect.ExtensionType) bool)
 func (c combinedPool) RangeExtensionsByMessage(message protoreflect.FullName, fn func(protoreflect.ExtensionType) bool) {
 	seen := map[protoreflect.FieldNumber]struct{}{}
 	for _, res := range c {
-		var keepGoing bool
+		keepGoing := true
 		res.RangeExtensionsByMessage(message, func(ext protoreflect.ExtensionType) bool {
 			if _, ok := seen[ext.TypeDescriptor().Number()]; ok {
 				return true
 			}
+			seen[ext.TypeDescriptor().Number()] = struct{}{}
 			keepGoing = fn(ext)
 			return keepGoing
 		})
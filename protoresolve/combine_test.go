@@ -0,0 +1,129 @@
+package protoresolve_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestCombine(t *testing.T) {
+	var first, second protoresolve.Registry
+	require.NoError(t, first.RegisterFile(testprotos.File_desc_test1_proto))
+	require.NoError(t, second.RegisterFile(testprotos.File_desc_test1_proto))
+	require.NoError(t, second.RegisterFile(testprotos.File_desc_test2_proto))
+	res := protoresolve.Combine(&first, &second)
+
+	// found in first
+	md, err := res.FindMessageByName("testprotos.TestMessage")
+	require.NoError(t, err)
+	assert.Equal(t, "desc_test1.proto", md.ParentFile().Path())
+	// falls back to second
+	md, err = res.FindMessageByName("testprotos.Frobnitz")
+	require.NoError(t, err)
+	assert.Equal(t, "desc_test2.proto", md.ParentFile().Path())
+	file, err := res.FindFileByPath("desc_test2.proto")
+	require.NoError(t, err)
+	assert.Equal(t, testprotos.File_desc_test2_proto, file)
+	// not found anywhere
+	_, err = res.FindDescriptorByName("foo.bar.Baz")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	// other errors are returned immediately
+	_, err = res.FindMessageByName("testprotos.TestMessage.nm")
+	var unexpectedType *protoresolve.ErrUnexpectedType
+	require.True(t, errors.As(err, &unexpectedType))
+
+	// type resolver also falls back
+	mt, err := res.AsTypeResolver().FindMessageByName("testprotos.Frobnitz")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("testprotos.Frobnitz"), mt.Descriptor().FullName())
+
+	// ranging suppresses duplicates
+	var paths []string
+	res.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		paths = append(paths, fd.Path())
+		return true
+	})
+	sort.Strings(paths)
+	assert.Equal(t, []string{"desc_test1.proto", "desc_test2.proto"}, paths)
+	var extNames []string
+	res.RangeExtensionsByMessage("testprotos.AnotherTestMessage", func(ext protoreflect.ExtensionDescriptor) bool {
+		extNames = append(extNames, string(ext.FullName()))
+		return true
+	})
+	assert.Len(t, extNames, 5)
+	// and stops early
+	paths = nil
+	res.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		paths = append(paths, fd.Path())
+		return false
+	})
+	assert.Len(t, paths, 1)
+
+	// ranging continues to subsequent resolvers even if the first is empty
+	res = protoresolve.Combine(&protoresolve.Registry{}, &second)
+	var count int
+	res.RangeExtensionsByMessage("testprotos.AnotherTestMessage", func(protoreflect.ExtensionDescriptor) bool {
+		count++
+		return true
+	})
+	assert.Equal(t, 5, count)
+	count = 0
+	res.(interface{ AsTypePool() protoresolve.TypePool }).AsTypePool().RangeMessages(func(protoreflect.MessageType) bool {
+		count++
+		return true
+	})
+	assert.Greater(t, count, 0)
+}
+
+func TestUnionPools(t *testing.T) {
+	// same file in both pools, but different instances
+	rebuilt, err := protodesc.NewFile(protodesc.ToFileDescriptorProto(testprotos.File_desc_test1_proto), protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	var primary, secondary, third protoresolve.Registry
+	require.NoError(t, primary.RegisterFile(rebuilt))
+	require.NoError(t, secondary.RegisterFile(testprotos.File_desc_test1_proto))
+	require.NoError(t, secondary.RegisterFile(testprotos.File_desc_test2_proto))
+	require.NoError(t, third.RegisterFile(testprotos.File_desc_test_proto3_proto))
+	pool := protoresolve.UnionPool(&primary, &secondary)
+
+	// primary wins
+	d, err := pool.FindDescriptorByName("testprotos.TestMessage")
+	require.NoError(t, err)
+	assert.Same(t, rebuilt, d.ParentFile())
+	fd, err := pool.FindFileByPath("desc_test1.proto")
+	require.NoError(t, err)
+	assert.Same(t, rebuilt, fd)
+	// falls back to secondary
+	d, err = pool.FindDescriptorByName("testprotos.Frobnitz")
+	require.NoError(t, err)
+	assert.Same(t, testprotos.File_desc_test2_proto, d.ParentFile())
+	_, err = pool.FindDescriptorByName("testprotos.TestRequest")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+
+	// counts are accurate, without duplicates
+	assert.Equal(t, 2, pool.NumFiles())
+	assert.Equal(t, 2, pool.NumFilesByPackage("testprotos"))
+	var files []protoreflect.FileDescriptor
+	pool.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		files = append(files, fd)
+		return true
+	})
+	require.Len(t, files, 2)
+	assert.Same(t, rebuilt, files[0])
+	assert.Same(t, testprotos.File_desc_test2_proto, files[1])
+
+	// N-way
+	pool = protoresolve.UnionPools(&primary, &secondary, &third)
+	assert.Equal(t, 3, pool.NumFiles())
+	_, err = pool.FindDescriptorByName("testprotos.TestRequest")
+	require.NoError(t, err)
+}
@@ -0,0 +1,63 @@
+package protoresolve
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ContextResolver is like Resolver, except that its methods accept a context.
+// This is useful for resolvers that are backed by a remote source, so that
+// queries can be cancelled and so that values in the context, such as tracing
+// spans, can be propagated to the remote source.
+//
+// The methods of ContextResolver correspond to the methods of Resolver that
+// query for a particular element. The methods that enumerate known elements do
+// not have counterparts since they are expected to only consult local state.
+type ContextResolver interface {
+	FindFileByPathContext(ctx context.Context, path string) (protoreflect.FileDescriptor, error)
+	FindDescriptorByNameContext(ctx context.Context, name protoreflect.FullName) (protoreflect.Descriptor, error)
+	FindMessageByNameContext(ctx context.Context, name protoreflect.FullName) (protoreflect.MessageDescriptor, error)
+	FindMessageByURLContext(ctx context.Context, url string) (protoreflect.MessageDescriptor, error)
+	FindExtensionByNameContext(ctx context.Context, name protoreflect.FullName) (protoreflect.ExtensionDescriptor, error)
+	FindExtensionByNumberContext(ctx context.Context, message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionDescriptor, error)
+}
+
+// AsContextResolver returns a ContextResolver that is backed by the given
+// resolver. If the given resolver already implements ContextResolver, it is
+// returned as is. Otherwise, the returned value delegates to the given
+// resolver's methods, ignoring the context.
+func AsContextResolver(r Resolver) ContextResolver {
+	if ctxRes, ok := r.(ContextResolver); ok {
+		return ctxRes
+	}
+	return contextResolver{r: r}
+}
+
+type contextResolver struct {
+	r Resolver
+}
+
+func (c contextResolver) FindFileByPathContext(_ context.Context, path string) (protoreflect.FileDescriptor, error) {
+	return c.r.FindFileByPath(path)
+}
+
+func (c contextResolver) FindDescriptorByNameContext(_ context.Context, name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	return c.r.FindDescriptorByName(name)
+}
+
+func (c contextResolver) FindMessageByNameContext(_ context.Context, name protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
+	return c.r.FindMessageByName(name)
+}
+
+func (c contextResolver) FindMessageByURLContext(_ context.Context, url string) (protoreflect.MessageDescriptor, error) {
+	return c.r.FindMessageByURL(url)
+}
+
+func (c contextResolver) FindExtensionByNameContext(_ context.Context, name protoreflect.FullName) (protoreflect.ExtensionDescriptor, error) {
+	return c.r.FindExtensionByName(name)
+}
+
+func (c contextResolver) FindExtensionByNumberContext(_ context.Context, message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionDescriptor, error) {
+	return c.r.FindExtensionByNumber(message, field)
+}
@@ -0,0 +1,49 @@
+package protoresolve_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestAsContextResolver(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	res := protoresolve.AsContextResolver(&reg)
+	ctx := context.Background()
+
+	fd, err := res.FindFileByPathContext(ctx, "desc_test1.proto")
+	require.NoError(t, err)
+	require.Same(t, testprotos.File_desc_test1_proto, fd)
+	d, err := res.FindDescriptorByNameContext(ctx, "testprotos.TestMessage")
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.FullName("testprotos.TestMessage"), d.FullName())
+	md, err := res.FindMessageByNameContext(ctx, "testprotos.AnotherTestMessage")
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.FullName("testprotos.AnotherTestMessage"), md.FullName())
+	md, err = res.FindMessageByURLContext(ctx, "type.googleapis.com/testprotos.TestMessage")
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.FullName("testprotos.TestMessage"), md.FullName())
+	ext, err := res.FindExtensionByNameContext(ctx, "testprotos.xtm")
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.FieldNumber(100), ext.Number())
+	ext, err = res.FindExtensionByNumberContext(ctx, "testprotos.AnotherTestMessage", 100)
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.FullName("testprotos.xtm"), ext.FullName())
+	_, err = res.FindDescriptorByNameContext(ctx, "foo.Bar")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+
+	// resolvers that already implement ContextResolver are returned as is
+	ctxRes := ctxResolver{Resolver: &reg}
+	require.Equal(t, protoresolve.ContextResolver(ctxRes), protoresolve.AsContextResolver(ctxRes))
+}
+
+type ctxResolver struct {
+	protoresolve.Resolver
+	protoresolve.ContextResolver
+}
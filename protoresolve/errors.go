@@ -1,6 +1,7 @@
 package protoresolve
 
 import (
+	"errors"
 	"fmt"
 
 	"google.golang.org/protobuf/reflect/protoreflect"
@@ -11,6 +12,11 @@ var (
 	// ErrNotFound is a sentinel error that is returned from resolvers to indicate that the named
 	// element is not known to the registry. It is the same as protoregistry.NotFound.
 	ErrNotFound = protoregistry.NotFound
+
+	// ErrUnknownAnyType is a sentinel error that is returned from NewMessageFromURL to
+	// indicate that the given type URL, such as from a google.protobuf.Any message,
+	// could not be resolved.
+	ErrUnknownAnyType = errors.New("unknown type for google.protobuf.Any")
 )
 
 // NewNotFoundError returns an error that wraps ErrNotFound with context
@@ -22,6 +28,13 @@ func NewNotFoundError[T ~string](name T) error {
 	return fmt.Errorf("%s: %w", name, ErrNotFound)
 }
 
+// NewNotFoundErrorf returns an error that wraps ErrNotFound with a message
+// formatted from the given format and args, as if by fmt.Sprintf. This is
+// useful when more context is needed than just the name of the element.
+func NewNotFoundErrorf(format string, args ...any) error {
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrNotFound)
+}
+
 // ErrUnexpectedType is an error that indicates a descriptor was resolved for
 // a given URL or name, but it is of the wrong type. So a query may have been
 // expecting a service descriptor, for example, but instead the queried name
@@ -57,6 +70,7 @@ func NewUnexpectedTypeError(expecting DescriptorKind, got protoreflect.Descripto
 		URL:        url,
 		Name:       name,
 		Expecting:  expecting,
+		Actual:     KindOf(got),
 		Descriptor: got,
 	}
 }
@@ -0,0 +1,39 @@
+package protoresolve_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestNewNotFoundError(t *testing.T) {
+	err := protoresolve.NewNotFoundError("foo.bar.Baz")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	require.EqualError(t, err, "foo.bar.Baz: "+protoresolve.ErrNotFound.Error())
+
+	err = protoresolve.NewNotFoundErrorf("no extension %d for message %q", 123, "foo.bar.Baz")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	require.EqualError(t, err, `no extension 123 for message "foo.bar.Baz": `+protoresolve.ErrNotFound.Error())
+}
+
+func TestNewUnexpectedTypeError(t *testing.T) {
+	field := testprotos.File_desc_test1_proto.Messages().ByName("TestMessage").Fields().ByName("nm")
+	err := fmt.Errorf("failed to resolve: %w", protoresolve.NewUnexpectedTypeError(protoresolve.DescriptorKindMessage, field, ""))
+	var unexpectedType *protoresolve.ErrUnexpectedType
+	require.True(t, errors.As(err, &unexpectedType))
+	require.Equal(t, protoreflect.FullName("testprotos.TestMessage.nm"), unexpectedType.Name)
+	require.Empty(t, unexpectedType.URL)
+	require.Equal(t, protoresolve.DescriptorKindMessage, unexpectedType.Expecting)
+	require.Equal(t, protoresolve.DescriptorKindField, unexpectedType.Actual)
+	require.Equal(t, field, unexpectedType.Descriptor)
+	require.EqualError(t, err, `failed to resolve: wrong kind of descriptor for name "testprotos.TestMessage.nm": expected a message, got a field`)
+
+	err = protoresolve.NewUnexpectedTypeError(protoresolve.DescriptorKindMessage, testprotos.File_desc_test1_proto.Enums().ByName("SomeEnum"), "type.googleapis.com/testprotos.SomeEnum")
+	require.EqualError(t, err, `wrong kind of descriptor for URL "type.googleapis.com/testprotos.SomeEnum": expected a message, got an enum`)
+}
@@ -0,0 +1,87 @@
+package protoresolve
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FilteredPool returns a view of the given pool that only includes the files
+// for which allow returns true. Queries for files that are not allowed, or for
+// elements defined in such files, return an error that wraps ErrNotFound, as if
+// the files were not present in the pool. Similarly, methods that enumerate
+// files skip the files that are not allowed.
+//
+// The allow function is called for each query, and repeatedly for the same
+// file, so it should be fast and must be safe for concurrent use if the returned
+// pool will be used concurrently. The methods that count files must call allow
+// for every file in the underlying pool, so they are linear in the size of the
+// pool.
+//
+// Note that the returned pool does not filter the imports of its files. So
+// a file that is allowed may import a file that is not, and the latter is still
+// accessible via the former's Imports method.
+func FilteredPool(pool DescriptorPool, allow func(protoreflect.FileDescriptor) bool) DescriptorPool {
+	return &filteredPool{pool: pool, allow: allow}
+}
+
+type filteredPool struct {
+	pool  DescriptorPool
+	allow func(protoreflect.FileDescriptor) bool
+}
+
+func (f *filteredPool) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	fd, err := f.pool.FindFileByPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if !f.allow(fd) {
+		return nil, NewNotFoundError(path)
+	}
+	return fd, nil
+}
+
+func (f *filteredPool) NumFiles() int {
+	var count int
+	f.RangeFiles(func(protoreflect.FileDescriptor) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+func (f *filteredPool) RangeFiles(fn func(protoreflect.FileDescriptor) bool) {
+	f.pool.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		if !f.allow(fd) {
+			return true
+		}
+		return fn(fd)
+	})
+}
+
+func (f *filteredPool) NumFilesByPackage(name protoreflect.FullName) int {
+	var count int
+	f.RangeFilesByPackage(name, func(protoreflect.FileDescriptor) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+func (f *filteredPool) RangeFilesByPackage(name protoreflect.FullName, fn func(protoreflect.FileDescriptor) bool) {
+	f.pool.RangeFilesByPackage(name, func(fd protoreflect.FileDescriptor) bool {
+		if !f.allow(fd) {
+			return true
+		}
+		return fn(fd)
+	})
+}
+
+func (f *filteredPool) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	d, err := f.pool.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if !f.allow(d.ParentFile()) {
+		return nil, NewNotFoundError(name)
+	}
+	return d, nil
+}
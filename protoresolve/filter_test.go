@@ -0,0 +1,50 @@
+package protoresolve_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/internal/testprotos/pkg"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestFilteredPool(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	require.NoError(t, reg.RegisterFile(pkg.File_pkg_desc_test_pkg_proto))
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test_proto3_proto))
+	pool := protoresolve.FilteredPool(&reg, func(fd protoreflect.FileDescriptor) bool {
+		return !strings.HasPrefix(fd.Path(), "desc_test1")
+	})
+
+	assert.Equal(t, 2, pool.NumFiles())
+	var paths []string
+	pool.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		paths = append(paths, fd.Path())
+		return true
+	})
+	assert.ElementsMatch(t, []string{"pkg/desc_test_pkg.proto", "desc_test_proto3.proto"}, paths)
+	assert.Equal(t, 1, pool.NumFilesByPackage("testprotos"))
+	paths = nil
+	pool.RangeFilesByPackage("testprotos", func(fd protoreflect.FileDescriptor) bool {
+		paths = append(paths, fd.Path())
+		return true
+	})
+	assert.Equal(t, []string{"desc_test_proto3.proto"}, paths)
+
+	_, err := pool.FindFileByPath("desc_test_proto3.proto")
+	require.NoError(t, err)
+	_, err = pool.FindDescriptorByName("testprotos.TestRequest")
+	require.NoError(t, err)
+	_, err = pool.FindFileByPath("desc_test1.proto")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	_, err = pool.FindDescriptorByName("testprotos.TestMessage")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	_, err = pool.FindDescriptorByName("testprotos.DoesNotExist")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+}
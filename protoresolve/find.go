@@ -0,0 +1,93 @@
+package protoresolve
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Find uses the given pool to find the element with the given fully-qualified
+// name. The element must be of type D. If the element is found but is the wrong
+// type, an *ErrUnexpectedType error is returned. For example, the following
+// finds a message:
+//
+//	md, err := protoresolve.Find[protoreflect.MessageDescriptor](pool, "foo.bar.Baz")
+//
+// Since protoreflect.ExtensionDescriptor is an alias for
+// protoreflect.FieldDescriptor, Find cannot distinguish between extensions and
+// other fields. Use FindExtension to find an element that must be an extension.
+func Find[D protoreflect.Descriptor](pool DescriptorResolver, name protoreflect.FullName) (D, error) {
+	var zero D
+	d, err := pool.FindDescriptorByName(name)
+	if err != nil {
+		return zero, err
+	}
+	result, ok := d.(D)
+	if !ok {
+		return zero, NewUnexpectedTypeError(kindOfType[D](), d, "")
+	}
+	return result, nil
+}
+
+// kindOfType returns the kind of descriptor represented by the type D.
+func kindOfType[D protoreflect.Descriptor]() DescriptorKind {
+	var zero D
+	switch any(&zero).(type) {
+	case *protoreflect.FileDescriptor:
+		return DescriptorKindFile
+	case *protoreflect.MessageDescriptor:
+		return DescriptorKindMessage
+	case *protoreflect.FieldDescriptor:
+		return DescriptorKindField
+	case *protoreflect.OneofDescriptor:
+		return DescriptorKindOneof
+	case *protoreflect.EnumDescriptor:
+		return DescriptorKindEnum
+	case *protoreflect.EnumValueDescriptor:
+		return DescriptorKindEnumValue
+	case *protoreflect.ServiceDescriptor:
+		return DescriptorKindService
+	case *protoreflect.MethodDescriptor:
+		return DescriptorKindMethod
+	default:
+		return DescriptorKindUnknown
+	}
+}
+
+// FindFile uses the given pool to find the file with the given path. This is
+// the same as calling pool.FindFileByPath and is provided for symmetry with the
+// other Find* functions in this package.
+func FindFile(pool FileResolver, path string) (protoreflect.FileDescriptor, error) {
+	return pool.FindFileByPath(path)
+}
+
+// FindMessage uses the given pool to find the message with the given name. It
+// is the same as Find[protoreflect.MessageDescriptor](pool, name).
+func FindMessage(pool DescriptorResolver, name protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
+	return Find[protoreflect.MessageDescriptor](pool, name)
+}
+
+// FindEnum uses the given pool to find the enum with the given name. It is the
+// same as Find[protoreflect.EnumDescriptor](pool, name).
+func FindEnum(pool DescriptorResolver, name protoreflect.FullName) (protoreflect.EnumDescriptor, error) {
+	return Find[protoreflect.EnumDescriptor](pool, name)
+}
+
+// FindService uses the given pool to find the service with the given name. It
+// is the same as Find[protoreflect.ServiceDescriptor](pool, name).
+func FindService(pool DescriptorResolver, name protoreflect.FullName) (protoreflect.ServiceDescriptor, error) {
+	return Find[protoreflect.ServiceDescriptor](pool, name)
+}
+
+// FindExtension uses the given pool to find the extension with the given name.
+// Unlike Find[protoreflect.ExtensionDescriptor](pool, name), this returns an
+// *ErrUnexpectedType error if the named element is a field but not an extension.
+func FindExtension(pool DescriptorResolver, name protoreflect.FullName) (protoreflect.ExtensionDescriptor, error) {
+	d, err := pool.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	ext, ok := d.(protoreflect.ExtensionDescriptor)
+	if !ok || !ext.IsExtension() {
+		return nil, NewUnexpectedTypeError(DescriptorKindExtension, d, "")
+	}
+	return ext, nil
+}
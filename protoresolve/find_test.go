@@ -0,0 +1,62 @@
+package protoresolve_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestFind(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+
+	md, err := protoresolve.Find[protoreflect.MessageDescriptor](&reg, "testprotos.TestMessage")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("testprotos.TestMessage"), md.FullName())
+	fld, err := protoresolve.Find[protoreflect.FieldDescriptor](&reg, "testprotos.TestMessage.ne")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FieldNumber(4), fld.Number())
+	val, err := protoresolve.Find[protoreflect.EnumValueDescriptor](&reg, "testprotos.TestMessage.VALUE1")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.EnumNumber(1), val.Number())
+
+	_, err = protoresolve.Find[protoreflect.EnumDescriptor](&reg, "testprotos.TestMessage")
+	require.EqualError(t, err, `wrong kind of descriptor for name "testprotos.TestMessage": expected an enum, got a message`)
+	var unexpectedType *protoresolve.ErrUnexpectedType
+	require.ErrorAs(t, err, &unexpectedType)
+	_, err = protoresolve.Find[protoreflect.MessageDescriptor](&reg, "testprotos.DoesNotExist")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+}
+
+func TestFindShims(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test_comments_proto.Imports().Get(1).FileDescriptor))
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test_comments_proto))
+
+	fd, err := protoresolve.FindFile(&reg, "desc_test1.proto")
+	require.NoError(t, err)
+	assert.Same(t, testprotos.File_desc_test1_proto, fd)
+	md, err := protoresolve.FindMessage(&reg, "testprotos.AnotherTestMessage")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("testprotos.AnotherTestMessage"), md.FullName())
+	ed, err := protoresolve.FindEnum(&reg, "testprotos.TestMessage.NestedEnum")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("testprotos.TestMessage.NestedEnum"), ed.FullName())
+	sd, err := protoresolve.FindService(&reg, "foo.bar.RpcService")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("foo.bar.RpcService"), sd.FullName())
+	ext, err := protoresolve.FindExtension(&reg, "testprotos.xtm")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FieldNumber(100), ext.Number())
+
+	_, err = protoresolve.FindService(&reg, "testprotos.TestMessage")
+	require.EqualError(t, err, `wrong kind of descriptor for name "testprotos.TestMessage": expected a service, got a message`)
+	_, err = protoresolve.FindExtension(&reg, "testprotos.TestMessage.ne")
+	require.EqualError(t, err, `wrong kind of descriptor for name "testprotos.TestMessage.ne": expected an extension, got a field`)
+}
@@ -2,10 +2,12 @@ package protoresolve
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/bits"
 	"strings"
 
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/dynamicpb"
 )
@@ -78,6 +80,148 @@ func RangeExtensionsByMessage(res DescriptorPool, message protoreflect.FullName,
 	})
 }
 
+// FindAllDescriptorsByKind returns all descriptors in the given pool that are of
+// the given kind. For example, this can be used to find all services known to
+// the pool. See RangeDescriptorsByKind.
+func FindAllDescriptorsByKind(pool DescriptorPool, kind DescriptorKind) []protoreflect.Descriptor {
+	var results []protoreflect.Descriptor
+	RangeDescriptorsByKind(pool, kind, func(d protoreflect.Descriptor) bool {
+		results = append(results, d)
+		return true
+	})
+	return results
+}
+
+// RangeDescriptorsByKind enumerates all descriptors in the given pool that are of
+// the given kind. It stops early if the given function returns false. Only the
+// parts of the descriptor hierarchy that can contain elements of the given kind
+// are examined. So, for example, searching for services does not descend into
+// the messages and enums in each file.
+func RangeDescriptorsByKind(pool DescriptorPool, kind DescriptorKind, fn func(protoreflect.Descriptor) bool) {
+	if kind == DescriptorKindUnknown || kind > DescriptorKindMethod {
+		return
+	}
+	pool.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		switch kind {
+		case DescriptorKindFile:
+			return fn(file)
+		case DescriptorKindService, DescriptorKindMethod:
+			svcs := file.Services()
+			for i, length := 0, svcs.Len(); i < length; i++ {
+				svc := svcs.Get(i)
+				if kind == DescriptorKindService {
+					if !fn(svc) {
+						return false
+					}
+					continue
+				}
+				mtds := svc.Methods()
+				for j, length := 0, mtds.Len(); j < length; j++ {
+					if !fn(mtds.Get(j)) {
+						return false
+					}
+				}
+			}
+			return true
+		default:
+			return rangeTypesByKind(file, kind, fn)
+		}
+	})
+}
+
+func rangeTypesByKind(container TypeContainer, kind DescriptorKind, fn func(protoreflect.Descriptor) bool) bool {
+	if kind == DescriptorKindExtension {
+		exts := container.Extensions()
+		for i, length := 0, exts.Len(); i < length; i++ {
+			if !fn(exts.Get(i)) {
+				return false
+			}
+		}
+	}
+	if kind == DescriptorKindEnum || kind == DescriptorKindEnumValue {
+		enums := container.Enums()
+		for i, length := 0, enums.Len(); i < length; i++ {
+			enum := enums.Get(i)
+			if kind == DescriptorKindEnum {
+				if !fn(enum) {
+					return false
+				}
+				continue
+			}
+			vals := enum.Values()
+			for j, length := 0, vals.Len(); j < length; j++ {
+				if !fn(vals.Get(j)) {
+					return false
+				}
+			}
+		}
+	}
+	msgs := container.Messages()
+	for i, length := 0, msgs.Len(); i < length; i++ {
+		msg := msgs.Get(i)
+		switch kind {
+		case DescriptorKindMessage:
+			if !fn(msg) {
+				return false
+			}
+		case DescriptorKindField:
+			fields := msg.Fields()
+			for j, length := 0, fields.Len(); j < length; j++ {
+				if !fn(fields.Get(j)) {
+					return false
+				}
+			}
+		case DescriptorKindOneof:
+			oneofs := msg.Oneofs()
+			for j, length := 0, oneofs.Len(); j < length; j++ {
+				if !fn(oneofs.Get(j)) {
+					return false
+				}
+			}
+		}
+		if !rangeTypesByKind(msg, kind, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// FindEnumValueByName uses the given resolver to find the enum value with the
+// given name. Enum values are defined in the same scope as their enclosing enum,
+// so the fully-qualified name of an enum value is its enum's parent scope plus
+// the value's name. For example, the TYPE_BOOL value of the enum
+// google.protobuf.FieldDescriptorProto.Type has the fully-qualified name
+// "google.protobuf.FieldDescriptorProto.TYPE_BOOL". But since it is common to
+// instead qualify a value by its enum's name, this function accepts either form.
+// So "google.protobuf.FieldDescriptorProto.Type.TYPE_BOOL" can also be used to
+// find the above value.
+//
+// If no enum value is found, the error returned from querying the given name
+// is returned. If the given name refers to an element that is not an enum
+// value, an *ErrUnexpectedType error is returned.
+func FindEnumValueByName(res DescriptorResolver, name protoreflect.FullName) (protoreflect.EnumValueDescriptor, error) {
+	d, err := res.FindDescriptorByName(name)
+	if err == nil {
+		val, ok := d.(protoreflect.EnumValueDescriptor)
+		if !ok {
+			return nil, NewUnexpectedTypeError(DescriptorKindEnumValue, d, "")
+		}
+		return val, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	// try interpreting name as enum name + value name
+	if parent, parentErr := res.FindDescriptorByName(name.Parent()); parentErr == nil {
+		if ed, ok := parent.(protoreflect.EnumDescriptor); ok {
+			if val := ed.Values().ByName(name.Name()); val != nil {
+				return val, nil
+			}
+		}
+	}
+	return nil, err
+}
+
 // FindDescriptorByNameInFile searches the given file for the element with the given
 // fully-qualified name. This could be used to implement the
 // [DescriptorResolver.FindDescriptorByName] method for a resolver that doesn't want
@@ -212,6 +356,24 @@ func TypeNameFromURL(url string) protoreflect.FullName {
 	return protoreflect.FullName(url[pos+1:])
 }
 
+// NewMessageFromURL returns a new, empty message whose type is identified by the
+// given URL, such as the type URL from a google.protobuf.Any message. The message
+// type is resolved using the given resolver.
+//
+// If the resolver cannot find the type, the returned error wraps both
+// ErrUnknownAnyType and ErrNotFound. Other errors from the resolver are returned
+// as is.
+func NewMessageFromURL(res MessageTypeResolver, url string) (proto.Message, error) {
+	mt, err := res.FindMessageByURL(url)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w %q: %w", ErrUnknownAnyType, url, err)
+		}
+		return nil, err
+	}
+	return mt.New().Interface(), nil
+}
+
 // TypeKind represents a category of types that can be registered in a TypeRegistry.
 // The value for a particular kind is a single bit, so a TypeKind value can also
 // represent multiple kinds, by setting multiple bits (by combining values via
@@ -53,6 +53,30 @@ func KindOf(d protoreflect.Descriptor) DescriptorKind {
 	}
 }
 
+// DescriptorPath returns a human-readable path for the given descriptor, suitable
+// for use in error messages. It is usually the same as the descriptor's full name,
+// with the following exceptions, which make it easier to distinguish different
+// kinds of elements:
+//   - Enum values are qualified with the name of their enum, instead of with the
+//     enum's enclosing scope. So a value VALUE in enum pkg.MyEnum has a path of
+//     "pkg.MyEnum.VALUE" (whereas its full name is "pkg.VALUE").
+//   - Extensions are enclosed in parentheses, like in option names. So an
+//     extension named pkg.ext has a path of "(pkg.ext)".
+//   - Files are indicated by their path, instead of by their package.
+func DescriptorPath(d protoreflect.Descriptor) string {
+	switch d := d.(type) {
+	case protoreflect.FileDescriptor:
+		return d.Path()
+	case protoreflect.EnumValueDescriptor:
+		return string(d.Parent().FullName().Append(d.Name()))
+	case protoreflect.FieldDescriptor:
+		if d.IsExtension() {
+			return "(" + string(d.FullName()) + ")"
+		}
+	}
+	return string(d.FullName())
+}
+
 // String returns a textual representation of k.
 func (k DescriptorKind) String() string {
 	switch k {
@@ -0,0 +1,36 @@
+package protoresolve_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestDescriptorPath(t *testing.T) {
+	testCases := []struct {
+		name     protoreflect.FullName
+		expected string
+	}{
+		{name: "testprotos.TestMessage", expected: "testprotos.TestMessage"},
+		{name: "testprotos.TestMessage.nm", expected: "testprotos.TestMessage.nm"},
+		{name: "testprotos.AnotherTestMessage.atmoo", expected: "testprotos.AnotherTestMessage.atmoo"},
+		{name: "testprotos.TestMessage.NestedEnum", expected: "testprotos.TestMessage.NestedEnum"},
+		{name: "testprotos.TestMessage.VALUE1", expected: "testprotos.TestMessage.NestedEnum.VALUE1"},
+		{name: "testprotos.xtm", expected: "(testprotos.xtm)"},
+		{name: "testprotos.TestMessage.NestedMessage.AnotherNestedMessage.flags", expected: "(testprotos.TestMessage.NestedMessage.AnotherNestedMessage.flags)"},
+		{name: "testprotos.SomeService.SomeMethod", expected: "testprotos.SomeService.SomeMethod"},
+	}
+	for _, tc := range testCases {
+		t.Run(string(tc.name), func(t *testing.T) {
+			d := protoresolve.FindDescriptorByNameInFile(testprotos.File_desc_test1_proto, tc.name)
+			require.NotNil(t, d)
+			assert.Equal(t, tc.expected, protoresolve.DescriptorPath(d))
+		})
+	}
+	assert.Equal(t, "desc_test1.proto", protoresolve.DescriptorPath(testprotos.File_desc_test1_proto))
+}
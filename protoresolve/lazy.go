@@ -0,0 +1,290 @@
+package protoresolve
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// NewLazyRegistry returns a registry that contains the files in the given set,
+// but defers creating a [protoreflect.FileDescriptor] for each file until it is
+// first needed. Creating descriptors for all files in a large set can be costly,
+// so this can greatly reduce startup time for programs that only ever use a
+// small portion of the set.
+//
+// A file's descriptor is created the first time the file is queried by path, or
+// when a descriptor that the file contains is queried by name, or when the file
+// is needed as a dependency of another file being created. Ranging over files
+// creates descriptors for all files visited. Files whose descriptors cannot be
+// created are skipped when ranging and result in an error when queried.
+//
+// The NumFiles and NumFilesByPackage methods do not create any descriptors. So
+// they count all files in the given set, including any whose descriptors cannot
+// be created. This means they can report more files than are visited by
+// RangeFiles and RangeFilesByPackage.
+//
+// This function verifies that the given set is complete (all imports are
+// present), has no import cycles, and contains no conflicting symbols. But it
+// does not otherwise validate the files until they are accessed. The returned
+// registry is safe for concurrent use. Additional files can be registered with
+// the RegisterFile method.
+func NewLazyRegistry(fds *descriptorpb.FileDescriptorSet) (DescriptorRegistry, error) {
+	reg := &lazyRegistry{
+		files:    make(map[string]*lazyFile, len(fds.GetFile())),
+		symbols:  map[protoreflect.FullName]string{},
+		packages: map[protoreflect.FullName][]string{},
+	}
+	for _, fdProto := range fds.GetFile() {
+		path := fdProto.GetName()
+		if _, exists := reg.files[path]; exists {
+			return nil, fmt.Errorf("duplicate file %q", path)
+		}
+		reg.files[path] = &lazyFile{proto: fdProto}
+		reg.paths = append(reg.paths, path)
+		pkg := protoreflect.FullName(fdProto.GetPackage())
+		reg.packages[pkg] = append(reg.packages[pkg], path)
+		var err error
+		rangeSymbols(fdProto, func(name protoreflect.FullName) bool {
+			if existing, exists := reg.symbols[name]; exists {
+				err = fmt.Errorf("file %q: symbol %q already defined in file %q", path, name, existing)
+				return false
+			}
+			reg.symbols[name] = path
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	checked := make(map[string]bool, len(reg.files))
+	for _, path := range reg.paths {
+		if err := reg.checkImports(path, checked); err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
+
+type lazyRegistry struct {
+	// these fields are not modified after construction
+	files    map[string]*lazyFile
+	paths    []string
+	symbols  map[protoreflect.FullName]string
+	packages map[protoreflect.FullName][]string
+
+	mu    sync.RWMutex
+	extra protoregistry.Files
+}
+
+type lazyFile struct {
+	proto *descriptorpb.FileDescriptorProto
+	once  sync.Once
+	fd    protoreflect.FileDescriptor
+	err   error
+}
+
+// checkImports verifies that the given file's imports are all present and that
+// they do not form a cycle. The given map tracks the files that have been
+// checked: the value is false while a file's imports are being checked and true
+// after they have been checked.
+func (r *lazyRegistry) checkImports(path string, checked map[string]bool) error {
+	if done, ok := checked[path]; ok {
+		if !done {
+			return fmt.Errorf("import cycle involving %q", path)
+		}
+		return nil
+	}
+	checked[path] = false
+	for _, dep := range r.files[path].proto.GetDependency() {
+		if _, ok := r.files[dep]; !ok {
+			return fmt.Errorf("file %q imports %q, but %q is not present", path, dep, dep)
+		}
+		if err := r.checkImports(dep, checked); err != nil {
+			return err
+		}
+	}
+	checked[path] = true
+	return nil
+}
+
+// load returns the descriptor for the given file, creating it if necessary.
+func (r *lazyRegistry) load(lf *lazyFile) (protoreflect.FileDescriptor, error) {
+	lf.once.Do(func() {
+		// Imports are resolved via r, which lazily loads them, too. There
+		// can be no deadlock since we've already verified there are no cycles.
+		lf.fd, lf.err = protodesc.NewFile(lf.proto, r)
+		if lf.err != nil {
+			lf.err = fmt.Errorf("failed to create descriptor for %q: %w", lf.proto.GetName(), lf.err)
+		}
+	})
+	return lf.fd, lf.err
+}
+
+// FindFileByPath implements part of the DescriptorRegistry interface.
+func (r *lazyRegistry) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if lf, ok := r.files[path]; ok {
+		return r.load(lf)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.extra.FindFileByPath(path)
+}
+
+// NumFiles implements part of the DescriptorRegistry interface. The count
+// includes files whose descriptors cannot be created, which are skipped by
+// RangeFiles.
+func (r *lazyRegistry) NumFiles() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.files) + r.extra.NumFiles()
+}
+
+// RangeFiles implements part of the DescriptorRegistry interface.
+func (r *lazyRegistry) RangeFiles(fn func(protoreflect.FileDescriptor) bool) {
+	if !r.rangeLazyFiles(r.paths, fn) {
+		return
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.extra.RangeFiles(fn)
+}
+
+// NumFilesByPackage implements part of the DescriptorRegistry interface. Like
+// NumFiles, the count includes files whose descriptors cannot be created.
+func (r *lazyRegistry) NumFilesByPackage(name protoreflect.FullName) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.packages[name]) + r.extra.NumFilesByPackage(name)
+}
+
+// RangeFilesByPackage implements part of the DescriptorRegistry interface.
+func (r *lazyRegistry) RangeFilesByPackage(name protoreflect.FullName, fn func(protoreflect.FileDescriptor) bool) {
+	if !r.rangeLazyFiles(r.packages[name], fn) {
+		return
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.extra.RangeFilesByPackage(name, fn)
+}
+
+func (r *lazyRegistry) rangeLazyFiles(paths []string, fn func(protoreflect.FileDescriptor) bool) bool {
+	for _, path := range paths {
+		fd, err := r.load(r.files[path])
+		if err != nil {
+			continue
+		}
+		if !fn(fd) {
+			return false
+		}
+	}
+	return true
+}
+
+// FindDescriptorByName implements part of the DescriptorRegistry interface.
+func (r *lazyRegistry) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if path, ok := r.symbols[name]; ok {
+		fd, err := r.load(r.files[path])
+		if err != nil {
+			return nil, err
+		}
+		if d := FindDescriptorByNameInFile(fd, name); d != nil {
+			return d, nil
+		}
+		return nil, NewNotFoundError(name)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.extra.FindDescriptorByName(name)
+}
+
+// RegisterFile implements part of the DescriptorRegistry interface. It returns
+// an error if the given file conflicts with any file in the registry, even if
+// that file has not yet been loaded.
+func (r *lazyRegistry) RegisterFile(file protoreflect.FileDescriptor) error {
+	if _, exists := r.files[file.Path()]; exists {
+		return fmt.Errorf("file %q already registered", file.Path())
+	}
+	var err error
+	rangeSymbols(protodesc.ToFileDescriptorProto(file), func(name protoreflect.FullName) bool {
+		if existing, exists := r.symbols[name]; exists {
+			err = fmt.Errorf("file %q: symbol %q already defined in file %q", file.Path(), name, existing)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.extra.RegisterFile(file)
+}
+
+// rangeSymbols calls fn for the fully-qualified name of every element
+// defined in the given file.
+func rangeSymbols(fd *descriptorpb.FileDescriptorProto, fn func(protoreflect.FullName) bool) {
+	pkg := protoreflect.FullName(fd.GetPackage())
+	if !rangeTypeSymbols(pkg, fd.GetMessageType(), fd.GetEnumType(), fd.GetExtension(), fn) {
+		return
+	}
+	for _, svc := range fd.GetService() {
+		svcName := pkg.Append(protoreflect.Name(svc.GetName()))
+		if !fn(svcName) {
+			return
+		}
+		for _, mtd := range svc.GetMethod() {
+			if !fn(svcName.Append(protoreflect.Name(mtd.GetName()))) {
+				return
+			}
+		}
+	}
+}
+
+func rangeTypeSymbols(
+	scope protoreflect.FullName,
+	msgs []*descriptorpb.DescriptorProto,
+	enums []*descriptorpb.EnumDescriptorProto,
+	exts []*descriptorpb.FieldDescriptorProto,
+	fn func(protoreflect.FullName) bool,
+) bool {
+	for _, msg := range msgs {
+		msgName := scope.Append(protoreflect.Name(msg.GetName()))
+		if !fn(msgName) {
+			return false
+		}
+		for _, fld := range msg.GetField() {
+			if !fn(msgName.Append(protoreflect.Name(fld.GetName()))) {
+				return false
+			}
+		}
+		for _, ood := range msg.GetOneofDecl() {
+			if !fn(msgName.Append(protoreflect.Name(ood.GetName()))) {
+				return false
+			}
+		}
+		if !rangeTypeSymbols(msgName, msg.GetNestedType(), msg.GetEnumType(), msg.GetExtension(), fn) {
+			return false
+		}
+	}
+	for _, enum := range enums {
+		if !fn(scope.Append(protoreflect.Name(enum.GetName()))) {
+			return false
+		}
+		// enum values are defined in the same scope as the enum
+		for _, val := range enum.GetValue() {
+			if !fn(scope.Append(protoreflect.Name(val.GetName()))) {
+				return false
+			}
+		}
+	}
+	for _, ext := range exts {
+		if !fn(scope.Append(protoreflect.Name(ext.GetName()))) {
+			return false
+		}
+	}
+	return true
+}
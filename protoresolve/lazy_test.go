@@ -0,0 +1,173 @@
+package protoresolve_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/internal/testprotos/nopkg"
+	"github.com/jhump/protoreflect/v2/internal/testprotos/pkg"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func lazyTestFileSet() *descriptorpb.FileDescriptorSet {
+	// dependents before dependencies
+	return &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(testprotos.File_desc_test2_proto),
+			protodesc.ToFileDescriptorProto(nopkg.File_nopkg_desc_test_nopkg_proto),
+			protodesc.ToFileDescriptorProto(nopkg.File_nopkg_desc_test_nopkg_new_proto),
+			protodesc.ToFileDescriptorProto(pkg.File_pkg_desc_test_pkg_proto),
+			protodesc.ToFileDescriptorProto(testprotos.File_desc_test1_proto),
+		},
+	}
+}
+
+func TestNewLazyRegistry(t *testing.T) {
+	fileSet := lazyTestFileSet()
+	// This file is invalid, but that isn't detected until it is used.
+	fileSet.File = append(fileSet.File, &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("broken.proto"),
+		Package: proto.String("broken"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Broken"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("foo"),
+						Number:   proto.Int32(1),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".broken.DoesNotExist"),
+					},
+				},
+			},
+		},
+	})
+	reg, err := protoresolve.NewLazyRegistry(fileSet)
+	require.NoError(t, err)
+	require.Equal(t, 6, reg.NumFiles())
+	require.Equal(t, 2, reg.NumFilesByPackage("testprotos"))
+
+	d, err := reg.FindDescriptorByName("testprotos.Frobnitz")
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.FullName("testprotos.Frobnitz"), d.FullName())
+	fd, err := reg.FindFileByPath("desc_test2.proto")
+	require.NoError(t, err)
+	require.Same(t, fd, d.ParentFile())
+	// imports are loaded from the same registry
+	dep, err := reg.FindFileByPath("desc_test1.proto")
+	require.NoError(t, err)
+	require.Same(t, dep, fd.Imports().Get(0).FileDescriptor)
+	// enum values are in the enum's enclosing scope
+	d, err = reg.FindDescriptorByName("testprotos.TestMessage.VALUE1")
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.FullName("testprotos.TestMessage.NestedEnum"), d.Parent().FullName())
+
+	_, err = reg.FindDescriptorByName("broken.Broken")
+	require.ErrorContains(t, err, `failed to create descriptor for "broken.proto"`)
+	_, err = reg.FindFileByPath("broken.proto")
+	require.ErrorContains(t, err, `failed to create descriptor for "broken.proto"`)
+	_, err = reg.FindDescriptorByName("testprotos.DoesNotExist")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+
+	// files that cannot be created are skipped
+	var paths []string
+	reg.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		paths = append(paths, fd.Path())
+		return true
+	})
+	assert.Equal(t, []string{
+		"desc_test2.proto",
+		"nopkg/desc_test_nopkg.proto",
+		"nopkg/desc_test_nopkg_new.proto",
+		"pkg/desc_test_pkg.proto",
+		"desc_test1.proto",
+	}, paths)
+	// but they are still counted, since counting does not create descriptors
+	require.Equal(t, 6, reg.NumFiles())
+	require.Equal(t, 1, reg.NumFilesByPackage("broken"))
+	var count int
+	reg.RangeFilesByPackage("broken", func(protoreflect.FileDescriptor) bool {
+		count++
+		return true
+	})
+	require.Zero(t, count)
+
+	// registering additional files
+	require.ErrorContains(t, reg.RegisterFile(testprotos.File_desc_test1_proto), `file "desc_test1.proto" already registered`)
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test_complex_proto))
+	require.Equal(t, 7, reg.NumFiles())
+	d, err = reg.FindDescriptorByName("foo.bar.Simple")
+	require.NoError(t, err)
+	require.Same(t, testprotos.File_desc_test_complex_proto, d.ParentFile())
+}
+
+func TestNewLazyRegistry_Errors(t *testing.T) {
+	fileSet := lazyTestFileSet()
+	fileSet.File = fileSet.File[:1]
+	_, err := protoresolve.NewLazyRegistry(fileSet)
+	require.ErrorContains(t, err, `file "desc_test2.proto" imports "desc_test1.proto", but "desc_test1.proto" is not present`)
+
+	fileSet = lazyTestFileSet()
+	fileSet.File = append(fileSet.File, protodesc.ToFileDescriptorProto(testprotos.File_desc_test1_proto))
+	_, err = protoresolve.NewLazyRegistry(fileSet)
+	require.EqualError(t, err, `duplicate file "desc_test1.proto"`)
+
+	fileSet = lazyTestFileSet()
+	dupe := protodesc.ToFileDescriptorProto(testprotos.File_desc_test1_proto)
+	dupe.Name = proto.String("dupe.proto")
+	fileSet.File = append(fileSet.File, dupe)
+	_, err = protoresolve.NewLazyRegistry(fileSet)
+	require.ErrorContains(t, err, `file "dupe.proto": symbol "testprotos.TestMessage" already defined in file "desc_test1.proto"`)
+
+	fileSet = &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{Name: proto.String("a.proto"), Dependency: []string{"b.proto"}},
+			{Name: proto.String("b.proto"), Dependency: []string{"a.proto"}},
+		},
+	}
+	_, err = protoresolve.NewLazyRegistry(fileSet)
+	require.EqualError(t, err, `import cycle involving "a.proto"`)
+}
+
+func TestNewLazyRegistry_ConcurrentFirstAccess(t *testing.T) {
+	reg, err := protoresolve.NewLazyRegistry(lazyTestFileSet())
+	require.NoError(t, err)
+	names := []protoreflect.FullName{
+		"testprotos.Frobnitz",
+		"testprotos.TestMessage",
+		"jhump.protoreflect.desc.Bar",
+		"TopLevel",
+	}
+	results := make([][]protoreflect.Descriptor, len(names))
+	for i := range results {
+		results[i] = make([]protoreflect.Descriptor, 8)
+	}
+	var wg sync.WaitGroup
+	for i, name := range names {
+		for j := 0; j < 8; j++ {
+			i, j, name := i, j, name
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d, err := reg.FindDescriptorByName(name)
+				assert.NoError(t, err)
+				results[i][j] = d
+			}()
+		}
+	}
+	wg.Wait()
+	for _, ds := range results {
+		for _, d := range ds[1:] {
+			require.Same(t, ds[0], d)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package protoresolve
+
+import (
+	"maps"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ReadOnlyPool is an immutable, point-in-time copy of the contents of a
+// [Registry]. It implements the full Resolver interface but provides no way to
+// register additional files. It is safe for concurrent use.
+//
+// Use [Registry.ReadOnlySnapshot] to create a ReadOnlyPool. The zero value is an
+// empty pool.
+type ReadOnlyPool struct {
+	reg *Registry
+}
+
+var _ Resolver = ReadOnlyPool{}
+
+// ReadOnlySnapshot returns a read-only copy of the current contents of r. Files
+// that are subsequently registered with r are not visible in the returned pool.
+// This is useful for serving queries from a consistent view of a registry while
+// the registry is being updated concurrently, such as when it is being reloaded
+// from a remote source.
+//
+// Creating the snapshot requires time proportional to the number of files in r.
+// But the descriptors themselves are not copied: the returned pool contains the
+// same descriptor instances as r.
+func (r *Registry) ReadOnlySnapshot() ReadOnlyPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	snapshot := &Registry{
+		exts:   make(map[protoreflect.FullName]map[protoreflect.FieldNumber]protoreflect.FieldDescriptor, len(r.exts)),
+		protos: maps.Clone(r.protos),
+	}
+	r.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		// cannot fail since the files were already successfully registered with r
+		_ = snapshot.files.RegisterFile(file)
+		return true
+	})
+	for msg, exts := range r.exts {
+		snapshot.exts[msg] = maps.Clone(exts)
+	}
+	return ReadOnlyPool{reg: snapshot}
+}
+
+func (p ReadOnlyPool) registry() *Registry {
+	if p.reg == nil {
+		return &Registry{}
+	}
+	return p.reg
+}
+
+// FindFileByPath implements part of the Resolver interface.
+func (p ReadOnlyPool) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	return p.registry().FindFileByPath(path)
+}
+
+// NumFiles implements part of the Resolver interface.
+func (p ReadOnlyPool) NumFiles() int {
+	return p.registry().NumFiles()
+}
+
+// RangeFiles implements part of the Resolver interface.
+func (p ReadOnlyPool) RangeFiles(fn func(protoreflect.FileDescriptor) bool) {
+	p.registry().RangeFiles(fn)
+}
+
+// NumFilesByPackage implements part of the Resolver interface.
+func (p ReadOnlyPool) NumFilesByPackage(name protoreflect.FullName) int {
+	return p.registry().NumFilesByPackage(name)
+}
+
+// RangeFilesByPackage implements part of the Resolver interface.
+func (p ReadOnlyPool) RangeFilesByPackage(name protoreflect.FullName, fn func(protoreflect.FileDescriptor) bool) {
+	p.registry().RangeFilesByPackage(name, fn)
+}
+
+// FindDescriptorByName implements part of the Resolver interface.
+func (p ReadOnlyPool) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	return p.registry().FindDescriptorByName(name)
+}
+
+// FindMessageByName implements part of the Resolver interface.
+func (p ReadOnlyPool) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
+	return p.registry().FindMessageByName(name)
+}
+
+// FindMessageByURL implements part of the Resolver interface.
+func (p ReadOnlyPool) FindMessageByURL(url string) (protoreflect.MessageDescriptor, error) {
+	return p.registry().FindMessageByURL(url)
+}
+
+// FindExtensionByName implements part of the Resolver interface.
+func (p ReadOnlyPool) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionDescriptor, error) {
+	return p.registry().FindExtensionByName(name)
+}
+
+// FindExtensionByNumber implements part of the Resolver interface.
+func (p ReadOnlyPool) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionDescriptor, error) {
+	return p.registry().FindExtensionByNumber(message, field)
+}
+
+// RangeExtensionsByMessage implements part of the Resolver interface.
+func (p ReadOnlyPool) RangeExtensionsByMessage(message protoreflect.FullName, fn func(protoreflect.ExtensionDescriptor) bool) {
+	p.registry().RangeExtensionsByMessage(message, fn)
+}
+
+// AsTypeResolver implements part of the Resolver interface.
+func (p ReadOnlyPool) AsTypeResolver() TypeResolver {
+	return p.AsTypePool()
+}
+
+// AsTypePool returns a view of this pool as a TypePool.
+func (p ReadOnlyPool) AsTypePool() TypePool {
+	return TypesFromDescriptorPool(p)
+}
@@ -3,8 +3,11 @@ package protoresolve
 import (
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"sync"
 
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
@@ -16,7 +19,9 @@ import (
 
 // Registry implements the full Resolver interface defined in this package. It is
 // thread-safe and can be used for all kinds of operations where types or descriptors
-// may need to be resolved from names or numbers.
+// may need to be resolved from names or numbers. Lookups acquire a read lock, so
+// they can proceed in parallel. It is safe to register new files while other
+// goroutines are concurrently resolving elements.
 //
 // Furthermore, it memoizes the underlying descriptor protos, so one can efficiently
 // recover a FileDescriptorProto for a particular FileDescriptor, without having to
@@ -82,19 +87,51 @@ func FromFiles(files *protoregistry.Files) (*Registry, error) {
 }
 
 // FromFileDescriptorSet constructs a *Registry from the given file descriptor set.
+// The files in the set need not be in any particular order: a copy of the set's
+// files is first sorted topologically so that each file is registered after its
+// dependencies. The given set is not modified. An error is returned if any file
+// imports a file that is not present in the set.
 func FromFileDescriptorSet(files *descriptorpb.FileDescriptorSet) (*Registry, error) {
 	var reg Registry
-	if err := sort.SortFiles(files.File); err != nil {
+	sorted := slices.Clone(files.File)
+	if err := sort.SortFiles(sorted); err != nil {
 		return nil, err
 	}
-	for _, file := range files.File {
+	for _, file := range sorted {
 		if _, err := reg.RegisterFileProto(file); err != nil {
-			return nil, fmt.Errorf("failed to register %q: %w", file, err)
+			return nil, fmt.Errorf("failed to register %q: %w", file.GetName(), err)
 		}
 	}
 	return &reg, nil
 }
 
+// NewRegistryFromFileDescriptorSet constructs a *Registry from the given bytes,
+// which must be a serialized google.protobuf.FileDescriptorSet. This is the
+// format produced by protoc's --descriptor_set_out option and by "buf build".
+// The files are registered as described by [FromFileDescriptorSet].
+func NewRegistryFromFileDescriptorSet(data []byte) (*Registry, error) {
+	var files descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal file descriptor set: %w", err)
+	}
+	return FromFileDescriptorSet(&files)
+}
+
+// NewRegistryFromFileDescriptorSetFile constructs a *Registry from the contents
+// of the file at the given path, which must be a serialized
+// google.protobuf.FileDescriptorSet. See [NewRegistryFromFileDescriptorSet].
+func NewRegistryFromFileDescriptorSetFile(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file descriptor set: %w", err)
+	}
+	reg, err := NewRegistryFromFileDescriptorSet(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return reg, nil
+}
+
 // RegisterFileProto registers the given file descriptor proto and returns the
 // corresponding [protoreflect.FileDescriptor]. All the file's dependencies must
 // have already been registered.
@@ -134,6 +171,90 @@ func (r *Registry) RegisterFile(file protoreflect.FileDescriptor) error {
 	return r.registerFileLocked(file, nil)
 }
 
+// MergeFrom registers all files from the given pool into r. If a file with
+// the same path is already registered with identical contents, it is skipped.
+// If a file with the same path is already registered but has different
+// contents, an error is returned that names the conflicting file. Files that
+// were processed before such an error is encountered remain registered.
+func (r *Registry) MergeFrom(pool DescriptorPool) error {
+	// Collect files first, in case pool is r or otherwise
+	// needs to acquire r's lock while ranging.
+	files := make([]protoreflect.FileDescriptor, 0, pool.NumFiles())
+	pool.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		files = append(files, file)
+		return true
+	})
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, file := range files {
+		if existing, err := r.files.FindFileByPath(file.Path()); err == nil {
+			if !sameFile(existing, file) {
+				return fmt.Errorf("file %q already registered with different contents", file.Path())
+			}
+			continue
+		}
+		if err := r.registerFileLocked(file, nil); err != nil {
+			return fmt.Errorf("failed to register %q: %w", file.Path(), err)
+		}
+	}
+	return nil
+}
+
+// Snapshot returns all files registered in r as a file descriptor set. The
+// files are in topological order: every file appears after all of its
+// dependencies. So the result is suitable for passing to [FromFileDescriptorSet]
+// or to [protodesc.NewFiles].
+//
+// For files that were registered via RegisterFileProto, the original file
+// descriptor proto is used. Callers should not mutate the returned protos.
+func (r *Registry) Snapshot() *descriptorpb.FileDescriptorSet {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	paths := make([]string, 0, r.files.NumFiles())
+	r.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		paths = append(paths, file.Path())
+		return true
+	})
+	// sort by path, so the result is deterministic
+	slices.Sort(paths)
+	added := make(map[string]struct{}, len(paths))
+	fileSet := &descriptorpb.FileDescriptorSet{
+		File: make([]*descriptorpb.FileDescriptorProto, 0, len(paths)),
+	}
+	var addFile func(path string)
+	addFile = func(path string) {
+		if _, ok := added[path]; ok {
+			return
+		}
+		added[path] = struct{}{}
+		file, err := r.files.FindFileByPath(path)
+		if err != nil {
+			// imported file is not in this registry
+			return
+		}
+		imports := file.Imports()
+		for i, length := 0, imports.Len(); i < length; i++ {
+			addFile(imports.Get(i).Path())
+		}
+		fd := r.protos[file]
+		if fd == nil {
+			fd = protodesc.ToFileDescriptorProto(file)
+		}
+		fileSet.File = append(fileSet.File, fd)
+	}
+	for _, path := range paths {
+		addFile(path)
+	}
+	return fileSet
+}
+
+func sameFile(a, b protoreflect.FileDescriptor) bool {
+	if a == b {
+		return true
+	}
+	return proto.Equal(protodesc.ToFileDescriptorProto(a), protodesc.ToFileDescriptorProto(b))
+}
+
 func (r *Registry) registerFileLocked(file protoreflect.FileDescriptor, fd *descriptorpb.FileDescriptorProto) error {
 	if err := r.checkExtensionsLocked(file); err != nil {
 		_, findFileErr := r.files.FindFileByPath(file.Path())
@@ -259,6 +380,10 @@ func (r *Registry) NumFiles() int {
 }
 
 // RangeFiles implements part of the FilePool interface.
+//
+// The files are copied while holding a read lock, and then fn is invoked for
+// each file after the lock has been released. So fn may safely register new
+// files with r. Such newly registered files are not visited.
 func (r *Registry) RangeFiles(fn func(protoreflect.FileDescriptor) bool) {
 	var files []protoreflect.FileDescriptor
 	func() {
@@ -286,7 +411,8 @@ func (r *Registry) NumFilesByPackage(name protoreflect.FullName) int {
 	return r.files.NumFilesByPackage(name)
 }
 
-// RangeFilesByPackage implements part of the FilePool interface.
+// RangeFilesByPackage implements part of the FilePool interface. Like
+// RangeFiles, it iterates over a snapshot, so fn may safely register new files.
 func (r *Registry) RangeFilesByPackage(name protoreflect.FullName, fn func(protoreflect.FileDescriptor) bool) {
 	var files []protoreflect.FileDescriptor
 	func() {
@@ -314,6 +440,21 @@ func (r *Registry) FindDescriptorByName(name protoreflect.FullName) (protoreflec
 	return r.files.FindDescriptorByName(name)
 }
 
+// Contains returns true if an element with the given name has been registered.
+// This is a convenience for checking membership without having to inspect
+// the error returned from FindDescriptorByName.
+func (r *Registry) Contains(name protoreflect.FullName) bool {
+	_, err := r.FindDescriptorByName(name)
+	return err == nil
+}
+
+// FindEnumValueByName returns the enum value with the given name. The name may
+// be the value's fully-qualified name, which is scoped to its enum's parent, or
+// it may be qualified by the name of the enum itself. See [FindEnumValueByName].
+func (r *Registry) FindEnumValueByName(name protoreflect.FullName) (protoreflect.EnumValueDescriptor, error) {
+	return FindEnumValueByName(r, name)
+}
+
 // FindMessageByName implements part of the Resolver interface.
 func (r *Registry) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
 	d, err := r.FindDescriptorByName(name)
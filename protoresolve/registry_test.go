@@ -1,11 +1,28 @@
 package protoresolve_test
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/internal/testprotos/nopkg"
+	"github.com/jhump/protoreflect/v2/internal/testprotos/pkg"
 	"github.com/jhump/protoreflect/v2/protoresolve"
 )
 
@@ -24,3 +41,291 @@ func TestFromFiles(t *testing.T) {
 	require.NoError(t, err)
 	testResolver(t, reg)
 }
+
+func TestFromFileDescriptorSet(t *testing.T) {
+	// dependents before dependencies
+	fileSet := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(testprotos.File_desc_test2_proto),
+			protodesc.ToFileDescriptorProto(nopkg.File_nopkg_desc_test_nopkg_proto),
+			protodesc.ToFileDescriptorProto(nopkg.File_nopkg_desc_test_nopkg_new_proto),
+			protodesc.ToFileDescriptorProto(pkg.File_pkg_desc_test_pkg_proto),
+			protodesc.ToFileDescriptorProto(testprotos.File_desc_test1_proto),
+		},
+	}
+	reg, err := protoresolve.FromFileDescriptorSet(fileSet)
+	require.NoError(t, err)
+	require.Equal(t, 5, reg.NumFiles())
+	_, err = reg.FindMessageByName("testprotos.Frobnitz")
+	require.NoError(t, err)
+	// the given set is not re-ordered
+	require.Equal(t, "desc_test2.proto", fileSet.File[0].GetName())
+	require.Equal(t, "desc_test1.proto", fileSet.File[4].GetName())
+
+	// serialized set, like one read from disk
+	data, err := proto.Marshal(fileSet)
+	require.NoError(t, err)
+	reg, err = protoresolve.NewRegistryFromFileDescriptorSet(data)
+	require.NoError(t, err)
+	require.Equal(t, 5, reg.NumFiles())
+	_, err = reg.FindMessageByName("testprotos.Frobnitz")
+	require.NoError(t, err)
+	_, err = protoresolve.NewRegistryFromFileDescriptorSet([]byte("not a file descriptor set"))
+	require.ErrorContains(t, err, "failed to unmarshal file descriptor set")
+
+	path := filepath.Join(t.TempDir(), "test.binpb")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	reg, err = protoresolve.NewRegistryFromFileDescriptorSetFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 5, reg.NumFiles())
+	_, err = reg.FindMessageByName("testprotos.Frobnitz")
+	require.NoError(t, err)
+	_, err = protoresolve.NewRegistryFromFileDescriptorSetFile(filepath.Join(t.TempDir(), "missing.binpb"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	fileSet = &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(testprotos.File_desc_test2_proto),
+		},
+	}
+	_, err = protoresolve.FromFileDescriptorSet(fileSet)
+	require.ErrorContains(t, err, `file "desc_test2.proto" imports "desc_test1.proto", but "desc_test1.proto" is not present`)
+	data, err = proto.Marshal(fileSet)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	_, err = protoresolve.NewRegistryFromFileDescriptorSetFile(path)
+	require.ErrorContains(t, err, `file "desc_test2.proto" imports "desc_test1.proto", but "desc_test1.proto" is not present`)
+}
+
+func TestRegistry_ConcurrentRegisterAndResolve(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+
+	files := []protoreflect.FileDescriptor{
+		testprotos.File_desc_test2_proto,
+		testprotos.File_desc_test_complex_proto,
+		testprotos.File_desc_test_proto3_proto,
+		testprotos.File_desc_test_wellknowntypes_proto,
+	}
+	var wg sync.WaitGroup
+	for _, file := range files {
+		file := file
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// require must not be used outside the test's goroutine
+			assert.NoError(t, reg.RegisterFile(file))
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := reg.FindMessageByName("testprotos.TestMessage")
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, err = reg.FindExtensionByNumber("testprotos.AnotherTestMessage", 100)
+				if !assert.NoError(t, err) {
+					return
+				}
+				reg.RangeFiles(func(protoreflect.FileDescriptor) bool {
+					return true
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, len(files)+1, reg.NumFiles())
+}
+
+func TestRegistry_RegisterDuringRange(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	require.NoError(t, reg.RegisterFile(pkg.File_pkg_desc_test_pkg_proto))
+
+	// Registering files from the callback must not deadlock, and the newly
+	// registered files are not visited.
+	var visited []string
+	reg.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		visited = append(visited, fd.Path())
+		if fd == testprotos.File_desc_test1_proto {
+			require.NoError(t, reg.RegisterFile(testprotos.File_desc_test_proto3_proto))
+		}
+		return true
+	})
+	require.Len(t, visited, 2)
+	visited = nil
+	reg.RangeFilesByPackage("testprotos", func(fd protoreflect.FileDescriptor) bool {
+		visited = append(visited, fd.Path())
+		require.NoError(t, reg.RegisterFile(testprotos.File_desc_test2_proto))
+		// stop early
+		return false
+	})
+	require.Len(t, visited, 1)
+	require.Equal(t, 4, reg.NumFiles())
+}
+
+func TestRegistry_Contains(t *testing.T) {
+	var reg protoresolve.Registry
+	require.False(t, reg.Contains("testprotos.TestMessage"))
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	require.True(t, reg.Contains("testprotos.TestMessage"))
+	require.True(t, reg.Contains("testprotos.TestMessage.NestedMessage.AnotherNestedMessage.flags"))
+	require.True(t, reg.Contains("testprotos.SomeService.SomeMethod"))
+	require.False(t, reg.Contains("testprotos.Frobnitz"))
+	require.False(t, reg.Contains(""))
+}
+
+func TestRegistry_MergeFrom(t *testing.T) {
+	var src, dest protoresolve.Registry
+	require.NoError(t, src.RegisterFile(testprotos.File_desc_test1_proto))
+	require.NoError(t, src.RegisterFile(testprotos.File_desc_test2_proto))
+	require.NoError(t, dest.RegisterFile(testprotos.File_desc_test1_proto))
+	require.NoError(t, dest.MergeFrom(&src))
+	require.Equal(t, 2, dest.NumFiles())
+	_, err := dest.FindMessageByName("testprotos.Frobnitz")
+	require.NoError(t, err)
+	// merging again is a no-op
+	require.NoError(t, dest.MergeFrom(&src))
+	require.Equal(t, 2, dest.NumFiles())
+
+	fileProto := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String("foo.proto"),
+		Package:     proto.String("foo"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Bar")}},
+	}
+	var first, second protoresolve.Registry
+	_, err = first.RegisterFileProto(fileProto)
+	require.NoError(t, err)
+	// identical contents, but a distinct descriptor
+	_, err = second.RegisterFileProto(proto.Clone(fileProto).(*descriptorpb.FileDescriptorProto))
+	require.NoError(t, err)
+	require.NoError(t, first.MergeFrom(&second))
+
+	var third protoresolve.Registry
+	otherProto := proto.Clone(fileProto).(*descriptorpb.FileDescriptorProto)
+	otherProto.MessageType[0].Name = proto.String("Baz")
+	_, err = third.RegisterFileProto(otherProto)
+	require.NoError(t, err)
+	require.ErrorContains(t, first.MergeFrom(&third), `file "foo.proto" already registered with different contents`)
+}
+
+func TestRegistry_Snapshot(t *testing.T) {
+	// registered out of order, so dependents come before dependencies
+	files := []protoreflect.FileDescriptor{
+		testprotos.File_desc_test_complex_proto,
+		testprotos.File_desc_test2_proto,
+		testprotos.File_desc_test1_proto,
+		testprotos.File_desc_test_wellknowntypes_proto,
+		pkg.File_pkg_desc_test_pkg_proto,
+		nopkg.File_nopkg_desc_test_nopkg_proto,
+		nopkg.File_nopkg_desc_test_nopkg_new_proto,
+		descriptorpb.File_google_protobuf_descriptor_proto,
+		anypb.File_google_protobuf_any_proto,
+		durationpb.File_google_protobuf_duration_proto,
+		structpb.File_google_protobuf_struct_proto,
+		timestamppb.File_google_protobuf_timestamp_proto,
+		wrapperspb.File_google_protobuf_wrappers_proto,
+	}
+	var reg protoresolve.Registry
+	for _, file := range files {
+		require.NoError(t, reg.RegisterFile(file))
+	}
+	fileSet := reg.Snapshot()
+	require.Len(t, fileSet.File, len(files))
+	seen := map[string]bool{}
+	for _, fd := range fileSet.File {
+		for _, dep := range fd.Dependency {
+			require.True(t, seen[dep], "%s should appear before %s", dep, fd.GetName())
+		}
+		seen[fd.GetName()] = true
+	}
+
+	roundTripped, err := protoresolve.FromFileDescriptorSet(fileSet)
+	require.NoError(t, err)
+	require.Equal(t, len(files), roundTripped.NumFiles())
+	md, err := roundTripped.FindMessageByName("foo.bar.Test")
+	require.NoError(t, err)
+	require.Equal(t, "desc_test_complex.proto", md.ParentFile().Path())
+}
+
+func TestRegistry_ReadOnlySnapshot(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	snapshot := reg.ReadOnlySnapshot()
+	testResolver(t, snapshot)
+
+	// changes to the registry are not visible in the snapshot
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test2_proto))
+	require.Equal(t, 2, reg.NumFiles())
+	require.Equal(t, 1, snapshot.NumFiles())
+	_, err := snapshot.FindMessageByName("testprotos.Frobnitz")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	_, err = snapshot.FindExtensionByNumber("testprotos.AnotherTestMessage", 100)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_, err := snapshot.FindMessageByName("testprotos.TestMessage")
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, err = snapshot.FindFileByPath("desc_test1.proto")
+				if !assert.NoError(t, err) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var empty protoresolve.ReadOnlyPool
+	require.Equal(t, 0, empty.NumFiles())
+	_, err = empty.FindMessageByName("testprotos.TestMessage")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+}
+
+func BenchmarkRegistry_ParallelReads(b *testing.B) {
+	var reg protoresolve.Registry
+	for _, file := range []protoreflect.FileDescriptor{
+		testprotos.File_desc_test1_proto,
+		testprotos.File_desc_test2_proto,
+		testprotos.File_desc_test_complex_proto,
+	} {
+		if err := reg.RegisterFile(file); err != nil {
+			b.Fatal(err)
+		}
+	}
+	names := []protoreflect.FullName{
+		"testprotos.TestMessage",
+		"testprotos.TestMessage.NestedMessage.AnotherNestedMessage",
+		"testprotos.AnotherTestMessage.map_field1",
+		"testprotos.SomeService.SomeMethod",
+		"foo.bar.Test.Nested._NestedNested",
+		"foo.bar.EnumWithReservations",
+	}
+	// RunParallel starts parallelism*GOMAXPROCS goroutines. So we compute the
+	// parallelism that results in 8 concurrent readers (or the fewest readers
+	// over 8, if GOMAXPROCS does not evenly divide 8).
+	const numReaders = 8
+	procs := runtime.GOMAXPROCS(0)
+	b.SetParallelism((numReaders + procs - 1) / procs)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			if _, err := reg.FindDescriptorByName(names[i%len(names)]); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
 
 	"github.com/jhump/protoreflect/v2/internal/testprotos"
 	"github.com/jhump/protoreflect/v2/protoresolve"
@@ -64,6 +65,48 @@ func TestFindExtensionByNumberInFile(t *testing.T) {
 	require.Nil(t, extd)
 }
 
+func TestFindEnumValueByName(t *testing.T) {
+	for _, name := range []protoreflect.FullName{
+		"google.protobuf.FieldDescriptorProto.TYPE_BOOL",
+		"google.protobuf.FieldDescriptorProto.Type.TYPE_BOOL",
+	} {
+		val, err := protoresolve.FindEnumValueByName(protoresolve.GlobalDescriptors, name)
+		require.NoError(t, err, name)
+		assert.Equal(t, protoreflect.FullName("google.protobuf.FieldDescriptorProto.TYPE_BOOL"), val.FullName())
+		assert.Equal(t, protoreflect.EnumNumber(8), val.Number())
+	}
+
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	val, err := reg.FindEnumValueByName("testprotos.TestMessage.NestedEnum.VALUE2")
+	require.NoError(t, err)
+	assert.Equal(t, protoreflect.FullName("testprotos.TestMessage.VALUE2"), val.FullName())
+
+	_, err = reg.FindEnumValueByName("testprotos.TestMessage.NestedEnum.VALUE3")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	_, err = reg.FindEnumValueByName("testprotos.TestMessage.NestedMessage")
+	var unexpectedType *protoresolve.ErrUnexpectedType
+	require.ErrorAs(t, err, &unexpectedType)
+}
+
+func TestNewMessageFromURL(t *testing.T) {
+	msg, err := protoresolve.NewMessageFromURL(protoregistry.GlobalTypes, "type.googleapis.com/testprotos.TestMessage")
+	require.NoError(t, err)
+	require.IsType(t, (*testprotos.TestMessage)(nil), msg)
+
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	msg, err = protoresolve.NewMessageFromURL(reg.AsTypeResolver(), "foo.com/testprotos.TestMessage")
+	require.NoError(t, err)
+	require.IsType(t, (*dynamicpb.Message)(nil), msg)
+	require.Equal(t, protoreflect.FullName("testprotos.TestMessage"), msg.ProtoReflect().Descriptor().FullName())
+
+	_, err = protoresolve.NewMessageFromURL(reg.AsTypeResolver(), "type.googleapis.com/testprotos.Frobnitz")
+	require.ErrorIs(t, err, protoresolve.ErrUnknownAnyType)
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	require.ErrorContains(t, err, `unknown type for google.protobuf.Any "type.googleapis.com/testprotos.Frobnitz"`)
+}
+
 func TestFindDescriptorByNameInFile(t *testing.T) {
 	d := protoresolve.FindDescriptorByNameInFile(testprotos.File_desc_test1_proto, "testprotos.TestMessage")
 	require.NotNil(t, d)
@@ -235,6 +278,83 @@ func TestRangeExtensionsByMessage(t *testing.T) {
 	assert.Equal(t, 0, len(exts))
 }
 
+func TestRangeDescriptorsByKind(t *testing.T) {
+	var files protoregistry.Files
+	err := files.RegisterFile(testprotos.File_desc_test1_proto)
+	require.NoError(t, err)
+	err = files.RegisterFile(testprotos.File_desc_test2_proto)
+	require.NoError(t, err)
+	err = files.RegisterFile(testprotos.File_desc_test_complex_proto)
+	require.NoError(t, err)
+
+	var names []string
+	for _, d := range protoresolve.FindAllDescriptorsByKind(&files, protoresolve.DescriptorKindService) {
+		names = append(names, string(d.FullName()))
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"foo.bar.TestTestService", "testprotos.SomeService"}, names)
+
+	// stops when func returns false
+	var count int
+	protoresolve.RangeDescriptorsByKind(&files, protoresolve.DescriptorKindMethod, func(protoreflect.Descriptor) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+
+	// compare results to an exhaustive walk of all descriptors
+	expected := map[protoresolve.DescriptorKind][]string{}
+	var walk func(d protoreflect.Descriptor)
+	walk = func(d protoreflect.Descriptor) {
+		kind := protoresolve.KindOf(d)
+		expected[kind] = append(expected[kind], string(d.FullName()))
+		var children []protoreflect.Descriptor
+		addAll := func(list interface {
+			Len() int
+		}, get func(int) protoreflect.Descriptor) {
+			for i := 0; i < list.Len(); i++ {
+				children = append(children, get(i))
+			}
+		}
+		switch d := d.(type) {
+		case protoreflect.FileDescriptor:
+			addAll(d.Services(), func(i int) protoreflect.Descriptor { return d.Services().Get(i) })
+			addAll(d.Messages(), func(i int) protoreflect.Descriptor { return d.Messages().Get(i) })
+			addAll(d.Enums(), func(i int) protoreflect.Descriptor { return d.Enums().Get(i) })
+			addAll(d.Extensions(), func(i int) protoreflect.Descriptor { return d.Extensions().Get(i) })
+		case protoreflect.MessageDescriptor:
+			addAll(d.Fields(), func(i int) protoreflect.Descriptor { return d.Fields().Get(i) })
+			addAll(d.Oneofs(), func(i int) protoreflect.Descriptor { return d.Oneofs().Get(i) })
+			addAll(d.Messages(), func(i int) protoreflect.Descriptor { return d.Messages().Get(i) })
+			addAll(d.Enums(), func(i int) protoreflect.Descriptor { return d.Enums().Get(i) })
+			addAll(d.Extensions(), func(i int) protoreflect.Descriptor { return d.Extensions().Get(i) })
+		case protoreflect.EnumDescriptor:
+			addAll(d.Values(), func(i int) protoreflect.Descriptor { return d.Values().Get(i) })
+		case protoreflect.ServiceDescriptor:
+			addAll(d.Methods(), func(i int) protoreflect.Descriptor { return d.Methods().Get(i) })
+		}
+		for _, child := range children {
+			walk(child)
+		}
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		walk(fd)
+		return true
+	})
+	for kind := protoresolve.DescriptorKindFile; kind <= protoresolve.DescriptorKindMethod; kind++ {
+		t.Run(kind.String(), func(t *testing.T) {
+			var actual []string
+			for _, d := range protoresolve.FindAllDescriptorsByKind(&files, kind) {
+				require.Equal(t, kind, protoresolve.KindOf(d))
+				actual = append(actual, string(d.FullName()))
+			}
+			require.NotEmpty(t, actual)
+			assert.ElementsMatch(t, expected[kind], actual)
+		})
+	}
+	assert.Empty(t, protoresolve.FindAllDescriptorsByKind(&files, protoresolve.DescriptorKindUnknown))
+}
+
 func TestGlobalDescriptors(t *testing.T) {
 	// TODO
 	testResolver(t, protoresolve.GlobalDescriptors)
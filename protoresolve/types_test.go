@@ -0,0 +1,119 @@
+package protoresolve_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestTypesFromDescriptorPool(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	types := protoresolve.TypesFromDescriptorPool(&reg)
+
+	testCases := []struct {
+		name     protoreflect.FullName
+		kind     protoresolve.DescriptorKind
+		notFound bool
+	}{
+		{name: "testprotos.TestMessage", kind: protoresolve.DescriptorKindMessage},
+		{name: "testprotos.TestMessage.NestedMessage.AnotherNestedMessage", kind: protoresolve.DescriptorKindMessage},
+		{name: "testprotos.SomeEnum", kind: protoresolve.DescriptorKindEnum},
+		{name: "testprotos.TestMessage.NestedEnum", kind: protoresolve.DescriptorKindEnum},
+		{name: "testprotos.xtm", kind: protoresolve.DescriptorKindExtension},
+		{name: "testprotos.TestMessage.NestedMessage.AnotherNestedMessage.flags", kind: protoresolve.DescriptorKindExtension},
+		{name: "testprotos.Nope", kind: protoresolve.DescriptorKindMessage, notFound: true},
+		{name: "testprotos.Nope", kind: protoresolve.DescriptorKindEnum, notFound: true},
+		{name: "testprotos.Nope", kind: protoresolve.DescriptorKindExtension, notFound: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.kind.String()+":"+string(tc.name), func(t *testing.T) {
+			var desc protoreflect.Descriptor
+			var err error
+			switch tc.kind {
+			case protoresolve.DescriptorKindMessage:
+				var mt protoreflect.MessageType
+				mt, err = types.FindMessageByName(tc.name)
+				if err == nil {
+					desc = mt.Descriptor()
+				}
+			case protoresolve.DescriptorKindEnum:
+				var et protoreflect.EnumType
+				et, err = types.FindEnumByName(tc.name)
+				if err == nil {
+					desc = et.Descriptor()
+				}
+			case protoresolve.DescriptorKindExtension:
+				var xt protoreflect.ExtensionType
+				xt, err = types.FindExtensionByName(tc.name)
+				if err == nil {
+					desc = xt.TypeDescriptor()
+				}
+			}
+			if tc.notFound {
+				require.ErrorIs(t, err, protoresolve.ErrNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.name, desc.FullName())
+			assert.Equal(t, tc.kind, protoresolve.KindOf(desc))
+		})
+	}
+
+	// can be used as a serialization resolver
+	var _ protoresolve.SerializationResolver = types
+	xt, err := types.FindExtensionByName("testprotos.xs")
+	require.NoError(t, err)
+	mt, err := types.FindMessageByName("testprotos.AnotherTestMessage")
+	require.NoError(t, err)
+	msg := mt.New()
+	msg.Set(xt.TypeDescriptor(), protoreflect.ValueOfString("abc"))
+	data, err := protojson.MarshalOptions{Resolver: types}.Marshal(msg.Interface())
+	require.NoError(t, err)
+	roundTripped := dynamicpb.NewMessage(mt.Descriptor())
+	require.NoError(t, protojson.UnmarshalOptions{Resolver: types}.Unmarshal(data, roundTripped))
+	assert.True(t, proto.Equal(msg.Interface(), roundTripped))
+}
+
+func TestTypesFromResolver_Range(t *testing.T) {
+	var reg protoresolve.Registry
+	require.NoError(t, reg.RegisterFile(testprotos.File_desc_test1_proto))
+	// Since a Registry is a DescriptorPool, the result is a TypePool.
+	types, ok := protoresolve.TypesFromResolver(&reg).(protoresolve.TypePool)
+	require.True(t, ok)
+
+	var messages, enums, extensions []protoreflect.FullName
+	types.RangeMessages(func(mt protoreflect.MessageType) bool {
+		messages = append(messages, mt.Descriptor().FullName())
+		return true
+	})
+	types.RangeEnums(func(et protoreflect.EnumType) bool {
+		enums = append(enums, et.Descriptor().FullName())
+		return true
+	})
+	types.RangeExtensions(func(xt protoreflect.ExtensionType) bool {
+		extensions = append(extensions, xt.TypeDescriptor().FullName())
+		return true
+	})
+	assert.Contains(t, messages, protoreflect.FullName("testprotos.TestMessage"))
+	assert.Contains(t, messages, protoreflect.FullName("testprotos.TestMessage.NestedMessage.AnotherNestedMessage"))
+	assert.Contains(t, enums, protoreflect.FullName("testprotos.TestMessage.NestedEnum"))
+	assert.Contains(t, extensions, protoreflect.FullName("testprotos.xtm"))
+	assert.Contains(t, extensions, protoreflect.FullName("testprotos.TestMessage.NestedMessage.AnotherNestedMessage.flags"))
+
+	// stopping early
+	var count int
+	types.RangeMessages(func(protoreflect.MessageType) bool {
+		count++
+		return false
+	})
+	assert.Equal(t, 1, count)
+}
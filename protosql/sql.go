@@ -0,0 +1,308 @@
+// Package protosql generates SQL DDL statements from protobuf message
+// descriptors, for storing messages in relational databases.
+package protosql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+// SQLDialect identifies a dialect of SQL, which determines the column types
+// and identifier quoting used in generated statements.
+type SQLDialect int
+
+// The supported SQL dialects.
+const (
+	DialectPostgres = SQLDialect(iota)
+	DialectMySQL
+	DialectSQLite
+)
+
+// String returns a textual representation of d.
+func (d SQLDialect) String() string {
+	switch d {
+	case DialectPostgres:
+		return "postgres"
+	case DialectMySQL:
+		return "mysql"
+	case DialectSQLite:
+		return "sqlite"
+	default:
+		return fmt.Sprintf("unknown dialect (%d)", d)
+	}
+}
+
+// PrimaryKeyOption is the name of the custom field option that marks a field as
+// the primary key. It must be a bool extension of google.protobuf.FieldOptions,
+// defined in a file that is (directly or transitively) imported by the file
+// that defines the message, or defined in that same file.
+const PrimaryKeyOption = "sql.primary_key"
+
+// MessageToCreateTable returns SQL DDL statements that create tables for
+// storing the given message. The table is named after the message, in
+// snake_case (for nested messages, the names of enclosing messages are included
+// as a prefix, separated by underscores). The primary key is the field that has
+// the [PrimaryKeyOption] set to true or, if there is no such field, the field
+// named "id". It is an error if the message has no primary key or if the
+// primary key field is not a singular scalar or enum field.
+//
+// Singular scalar fields are stored in columns of the appropriate type, and
+// enum fields are stored in integer columns, using the enum values' numbers.
+// Singular message fields are stored in JSON columns (in SQLite, which has no
+// JSON column type, these are text columns). Fields are nullable unless they
+// are required (in proto2 or editions syntax) or the primary key.
+//
+// Repeated and map fields are stored in separate join tables, whose names are
+// the main table's name and the field's name, separated by an underscore. Each
+// join table has a column that references the main table's primary key, named
+// after the main table and the primary key column, separated by an underscore.
+// Tables for repeated fields also have "idx" and "value" columns, which store
+// each element's position and value. Tables for map fields instead have "key"
+// and "value" columns.
+func MessageToCreateTable(md protoreflect.MessageDescriptor, dialect SQLDialect) (string, error) {
+	if dialect < DialectPostgres || dialect > DialectSQLite {
+		return "", fmt.Errorf("unsupported SQL dialect: %v", dialect)
+	}
+	pk, err := primaryKey(md)
+	if err != nil {
+		return "", err
+	}
+	g := generator{dialect: dialect}
+	table := tableName(md)
+	var joinTables []protoreflect.FieldDescriptor
+	var columns []string
+	fields := md.Fields()
+	for i, length := 0, fields.Len(); i < length; i++ {
+		fld := fields.Get(i)
+		if fld.IsList() || fld.IsMap() {
+			joinTables = append(joinTables, fld)
+			continue
+		}
+		col := g.quote(string(fld.Name())) + " " + g.columnType(fld, fld == pk)
+		if fld == pk || fld.Cardinality() == protoreflect.Required {
+			col += " NOT NULL"
+		}
+		columns = append(columns, col)
+	}
+	columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", g.quote(string(pk.Name()))))
+
+	var sb strings.Builder
+	g.writeCreateTable(&sb, table, columns)
+	for _, fld := range joinTables {
+		parentCol := g.quote(table + "_" + string(pk.Name()))
+		columns := []string{parentCol + " " + g.columnType(pk, true) + " NOT NULL"}
+		var key string
+		if fld.IsMap() {
+			key = g.quote("key")
+			columns = append(columns,
+				key+" "+g.columnType(fld.MapKey(), true)+" NOT NULL",
+				g.quote("value")+" "+g.columnType(fld.MapValue(), false))
+		} else {
+			key = g.quote("idx")
+			columns = append(columns,
+				key+" INTEGER NOT NULL",
+				g.quote("value")+" "+g.columnType(fld, false))
+		}
+		columns = append(columns,
+			fmt.Sprintf("PRIMARY KEY (%s, %s)", parentCol, key),
+			fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s) ON DELETE CASCADE",
+				parentCol, g.quote(table), g.quote(string(pk.Name()))))
+		sb.WriteString("\n")
+		g.writeCreateTable(&sb, table+"_"+string(fld.Name()), columns)
+	}
+	return sb.String(), nil
+}
+
+type generator struct {
+	dialect SQLDialect
+}
+
+func (g *generator) writeCreateTable(sb *strings.Builder, table string, columns []string) {
+	_, _ = fmt.Fprintf(sb, "CREATE TABLE %s (\n", g.quote(table))
+	for i, col := range columns {
+		sb.WriteString("  " + col)
+		if i < len(columns)-1 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(");\n")
+}
+
+func (g *generator) quote(ident string) string {
+	if g.dialect == DialectMySQL {
+		return "`" + strings.ReplaceAll(ident, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(ident, `"`, `""`) + `"`
+}
+
+// columnType returns the SQL type for a column that stores the value of the
+// given field. If key is true, the column is part of a primary key.
+func (g *generator) columnType(fld protoreflect.FieldDescriptor, key bool) string {
+	var postgres, mysql, sqlite string
+	switch fld.Kind() {
+	case protoreflect.BoolKind:
+		postgres, mysql, sqlite = "BOOLEAN", "BOOLEAN", "INTEGER"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind, protoreflect.EnumKind:
+		postgres, mysql, sqlite = "INTEGER", "INT", "INTEGER"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		postgres, mysql, sqlite = "BIGINT", "INT UNSIGNED", "INTEGER"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		postgres, mysql, sqlite = "BIGINT", "BIGINT", "INTEGER"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		postgres, mysql, sqlite = "NUMERIC(20)", "BIGINT UNSIGNED", "INTEGER"
+	case protoreflect.FloatKind:
+		postgres, mysql, sqlite = "REAL", "FLOAT", "REAL"
+	case protoreflect.DoubleKind:
+		postgres, mysql, sqlite = "DOUBLE PRECISION", "DOUBLE", "REAL"
+	case protoreflect.StringKind:
+		// MySQL cannot index TEXT columns without a prefix length
+		mysql = "TEXT"
+		if key {
+			mysql = "VARCHAR(255)"
+		}
+		postgres, sqlite = "TEXT", "TEXT"
+	case protoreflect.BytesKind:
+		postgres, mysql, sqlite = "BYTEA", "LONGBLOB", "BLOB"
+	default:
+		// messages
+		postgres, mysql, sqlite = "JSONB", "JSON", "TEXT"
+	}
+	switch g.dialect {
+	case DialectMySQL:
+		return mysql
+	case DialectSQLite:
+		return sqlite
+	default:
+		return postgres
+	}
+}
+
+func primaryKey(md protoreflect.MessageDescriptor) (protoreflect.FieldDescriptor, error) {
+	var pk protoreflect.FieldDescriptor
+	if ext := findExtension(md.ParentFile(), PrimaryKeyOption); ext != nil {
+		fields := md.Fields()
+		for i, length := 0, fields.Len(); i < length; i++ {
+			fld := fields.Get(i)
+			if !boolOption(fld.Options(), ext) {
+				continue
+			}
+			if pk != nil {
+				return nil, fmt.Errorf("message %s has multiple primary key fields: %s and %s", md.FullName(), pk.Name(), fld.Name())
+			}
+			pk = fld
+		}
+	}
+	if pk == nil {
+		pk = md.Fields().ByName("id")
+	}
+	if pk == nil {
+		return nil, fmt.Errorf("message %s has no primary key: no field has option (%s) set and no field is named id", md.FullName(), PrimaryKeyOption)
+	}
+	if pk.IsList() || pk.IsMap() || pk.Message() != nil {
+		return nil, fmt.Errorf("message %s: primary key field %s must be a singular scalar or enum field", md.FullName(), pk.Name())
+	}
+	return pk, nil
+}
+
+// findExtension searches the given file and its transitive imports for an
+// extension with the given name.
+func findExtension(fd protoreflect.FileDescriptor, name protoreflect.FullName) protoreflect.ExtensionDescriptor {
+	seen := map[string]struct{}{}
+	queue := []protoreflect.FileDescriptor{fd}
+	for len(queue) > 0 {
+		file := queue[0]
+		queue = queue[1:]
+		if _, ok := seen[file.Path()]; ok {
+			continue
+		}
+		seen[file.Path()] = struct{}{}
+		if ext, ok := protoresolve.FindDescriptorByNameInFile(file, name).(protoreflect.ExtensionDescriptor); ok {
+			return ext
+		}
+		imports := file.Imports()
+		for i, length := 0, imports.Len(); i < length; i++ {
+			queue = append(queue, imports.Get(i).FileDescriptor)
+		}
+	}
+	return nil
+}
+
+// boolOption returns the value of the given bool extension in the given options
+// message. The extension may be a known field or an unrecognized field.
+func boolOption(opts proto.Message, ext protoreflect.ExtensionDescriptor) bool {
+	msg := opts.ProtoReflect()
+	if !msg.IsValid() || ext.Kind() != protoreflect.BoolKind {
+		return false
+	}
+	var val, found bool
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.FullName() == ext.FullName() {
+			val, found = v.Bool(), true
+			return false
+		}
+		return true
+	})
+	if found {
+		return val
+	}
+	unknown := msg.GetUnknown()
+	for len(unknown) > 0 {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return false
+		}
+		unknown = unknown[n:]
+		if num == ext.Number() && typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(unknown)
+			if n < 0 {
+				return false
+			}
+			// last value wins
+			val = v != 0
+			unknown = unknown[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, unknown)
+		if n < 0 {
+			return false
+		}
+		unknown = unknown[n:]
+	}
+	return val
+}
+
+// tableName returns the snake_case name of the table for the given message.
+func tableName(md protoreflect.MessageDescriptor) string {
+	name := strings.TrimPrefix(string(md.FullName()), string(md.ParentFile().Package()))
+	parts := strings.Split(strings.TrimPrefix(name, "."), ".")
+	for i, part := range parts {
+		parts[i] = snakeCase(part)
+	}
+	return strings.Join(parts, "_")
+}
+
+// snakeCase converts an UpperCamelCase name to snake_case.
+func snakeCase(name string) string {
+	var sb strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// start a new word at an upper-case letter that follows a lower-case
+			// letter or digit or that precedes a lower-case letter (for acronyms)
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) && runes[i-1] != '_' {
+				sb.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
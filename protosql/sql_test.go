@@ -0,0 +1,152 @@
+package protosql
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func compileForTest(t *testing.T, source string) protoreflect.FileDescriptor {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"sql.proto": `
+					syntax = "proto3";
+					package sql;
+					import "google/protobuf/descriptor.proto";
+					extend google.protobuf.FieldOptions {
+						bool primary_key = 50000;
+					}
+				`,
+				"test.proto": source,
+			}),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	return files[0]
+}
+
+func TestMessageToCreateTable(t *testing.T) {
+	fd := compileForTest(t, `
+		syntax = "proto2";
+		package test;
+		import "sql.proto";
+		message UserProfile {
+			required string user_name = 1 [(sql.primary_key) = true];
+			optional int64 id = 2;
+			optional uint32 age = 3;
+			optional bytes avatar = 4;
+			optional Address address = 5;
+			optional Status status = 6;
+			repeated string emails = 7;
+			map<string, double> scores = 8;
+			message Address {
+				optional string city = 1;
+			}
+			enum Status {
+				UNKNOWN = 0;
+			}
+		}
+	`)
+	md := fd.Messages().ByName("UserProfile")
+
+	ddl, err := MessageToCreateTable(md, DialectPostgres)
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE TABLE "user_profile" (
+  "user_name" TEXT NOT NULL,
+  "id" BIGINT,
+  "age" BIGINT,
+  "avatar" BYTEA,
+  "address" JSONB,
+  "status" INTEGER,
+  PRIMARY KEY ("user_name")
+);
+
+CREATE TABLE "user_profile_emails" (
+  "user_profile_user_name" TEXT NOT NULL,
+  "idx" INTEGER NOT NULL,
+  "value" TEXT,
+  PRIMARY KEY ("user_profile_user_name", "idx"),
+  FOREIGN KEY ("user_profile_user_name") REFERENCES "user_profile" ("user_name") ON DELETE CASCADE
+);
+
+CREATE TABLE "user_profile_scores" (
+  "user_profile_user_name" TEXT NOT NULL,
+  "key" TEXT NOT NULL,
+  "value" DOUBLE PRECISION,
+  PRIMARY KEY ("user_profile_user_name", "key"),
+  FOREIGN KEY ("user_profile_user_name") REFERENCES "user_profile" ("user_name") ON DELETE CASCADE
+);
+`, ddl)
+
+	ddl, err = MessageToCreateTable(md, DialectMySQL)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ddl, "CREATE TABLE `user_profile` (\n"+
+		"  `user_name` VARCHAR(255) NOT NULL,\n"+
+		"  `id` BIGINT,\n"+
+		"  `age` INT UNSIGNED,\n"+
+		"  `avatar` LONGBLOB,\n"+
+		"  `address` JSON,\n"+
+		"  `status` INT,\n"+
+		"  PRIMARY KEY (`user_name`)\n"+
+		");\n"), ddl)
+	assert.Contains(t, ddl, "`key` VARCHAR(255) NOT NULL,\n  `value` DOUBLE,\n")
+
+	ddl, err = MessageToCreateTable(md, DialectSQLite)
+	require.NoError(t, err)
+	assert.Contains(t, ddl, `"address" TEXT,`)
+	assert.Contains(t, ddl, `"value" REAL,`)
+
+	_, err = MessageToCreateTable(md.Messages().ByName("Address"), DialectPostgres)
+	require.ErrorContains(t, err, "message test.UserProfile.Address has no primary key")
+
+	_, err = MessageToCreateTable(md, SQLDialect(42))
+	require.EqualError(t, err, "unsupported SQL dialect: unknown dialect (42)")
+}
+
+func TestMessageToCreateTable_DefaultPrimaryKey(t *testing.T) {
+	fd := compileForTest(t, `
+		syntax = "proto3";
+		package test;
+		import "sql.proto";
+		message HTTPRequestLog {
+			uint64 id = 1;
+			string url = 2;
+		}
+		message BadKey {
+			repeated int32 id = 1;
+		}
+		message TwoKeys {
+			int32 a = 1 [(sql.primary_key) = true];
+			int32 b = 2 [(sql.primary_key) = true];
+		}
+	`)
+	ddl, err := MessageToCreateTable(fd.Messages().ByName("HTTPRequestLog"), DialectPostgres)
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE TABLE "http_request_log" (
+  "id" NUMERIC(20) NOT NULL,
+  "url" TEXT,
+  PRIMARY KEY ("id")
+);
+`, ddl)
+
+	_, err = MessageToCreateTable(fd.Messages().ByName("BadKey"), DialectPostgres)
+	require.EqualError(t, err, "message test.BadKey: primary key field id must be a singular scalar or enum field")
+	_, err = MessageToCreateTable(fd.Messages().ByName("TwoKeys"), DialectPostgres)
+	require.EqualError(t, err, "message test.TwoKeys has multiple primary key fields: a and b")
+}
+
+func TestSnakeCase(t *testing.T) {
+	assert.Equal(t, "user_profile", snakeCase("UserProfile"))
+	assert.Equal(t, "http_server", snakeCase("HTTPServer"))
+	assert.Equal(t, "user_id", snakeCase("UserID"))
+	assert.Equal(t, "foo_bar", snakeCase("foo_bar"))
+	assert.Equal(t, "v2_thing", snakeCase("V2Thing"))
+}
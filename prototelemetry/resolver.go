@@ -0,0 +1,235 @@
+// Package prototelemetry provides instrumentation for resolvers, so that the
+// behavior of descriptor resolution in production systems can be monitored.
+package prototelemetry
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+const (
+	labelMethod = "method"
+	labelKind   = "kind"
+
+	kindHit   = "hit"
+	kindMiss  = "miss"
+	kindError = "error"
+)
+
+// NewInstrumentedResolver returns a resolver that delegates to the given inner
+// resolver and records Prometheus metrics for all of its methods. The
+// TypeResolver returned by its AsTypeResolver method is also instrumented: its
+// methods are recorded with a "TypeResolver." prefix, such as
+// "TypeResolver.FindMessageByName". The metrics are registered with the given
+// registerer. If reg is nil, [prometheus.DefaultRegisterer] is used.
+//
+// The following metrics are recorded:
+//   - protoresolve_requests_total: a counter of calls.
+//   - protoresolve_request_duration_seconds: a histogram of the latency of calls.
+//
+// Both metrics have two labels: "method", which is the name of the resolver
+// method that was called, and "kind", which describes the outcome of the call.
+// The kind is "hit" if the requested element was found, "miss" if it was not
+// found (i.e. the error returned by the inner resolver wraps
+// [protoresolve.ErrNotFound]), or "error" for any other error. Methods that
+// cannot fail, like NumFiles and RangeFiles, always have a kind of "hit". The
+// latency of the Range* methods includes the time spent in the given callback.
+//
+// It is safe to create more than one instrumented resolver with the same
+// registerer. The resolvers share the same metrics in that case. This panics
+// if the metrics cannot be registered, such as if the registerer already has
+// different metrics with the same names.
+func NewInstrumentedResolver(inner protoresolve.Resolver, reg prometheus.Registerer) protoresolve.Resolver {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "protoresolve_requests_total",
+		Help: "Number of calls to resolver methods, by method and outcome.",
+	}, []string{labelMethod, labelKind})
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "protoresolve_request_duration_seconds",
+		Help:    "Latency of calls to resolver methods, by method and outcome.",
+		Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+	}, []string{labelMethod, labelKind})
+	return &instrumentedResolver{
+		inner:     inner,
+		requests:  register(reg, requests),
+		durations: register(reg, durations),
+	}
+}
+
+// register registers the given collector with reg. If an identical collector
+// is already registered, the existing one is returned.
+func register[C prometheus.Collector](reg prometheus.Registerer, collector C) C {
+	if err := reg.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return collector
+}
+
+var _ protoresolve.Resolver = (*instrumentedResolver)(nil)
+
+type instrumentedResolver struct {
+	inner     protoresolve.Resolver
+	requests  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+}
+
+func (r *instrumentedResolver) observe(method string, start time.Time, err error) {
+	kind := kindHit
+	if errors.Is(err, protoresolve.ErrNotFound) {
+		kind = kindMiss
+	} else if err != nil {
+		kind = kindError
+	}
+	r.requests.WithLabelValues(method, kind).Inc()
+	r.durations.WithLabelValues(method, kind).Observe(time.Since(start).Seconds())
+}
+
+// FindFileByPath implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	start := time.Now()
+	fd, err := r.inner.FindFileByPath(path)
+	r.observe("FindFileByPath", start, err)
+	return fd, err
+}
+
+// FindDescriptorByName implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	start := time.Now()
+	d, err := r.inner.FindDescriptorByName(name)
+	r.observe("FindDescriptorByName", start, err)
+	return d, err
+}
+
+// FindExtensionByNumber implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionDescriptor, error) {
+	start := time.Now()
+	ext, err := r.inner.FindExtensionByNumber(message, field)
+	r.observe("FindExtensionByNumber", start, err)
+	return ext, err
+}
+
+// FindMessageByURL implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) FindMessageByURL(url string) (protoreflect.MessageDescriptor, error) {
+	start := time.Now()
+	md, err := r.inner.FindMessageByURL(url)
+	r.observe("FindMessageByURL", start, err)
+	return md, err
+}
+
+// FindExtensionByName implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionDescriptor, error) {
+	start := time.Now()
+	ext, err := r.inner.FindExtensionByName(field)
+	r.observe("FindExtensionByName", start, err)
+	return ext, err
+}
+
+// FindMessageByName implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
+	start := time.Now()
+	md, err := r.inner.FindMessageByName(name)
+	r.observe("FindMessageByName", start, err)
+	return md, err
+}
+
+// NumFiles implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) NumFiles() int {
+	start := time.Now()
+	n := r.inner.NumFiles()
+	r.observe("NumFiles", start, nil)
+	return n
+}
+
+// RangeFiles implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) RangeFiles(fn func(protoreflect.FileDescriptor) bool) {
+	start := time.Now()
+	r.inner.RangeFiles(fn)
+	r.observe("RangeFiles", start, nil)
+}
+
+// NumFilesByPackage implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) NumFilesByPackage(name protoreflect.FullName) int {
+	start := time.Now()
+	n := r.inner.NumFilesByPackage(name)
+	r.observe("NumFilesByPackage", start, nil)
+	return n
+}
+
+// RangeFilesByPackage implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) RangeFilesByPackage(name protoreflect.FullName, fn func(protoreflect.FileDescriptor) bool) {
+	start := time.Now()
+	r.inner.RangeFilesByPackage(name, fn)
+	r.observe("RangeFilesByPackage", start, nil)
+}
+
+// RangeExtensionsByMessage implements part of the protoresolve.Resolver interface.
+func (r *instrumentedResolver) RangeExtensionsByMessage(message protoreflect.FullName, fn func(protoreflect.ExtensionDescriptor) bool) {
+	start := time.Now()
+	r.inner.RangeExtensionsByMessage(message, fn)
+	r.observe("RangeExtensionsByMessage", start, nil)
+}
+
+// AsTypeResolver implements part of the protoresolve.Resolver interface. The
+// returned TypeResolver records metrics for all of its methods.
+func (r *instrumentedResolver) AsTypeResolver() protoresolve.TypeResolver {
+	return &instrumentedTypeResolver{inner: r.inner.AsTypeResolver(), res: r}
+}
+
+type instrumentedTypeResolver struct {
+	inner protoresolve.TypeResolver
+	res   *instrumentedResolver
+}
+
+// FindExtensionByName implements part of the protoresolve.TypeResolver interface.
+func (r *instrumentedTypeResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	start := time.Now()
+	xt, err := r.inner.FindExtensionByName(field)
+	r.res.observe("TypeResolver.FindExtensionByName", start, err)
+	return xt, err
+}
+
+// FindExtensionByNumber implements part of the protoresolve.TypeResolver interface.
+func (r *instrumentedTypeResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	start := time.Now()
+	xt, err := r.inner.FindExtensionByNumber(message, field)
+	r.res.observe("TypeResolver.FindExtensionByNumber", start, err)
+	return xt, err
+}
+
+// FindMessageByName implements part of the protoresolve.TypeResolver interface.
+func (r *instrumentedTypeResolver) FindMessageByName(message protoreflect.FullName) (protoreflect.MessageType, error) {
+	start := time.Now()
+	mt, err := r.inner.FindMessageByName(message)
+	r.res.observe("TypeResolver.FindMessageByName", start, err)
+	return mt, err
+}
+
+// FindMessageByURL implements part of the protoresolve.TypeResolver interface.
+func (r *instrumentedTypeResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	start := time.Now()
+	mt, err := r.inner.FindMessageByURL(url)
+	r.res.observe("TypeResolver.FindMessageByURL", start, err)
+	return mt, err
+}
+
+// FindEnumByName implements part of the protoresolve.TypeResolver interface.
+func (r *instrumentedTypeResolver) FindEnumByName(enum protoreflect.FullName) (protoreflect.EnumType, error) {
+	start := time.Now()
+	et, err := r.inner.FindEnumByName(enum)
+	r.res.observe("TypeResolver.FindEnumByName", start, err)
+	return et, err
+}
@@ -0,0 +1,108 @@
+package prototelemetry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/protoresolve"
+)
+
+func TestNewInstrumentedResolver(t *testing.T) {
+	var inner protoresolve.Registry
+	require.NoError(t, inner.RegisterFile(testprotos.File_desc_test1_proto))
+	reg := prometheus.NewRegistry()
+	res := NewInstrumentedResolver(&inner, reg)
+
+	_, err := res.FindDescriptorByName("testprotos.TestMessage")
+	require.NoError(t, err)
+	_, err = res.FindDescriptorByName("testprotos.AnotherTestMessage")
+	require.NoError(t, err)
+	_, err = res.FindDescriptorByName("foo.Bar")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	_, err = res.FindFileByPath("desc_test1.proto")
+	require.NoError(t, err)
+	_, err = res.FindExtensionByNumber("testprotos.AnotherTestMessage", 100)
+	require.NoError(t, err)
+	_, err = res.FindMessageByURL("type.googleapis.com/testprotos.TestMessage.NestedEnum")
+	require.Error(t, err)
+	_, err = res.FindMessageByName("testprotos.TestMessage")
+	require.NoError(t, err)
+	_, err = res.FindExtensionByName("testprotos.NoSuchExtension")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	require.Equal(t, 1, res.NumFiles())
+	res.RangeFiles(func(protoreflect.FileDescriptor) bool { return true })
+	require.Equal(t, 1, res.NumFilesByPackage("testprotos"))
+	res.RangeFilesByPackage("testprotos", func(protoreflect.FileDescriptor) bool { return true })
+	res.RangeExtensionsByMessage("testprotos.AnotherTestMessage", func(protoreflect.ExtensionDescriptor) bool { return true })
+	// lookups through the type resolver are also instrumented
+	types := res.AsTypeResolver()
+	_, err = types.FindMessageByName("testprotos.TestMessage")
+	require.NoError(t, err)
+	_, err = types.FindMessageByURL("type.googleapis.com/foo.Bar")
+	require.ErrorIs(t, err, protoresolve.ErrNotFound)
+	_, err = types.FindExtensionByName("testprotos.xtm")
+	require.NoError(t, err)
+	_, err = types.FindExtensionByNumber("testprotos.AnotherTestMessage", 100)
+	require.NoError(t, err)
+	_, err = types.FindEnumByName("testprotos.TestMessage.NestedEnum")
+	require.NoError(t, err)
+
+	requests, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	expected := map[[2]string]float64{
+		{"FindDescriptorByName", "hit"}:               2,
+		{"FindDescriptorByName", "miss"}:              1,
+		{"FindFileByPath", "hit"}:                     1,
+		{"FindExtensionByNumber", "hit"}:              1,
+		{"FindMessageByURL", "error"}:                 1,
+		{"FindMessageByName", "hit"}:                  1,
+		{"FindExtensionByName", "miss"}:               1,
+		{"NumFiles", "hit"}:                           1,
+		{"RangeFiles", "hit"}:                         1,
+		{"NumFilesByPackage", "hit"}:                  1,
+		{"RangeFilesByPackage", "hit"}:                1,
+		{"RangeExtensionsByMessage", "hit"}:           1,
+		{"TypeResolver.FindMessageByName", "hit"}:     1,
+		{"TypeResolver.FindMessageByURL", "miss"}:     1,
+		{"TypeResolver.FindExtensionByName", "hit"}:   1,
+		{"TypeResolver.FindExtensionByNumber", "hit"}: 1,
+		{"TypeResolver.FindEnumByName", "hit"}:        1,
+	}
+	impl := res.(*instrumentedResolver)
+	for labels, count := range expected {
+		assert.Equal(t, count, testutil.ToFloat64(impl.requests.WithLabelValues(labels[0], labels[1])), "%v", labels)
+	}
+	assert.Equal(t, len(expected), testutil.CollectAndCount(impl.requests))
+	assert.Equal(t, len(expected), testutil.CollectAndCount(impl.durations))
+
+	// a second resolver with the same registerer shares the metrics
+	res2 := NewInstrumentedResolver(&inner, reg)
+	_, err = res2.FindFileByPath("desc_test1.proto")
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), testutil.ToFloat64(impl.requests.WithLabelValues("FindFileByPath", "hit")))
+}
+
+func TestNewInstrumentedResolver_Error(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	res := NewInstrumentedResolver(errResolver{protoresolve.GlobalDescriptors}, reg)
+	_, err := res.FindFileByPath("foo.proto")
+	require.EqualError(t, err, "boom")
+	impl := res.(*instrumentedResolver)
+	assert.Equal(t, float64(1), testutil.ToFloat64(impl.requests.WithLabelValues("FindFileByPath", "error")))
+}
+
+type errResolver struct {
+	protoresolve.Resolver
+}
+
+func (errResolver) FindFileByPath(string) (protoreflect.FileDescriptor, error) {
+	return nil, errors.New("boom")
+}
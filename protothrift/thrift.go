@@ -0,0 +1,246 @@
+// Package protothrift generates Apache Thrift IDL from protobuf descriptors, to
+// help with interoperability between protobuf and Thrift systems.
+package protothrift
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FileToThrift returns the contents of a Thrift IDL file that corresponds to
+// the given protobuf file. Messages become Thrift structs and enums become
+// Thrift enums. Since Thrift does not support nested types, nested messages
+// and enums are declared at the top level, with the names of their enclosing
+// messages as a prefix, separated by underscores (e.g. "Outer_Inner"). Services
+// become Thrift services, each method accepting a single argument named
+// "request".
+//
+// Imports become Thrift includes, with each ".proto" file name changed to have
+// a ".thrift" extension. Thrift IDL is not generated for imported files.
+//
+// Some protobuf constructs have no direct equivalent in Thrift, and a comment
+// noting the limitation is generated for them:
+//   - Fields in a oneof are generated as optional fields, with a comment that
+//     at most one of them may be set.
+//   - Map fields with non-string keys are omitted.
+//   - Fields with numbers too large for a Thrift field ID are omitted.
+//   - Streaming methods are omitted.
+//
+// Thrift has no unsigned integer or single-precision floating point types, so
+// unsigned integers are represented using signed integers (32-bit unsigned
+// values use 64-bit signed integers) and floats are represented as doubles.
+func FileToThrift(fd protoreflect.FileDescriptor) ([]byte, error) {
+	p := printer{file: fd}
+	p.printFile()
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.buf.Bytes(), nil
+}
+
+type printer struct {
+	file protoreflect.FileDescriptor
+	buf  bytes.Buffer
+	err  error
+}
+
+func (p *printer) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(&p.buf, format, args...)
+}
+
+func (p *printer) printComments(d protoreflect.Descriptor, indent string) {
+	loc := p.file.SourceLocations().ByDescriptor(d)
+	comments := strings.TrimSuffix(loc.LeadingComments, "\n")
+	if comments == "" {
+		return
+	}
+	for _, line := range strings.Split(comments, "\n") {
+		p.printf("%s//%s\n", indent, strings.TrimRight(line, " \t"))
+	}
+}
+
+func (p *printer) printFile() {
+	p.printf("// Code generated from %s. DO NOT EDIT.\n", p.file.Path())
+	if pkg := p.file.Package(); pkg != "" {
+		p.printf("\nnamespace * %s\n", pkg)
+	}
+	imports := p.file.Imports()
+	if imports.Len() > 0 {
+		p.printf("\n")
+		for i, length := 0, imports.Len(); i < length; i++ {
+			p.printf("include %q\n", thriftFileName(imports.Get(i).Path()))
+		}
+	}
+
+	// Enums are declared first so they are defined before any use.
+	var msgs []protoreflect.MessageDescriptor
+	var enums []protoreflect.EnumDescriptor
+	collectTypes(p.file, &msgs, &enums)
+	for _, ed := range enums {
+		p.printf("\n")
+		p.printEnum(ed)
+	}
+	for _, md := range msgs {
+		p.printf("\n")
+		p.printStruct(md)
+	}
+	svcs := p.file.Services()
+	for i, length := 0, svcs.Len(); i < length; i++ {
+		p.printf("\n")
+		p.printService(svcs.Get(i))
+	}
+}
+
+type typeContainer interface {
+	Messages() protoreflect.MessageDescriptors
+	Enums() protoreflect.EnumDescriptors
+}
+
+func collectTypes(container typeContainer, msgs *[]protoreflect.MessageDescriptor, enums *[]protoreflect.EnumDescriptor) {
+	for i, length := 0, container.Enums().Len(); i < length; i++ {
+		*enums = append(*enums, container.Enums().Get(i))
+	}
+	for i, length := 0, container.Messages().Len(); i < length; i++ {
+		md := container.Messages().Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+		*msgs = append(*msgs, md)
+		collectTypes(md, msgs, enums)
+	}
+}
+
+func (p *printer) printEnum(ed protoreflect.EnumDescriptor) {
+	p.printComments(ed, "")
+	p.printf("enum %s {\n", localName(ed))
+	vals := ed.Values()
+	for i, length := 0, vals.Len(); i < length; i++ {
+		val := vals.Get(i)
+		p.printComments(val, "  ")
+		p.printf("  %s = %d,\n", val.Name(), val.Number())
+	}
+	p.printf("}\n")
+}
+
+func (p *printer) printStruct(md protoreflect.MessageDescriptor) {
+	p.printComments(md, "")
+	p.printf("struct %s {\n", localName(md))
+	var currentOneof protoreflect.OneofDescriptor
+	fields := md.Fields()
+	for i, length := 0, fields.Len(); i < length; i++ {
+		fld := fields.Get(i)
+		if ood := fld.ContainingOneof(); ood != nil && !ood.IsSynthetic() && ood != currentOneof {
+			p.printf("  // oneof %s: Thrift structs cannot express oneofs, so at most one of\n", ood.Name())
+			p.printf("  // the following fields should be set: %s\n", oneofFieldNames(ood))
+		}
+		currentOneof = fld.ContainingOneof()
+		p.printField(fld)
+	}
+	p.printf("}\n")
+}
+
+func oneofFieldNames(ood protoreflect.OneofDescriptor) string {
+	fields := ood.Fields()
+	names := make([]string, fields.Len())
+	for i := range names {
+		names[i] = string(fields.Get(i).Name())
+	}
+	return strings.Join(names, ", ")
+}
+
+func (p *printer) printField(fld protoreflect.FieldDescriptor) {
+	if fld.Number() > math.MaxInt16 {
+		p.printf("  // field %s: number %d is too large for a Thrift field ID, so it is omitted\n", fld.Name(), fld.Number())
+		return
+	}
+	if fld.IsMap() && fld.MapKey().Kind() != protoreflect.StringKind {
+		p.printf("  // field %s: maps with %s keys are not supported, so it is omitted\n", fld.Name(), fld.MapKey().Kind())
+		return
+	}
+	p.printComments(fld, "  ")
+	requiredness := "optional"
+	if fld.Cardinality() == protoreflect.Required {
+		requiredness = "required"
+	}
+	p.printf("  %d: %s %s %s,\n", fld.Number(), requiredness, p.fieldType(fld), fld.Name())
+}
+
+func (p *printer) fieldType(fld protoreflect.FieldDescriptor) string {
+	switch {
+	case fld.IsMap():
+		return fmt.Sprintf("map<string, %s>", p.singularType(fld.MapValue()))
+	case fld.IsList():
+		return fmt.Sprintf("list<%s>", p.singularType(fld))
+	default:
+		return p.singularType(fld)
+	}
+}
+
+func (p *printer) singularType(fld protoreflect.FieldDescriptor) string {
+	switch fld.Kind() {
+	case protoreflect.BoolKind:
+		return "bool"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "i32"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "i64"
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return "double"
+	case protoreflect.StringKind:
+		return "string"
+	case protoreflect.BytesKind:
+		return "binary"
+	case protoreflect.EnumKind:
+		return p.typeName(fld.Enum())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return p.typeName(fld.Message())
+	default:
+		p.err = fmt.Errorf("field %s has unknown kind %v", fld.FullName(), fld.Kind())
+		return ""
+	}
+}
+
+func (p *printer) printService(sd protoreflect.ServiceDescriptor) {
+	p.printComments(sd, "")
+	p.printf("service %s {\n", sd.Name())
+	methods := sd.Methods()
+	for i, length := 0, methods.Len(); i < length; i++ {
+		md := methods.Get(i)
+		if md.IsStreamingClient() || md.IsStreamingServer() {
+			p.printf("  // method %s: streaming methods are not supported, so it is omitted\n", md.Name())
+			continue
+		}
+		p.printComments(md, "  ")
+		p.printf("  %s %s(1: %s request),\n", p.typeName(md.Output()), md.Name(), p.typeName(md.Input()))
+	}
+	p.printf("}\n")
+}
+
+// typeName returns the name used to refer to the given message or enum
+// from the file being generated.
+func (p *printer) typeName(d protoreflect.Descriptor) string {
+	name := localName(d)
+	if d.ParentFile().Path() == p.file.Path() {
+		return name
+	}
+	// types in included files are qualified with the included file's base name
+	return strings.TrimSuffix(path.Base(thriftFileName(d.ParentFile().Path())), ".thrift") + "." + name
+}
+
+// localName returns the Thrift name for the given message or enum, which
+// includes the names of any enclosing messages.
+func localName(d protoreflect.Descriptor) string {
+	name := strings.TrimPrefix(string(d.FullName()), string(d.ParentFile().Package()))
+	return strings.ReplaceAll(strings.TrimPrefix(name, "."), ".", "_")
+}
+
+func thriftFileName(protoPath string) string {
+	return strings.TrimSuffix(protoPath, ".proto") + ".thrift"
+}
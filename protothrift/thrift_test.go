@@ -0,0 +1,100 @@
+package protothrift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileToThrift(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"other/common.proto": `
+					syntax = "proto3";
+					package other;
+					message Empty {}
+				`,
+				"test.proto": `
+					syntax = "proto2";
+					package foo.bar;
+					import "other/common.proto";
+					// A message.
+					message Msg {
+						required int32 id = 1;
+						optional string name = 2;
+						repeated bytes blobs = 3;
+						map<string, Inner> inners = 4;
+						map<int32, string> by_id = 5;
+						optional uint32 small = 6;
+						optional float ratio = 7;
+						oneof choice {
+							string text = 8;
+							other.Empty nothing = 9;
+						}
+						optional Inner.Kind kind = 10;
+						optional bool big_tag = 100000;
+						message Inner {
+							enum Kind {
+								KIND_A = 1;
+								KIND_B = 2;
+							}
+						}
+					}
+					service Svc {
+						// Does a thing.
+						rpc Do(Msg) returns (other.Empty);
+						rpc Stream(Msg) returns (stream other.Empty);
+					}
+				`,
+			}),
+		},
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+
+	data, err := FileToThrift(files[0])
+	require.NoError(t, err)
+	expected := `// Code generated from test.proto. DO NOT EDIT.
+
+namespace * foo.bar
+
+include "other/common.thrift"
+
+enum Msg_Inner_Kind {
+  KIND_A = 1,
+  KIND_B = 2,
+}
+
+// A message.
+struct Msg {
+  1: required i32 id,
+  2: optional string name,
+  3: optional list<binary> blobs,
+  4: optional map<string, Msg_Inner> inners,
+  // field by_id: maps with int32 keys are not supported, so it is omitted
+  6: optional i64 small,
+  7: optional double ratio,
+  // oneof choice: Thrift structs cannot express oneofs, so at most one of
+  // the following fields should be set: text, nothing
+  8: optional string text,
+  9: optional common.Empty nothing,
+  10: optional Msg_Inner_Kind kind,
+  // field big_tag: number 100000 is too large for a Thrift field ID, so it is omitted
+}
+
+struct Msg_Inner {
+}
+
+service Svc {
+  // Does a thing.
+  common.Empty Do(1: Msg request),
+  // method Stream: streaming methods are not supported, so it is omitted
+}
+`
+	assert.Equal(t, expected, string(data))
+}
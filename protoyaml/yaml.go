@@ -0,0 +1,180 @@
+// Package protoyaml marshals and unmarshals protobuf messages to and from YAML.
+// The YAML format is the same as the JSON format defined by the protobuf spec,
+// just with YAML syntax instead of JSON syntax. So it uses the same field names
+// and value representations as the [protojson] package. For example, bytes
+// fields are base64-encoded strings, and google.protobuf.Timestamp and
+// google.protobuf.Duration values are strings in their canonical formats.
+//
+// This works with both generated messages and dynamic messages.
+package protoyaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Marshal returns the YAML encoding of the given message. Fields appear in the
+// same order and with the same representation as in [protojson.Marshal].
+func Marshal(msg proto.Message) ([]byte, error) {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is a subset of YAML, so the JSON output can be parsed as YAML. This
+	// also preserves the order of fields, unlike decoding into a map.
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	resetStyle(&node)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal parses the given YAML data into msg. The message is reset before
+// the data is unmarshalled into it. The data must be in the format produced by
+// Marshal, but it may use any YAML syntax, such as flow style, anchors and
+// aliases, and non-decimal integers.
+func Unmarshal(data []byte, msg proto.Message) error {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if node.Kind == 0 {
+		// empty document
+		buf.WriteString("{}")
+	} else if err := writeJSON(&buf, &node); err != nil {
+		return err
+	}
+	return protojson.Unmarshal(buf.Bytes(), msg)
+}
+
+// resetStyle clears the styles from the given node and its descendants, which
+// were all parsed from JSON, so that they are encoded in block style.
+func resetStyle(node *yaml.Node) {
+	// For strings, this lets the encoder decide whether quotes are needed.
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+func writeJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return writeJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key := node.Content[i]
+			if key.Kind == yaml.AliasNode {
+				key = key.Alias
+			}
+			if key.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: mapping key must be a scalar", key.Line)
+			}
+			if err := writeJSONString(buf, key.Value); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeJSON(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, child := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case yaml.ScalarNode:
+		return writeJSONScalar(buf, node)
+	default:
+		return fmt.Errorf("line %d: unexpected YAML node kind %d", node.Line, node.Kind)
+	}
+}
+
+func writeJSONScalar(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.ShortTag() {
+	case "!!null":
+		buf.WriteString("null")
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return err
+		}
+		buf.WriteString(strconv.FormatBool(b))
+	case "!!int":
+		// Decode to normalize forms like 0x1F and 1_000 into plain decimal.
+		var i int64
+		if err := node.Decode(&i); err == nil {
+			buf.WriteString(strconv.FormatInt(i, 10))
+			return nil
+		}
+		var u uint64
+		if err := node.Decode(&u); err != nil {
+			return err
+		}
+		buf.WriteString(strconv.FormatUint(u, 10))
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return err
+		}
+		switch {
+		case math.IsNaN(f):
+			buf.WriteString(`"NaN"`)
+		case math.IsInf(f, 1):
+			buf.WriteString(`"Infinity"`)
+		case math.IsInf(f, -1):
+			buf.WriteString(`"-Infinity"`)
+		default:
+			buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	default:
+		return writeJSONString(buf, node.Value)
+	}
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
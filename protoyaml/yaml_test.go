@@ -0,0 +1,99 @@
+package protoyaml
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+)
+
+func TestMarshal(t *testing.T) {
+	msg := &testprotos.TestRequest{
+		Foo:   []testprotos.Proto3Enum{testprotos.Proto3Enum_VALUE1, testprotos.Proto3Enum_VALUE_NEG1},
+		Bar:   "123",
+		Flags: map[string]bool{"a": true},
+		Snafu: &testprotos.TestMessage_NestedMessage_AnotherNestedMessage{
+			Yanm: []*testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{
+				{Foo: proto.String("foo: bar"), Bar: proto.Int32(-42), Baz: []byte("hello")},
+			},
+		},
+	}
+	data, err := Marshal(msg)
+	require.NoError(t, err)
+	require.Equal(t, `foo:
+  - VALUE1
+  - VALUE_NEG1
+bar: "123"
+snafu:
+  yanm:
+    - foo: 'foo: bar'
+      bar: -42
+      baz: aGVsbG8=
+flags:
+  a: true
+`, string(data))
+
+	// round-trip through both generated and dynamic messages
+	var roundTripped testprotos.TestRequest
+	require.NoError(t, Unmarshal(data, &roundTripped))
+	require.True(t, proto.Equal(msg, &roundTripped))
+	dyn := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+	require.NoError(t, Unmarshal(data, dyn))
+	require.True(t, proto.Equal(msg, dyn))
+	dynData, err := Marshal(dyn)
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(dynData))
+}
+
+func TestMarshal_WellKnownTypes(t *testing.T) {
+	msg := &testprotos.TestWellKnownTypes{
+		StartTime: &timestamppb.Timestamp{Seconds: 1700000000, Nanos: 500000000},
+		Elapsed:   &durationpb.Duration{Seconds: 90},
+	}
+	data, err := Marshal(msg)
+	require.NoError(t, err)
+	require.Equal(t, `startTime: "2023-11-14T22:13:20.500Z"
+elapsed: 90s
+`, string(data))
+	var roundTripped testprotos.TestWellKnownTypes
+	require.NoError(t, Unmarshal(data, &roundTripped))
+	require.True(t, proto.Equal(msg, &roundTripped))
+}
+
+func TestUnmarshal(t *testing.T) {
+	var msg testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage
+	require.NoError(t, Unmarshal([]byte(`
+foo: &name abc
+bar: 0x1F
+dne: VALUE2
+nm: {}
+tm: {ne: [1, VALUE2]}
+`), &msg))
+	expected := &testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage{
+		Foo: proto.String("abc"),
+		Bar: proto.Int32(31),
+		Dne: testprotos.TestMessage_NestedMessage_AnotherNestedMessage_YetAnotherNestedMessage_VALUE2.Enum(),
+		Nm:  &testprotos.TestMessage_NestedMessage{},
+		Tm: &testprotos.TestMessage{
+			Ne: []testprotos.TestMessage_NestedEnum{testprotos.TestMessage_VALUE1, testprotos.TestMessage_VALUE2},
+		},
+	}
+	require.True(t, proto.Equal(expected, &msg))
+
+	var val wrapperspb.DoubleValue
+	require.NoError(t, Unmarshal([]byte(`.inf`), &val))
+	require.True(t, math.IsInf(val.Value, 1))
+
+	var empty testprotos.TestRequest
+	require.NoError(t, Unmarshal(nil, &empty))
+	require.Equal(t, 0, proto.Size(&empty))
+
+	require.ErrorContains(t, Unmarshal([]byte(`nope: 1`), &msg), `unknown field "nope"`)
+}
@@ -1,12 +1,51 @@
 // Command protoc-gen-gosrcinfo is a protoc plugin. It emits Go code, into files
 // named "<file>.pb.srcinfo.go". These source files include source code info for
 // processed proto files and register that info with the srcinfo package.
+//
+// The plugin accepts the following options:
+//
+//	combine=true
+//
+// When set, instead of one output file per proto file, the plugin emits a
+// single file named "srcinfo.combined.pb.srcinfo.go" for each Go package. The
+// combined file is placed in the output directory of the first proto file in
+// that Go package.
+//
+//	skip_empty=false
+//
+// By default, no output is emitted for proto files that have no source code
+// info. When set to false, a file (with no registration) is emitted for them
+// anyway. This is useful with build systems that require that the outputs of
+// the plugin be known in advance.
+//
+//	version=true
+//
+// When set, each output file includes a comment with the version of the plugin
+// that generated it. The version is set at build time, via a linker flag:
+//
+//	go build -ldflags "-X main.Version=1.2.3" ./sourceinfo/cmd/protoc-gen-gosrcinfo
+//
+// If the plugin was built without a version, this option has no effect.
+//
+//	mode=binary
+//
+// When set, instead of Go code, the plugin emits files named
+// "<file>.pb.srcinfo.bin", which contain the serialized form of each file's
+// google.protobuf.SourceCodeInfo message. This allows source code info to be
+// shipped separately from a program's binary. Such files can be loaded at
+// runtime and then registered using the RegisterSourceInfoFromBytes function in
+// the sourceinfo package. This option cannot be used with the combine option.
+// The default mode is "go".
 package main
 
 import (
 	"bytes"
 	"compress/gzip"
+	"errors"
+	"flag"
 	"fmt"
+	"path"
+	"strings"
 
 	"google.golang.org/protobuf/compiler/protogen"
 	"google.golang.org/protobuf/proto"
@@ -14,18 +53,53 @@ import (
 	"google.golang.org/protobuf/types/pluginpb"
 )
 
+const combinedFileName = "srcinfo.combined.pb.srcinfo.go"
+
+// Version is the version of this plugin. It is empty unless set at build time
+// via "-ldflags -X main.Version=...".
+var Version = ""
+
+type options struct {
+	combine   bool
+	skipEmpty bool
+	version   bool
+	mode      string
+}
+
 func main() {
-	protogen.Options{}.Run(genSourceInfo)
+	var flags flag.FlagSet
+	var opts options
+	flags.BoolVar(&opts.combine, "combine", false, "emit a single file per Go package")
+	flags.BoolVar(&opts.skipEmpty, "skip_empty", true, "emit no output for files without source code info")
+	flags.BoolVar(&opts.version, "version", false, "include the plugin version in output files")
+	flags.StringVar(&opts.mode, "mode", "go", `output mode: "go" or "binary"`)
+	protogen.Options{ParamFunc: flags.Set}.Run(func(plugin *protogen.Plugin) error {
+		return genSourceInfo(plugin, opts)
+	})
 }
 
-func genSourceInfo(plugin *protogen.Plugin) error {
+func genSourceInfo(plugin *protogen.Plugin, opts options) error {
 	plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL |
 		pluginpb.CodeGeneratorResponse_FEATURE_SUPPORTS_EDITIONS)
 	plugin.SupportedEditionsMinimum = descriptorpb.Edition_EDITION_2023
 	plugin.SupportedEditionsMaximum = descriptorpb.Edition_EDITION_2023
+	generate := generateSourceInfo
+	switch opts.mode {
+	case "go":
+	case "binary":
+		if opts.combine {
+			return errors.New("combine option cannot be used with mode=binary")
+		}
+		generate = generateBinarySourceInfo
+	default:
+		return fmt.Errorf("invalid mode %q: must be \"go\" or \"binary\"", opts.mode)
+	}
+	if opts.combine {
+		return generateCombinedSourceInfo(plugin, opts)
+	}
 	for _, f := range plugin.Files {
 		if f.Generate {
-			if err := generateSourceInfo(f, plugin); err != nil {
+			if err := generate(f, plugin, opts); err != nil {
 				return fmt.Errorf("%s: %v", f.Desc.Path(), err)
 			}
 		}
@@ -33,21 +107,131 @@ func genSourceInfo(plugin *protogen.Plugin) error {
 	return nil
 }
 
-func generateSourceInfo(f *protogen.File, plugin *protogen.Plugin) error {
+func generateBinarySourceInfo(f *protogen.File, plugin *protogen.Plugin, opts options) error {
 	si := f.Proto.GetSourceCodeInfo()
-	if len(si.GetLocation()) == 0 {
+	if len(si.GetLocation()) == 0 && opts.skipEmpty {
+		return nil
+	}
+	siBytes, err := proto.Marshal(si)
+	if err != nil {
+		return fmt.Errorf("failed to serialize source code info: %w", err)
+	}
+	out := plugin.NewGeneratedFile(f.GeneratedFilenamePrefix+".pb.srcinfo.bin", f.GoImportPath)
+	_, err = out.Write(siBytes)
+	return err
+}
+
+func generateSourceInfo(f *protogen.File, plugin *protogen.Plugin, opts options) error {
+	si := f.Proto.GetSourceCodeInfo()
+	if len(si.GetLocation()) == 0 && opts.skipEmpty {
 		return nil
 	}
 	out := plugin.NewGeneratedFile(f.GeneratedFilenamePrefix+".pb.srcinfo.go", f.GoImportPath)
-	out.P("// Code generated by protoc-gen-gosrcinfo. DO NOT EDIT.")
-	out.P("// source: ", f.Desc.Path())
-	out.P()
+	writeHeader(out, opts, f)
 	out.P("package ", f.GoPackageName)
+	if len(si.GetLocation()) == 0 {
+		// nothing to register
+		return nil
+	}
+	encodedBytes, err := encodeSourceInfo(si)
+	if err != nil {
+		return err
+	}
 	out.P()
+	out.P("func init() {")
+	writeRegistration(out, f, encodedBytes)
+	out.P("}")
+	return nil
+}
 
+func generateCombinedSourceInfo(plugin *protogen.Plugin, opts options) error {
+	var pkgs []protogen.GoImportPath
+	firstByPkg := map[protogen.GoImportPath]*protogen.File{}
+	filesByPkg := map[protogen.GoImportPath][]*protogen.File{}
+	for _, f := range plugin.Files {
+		if !f.Generate {
+			continue
+		}
+		if _, ok := firstByPkg[f.GoImportPath]; !ok {
+			pkgs = append(pkgs, f.GoImportPath)
+			firstByPkg[f.GoImportPath] = f
+		}
+		if len(f.Proto.GetSourceCodeInfo().GetLocation()) > 0 {
+			filesByPkg[f.GoImportPath] = append(filesByPkg[f.GoImportPath], f)
+		}
+	}
+	for _, pkg := range pkgs {
+		files := filesByPkg[pkg]
+		if len(files) == 0 && opts.skipEmpty {
+			continue
+		}
+		first := firstByPkg[pkg]
+		out := plugin.NewGeneratedFile(path.Join(path.Dir(first.GeneratedFilenamePrefix), combinedFileName), pkg)
+		writeHeader(out, opts, files...)
+		out.P("package ", first.GoPackageName)
+		if len(files) == 0 {
+			// nothing to register
+			continue
+		}
+		funcNames := registrationFuncNames(files)
+		out.P()
+		out.P("func init() {")
+		for _, name := range funcNames {
+			out.P("  ", name, "()")
+		}
+		out.P("}")
+		for i, f := range files {
+			encodedBytes, err := encodeSourceInfo(f.Proto.GetSourceCodeInfo())
+			if err != nil {
+				return fmt.Errorf("%s: %v", f.Desc.Path(), err)
+			}
+			out.P()
+			out.P("func ", funcNames[i], "() {")
+			writeRegistration(out, f, encodedBytes)
+			out.P("}")
+		}
+	}
+	return nil
+}
+
+func writeHeader(out *protogen.GeneratedFile, opts options, sources ...*protogen.File) {
+	out.P("// Code generated by protoc-gen-gosrcinfo. DO NOT EDIT.")
+	if opts.version && Version != "" {
+		out.P("// generated by protoc-gen-gosrcinfo v", strings.TrimPrefix(Version, "v"))
+	}
+	for _, f := range sources {
+		out.P("// source: ", f.Desc.Path())
+	}
+	out.P()
+}
+
+// registrationFuncNames returns the names of the functions that register the
+// source info for the given files. The names are derived from the files' paths,
+// so different paths can result in the same name (e.g. "a/b.proto" and
+// "a_b.proto"). So a numeric suffix is added when necessary to make each name
+// unique.
+func registrationFuncNames(files []*protogen.File) []string {
+	names := make([]string, len(files))
+	used := map[string]struct{}{}
+	for i, f := range files {
+		base := "registerSourceInfo_" + strings.TrimPrefix(f.GoDescriptorIdent.GoName, "File_")
+		name := base
+		for n := 2; ; n++ {
+			if _, ok := used[name]; !ok {
+				break
+			}
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[name] = struct{}{}
+		names[i] = name
+	}
+	return names
+}
+
+func encodeSourceInfo(si *descriptorpb.SourceCodeInfo) ([]byte, error) {
 	siBytes, err := proto.Marshal(si)
 	if err != nil {
-		return fmt.Errorf("failed to serialize source code info: %w", err)
+		return nil, fmt.Errorf("failed to serialize source code info: %w", err)
 	}
 	// Source code info has lots of repeated sequences of bytes in the 'path' field
 	// of locations, and the comments tend to be text that is reasonably well
@@ -55,17 +239,18 @@ func generateSourceInfo(f *protogen.File, plugin *protogen.Plugin) error {
 	var encodedBuf bytes.Buffer
 	zipWriter := gzip.NewWriter(&encodedBuf)
 	if _, err := zipWriter.Write(siBytes); err != nil {
-		return fmt.Errorf("failed to compress source code info: %w", err)
+		return nil, fmt.Errorf("failed to compress source code info: %w", err)
 	}
 	if err := zipWriter.Close(); err != nil {
-		return fmt.Errorf("failed to compress source code info: %w", err)
+		return nil, fmt.Errorf("failed to compress source code info: %w", err)
 	}
+	return encodedBuf.Bytes(), nil
+}
 
+func writeRegistration(out *protogen.GeneratedFile, f *protogen.File, encodedBytes []byte) {
 	srcInfoPkg := protogen.GoImportPath("github.com/jhump/protoreflect/v2/sourceinfo")
 
-	out.P("func init() {")
 	out.P("  srcInfo := []byte{")
-	encodedBytes := encodedBuf.Bytes()
 	var buf bytes.Buffer
 	for len(encodedBytes) > 0 {
 		var chunk []byte
@@ -84,6 +269,4 @@ func generateSourceInfo(f *protogen.File, plugin *protogen.Plugin) error {
 	}
 	out.P("  }")
 	out.P("  ", srcInfoPkg.Ident("Register"), "(", fmt.Sprintf("%q", f.Desc.Path()), ", srcInfo)")
-	out.P("}")
-	return nil
 }
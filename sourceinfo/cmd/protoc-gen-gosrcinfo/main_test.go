@@ -0,0 +1,257 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func TestCombinedNameCollisions(t *testing.T) {
+	// "a/b.proto" and "a_b.proto" both have Go descriptor identifier "File_a_b_proto"
+	var files []*descriptorpb.FileDescriptorProto
+	for _, name := range []string{"a/b.proto", "a_b.proto", "a.b.proto"} {
+		files = append(files, &descriptorpb.FileDescriptorProto{
+			Name:    proto.String(name),
+			Package: proto.String("test"),
+			Options: &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test")},
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{
+				Location: []*descriptorpb.SourceCodeInfo_Location{{Path: []int32{}, Span: []int32{0, 0, 0}}},
+			},
+		})
+	}
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"a/b.proto", "a_b.proto", "a.b.proto"},
+		ProtoFile:      files,
+	}
+	plugin, err := protogen.Options{}.New(req)
+	require.NoError(t, err)
+	require.NoError(t, genSourceInfo(plugin, options{combine: true, skipEmpty: true, mode: "go"}))
+	resp := plugin.Response()
+	require.Empty(t, resp.GetError())
+	require.Len(t, resp.File, 1)
+
+	assert.Equal(t,
+		[]string{"registerSourceInfo_a_b_proto", "registerSourceInfo_a_b_proto_2", "registerSourceInfo_a_b_proto_3"},
+		registrationFuncNames(plugin.Files))
+
+	// generated code must be valid, with no duplicate function declarations
+	parsed, err := parser.ParseFile(token.NewFileSet(), resp.File[0].GetName(), resp.File[0].GetContent(), 0)
+	require.NoError(t, err)
+	funcs := map[string]int{}
+	for _, decl := range parsed.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name != "init" {
+			funcs[fn.Name.Name]++
+		}
+	}
+	assert.Equal(t, map[string]int{
+		"registerSourceInfo_a_b_proto":   1,
+		"registerSourceInfo_a_b_proto_2": 1,
+		"registerSourceInfo_a_b_proto_3": 1,
+	}, funcs)
+}
+
+func TestBinaryMode(t *testing.T) {
+	srcInfo := &descriptorpb.SourceCodeInfo{
+		Location: []*descriptorpb.SourceCodeInfo_Location{
+			{Path: []int32{}, Span: []int32{0, 0, 10}, LeadingComments: proto.String(" comment\n")},
+		},
+	}
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"a/b.proto", "a/c.proto"},
+		ProtoFile: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:           proto.String("a/b.proto"),
+				Package:        proto.String("test"),
+				Options:        &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test")},
+				SourceCodeInfo: srcInfo,
+			},
+			{
+				Name:    proto.String("a/c.proto"),
+				Package: proto.String("test"),
+				Options: &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test")},
+			},
+		},
+	}
+	plugin, err := protogen.Options{}.New(req)
+	require.NoError(t, err)
+	require.NoError(t, genSourceInfo(plugin, options{skipEmpty: true, mode: "binary"}))
+	resp := plugin.Response()
+	require.Empty(t, resp.GetError())
+	require.Len(t, resp.File, 1)
+	assert.Equal(t, "example.com/test/b.pb.srcinfo.bin", resp.File[0].GetName())
+	var got descriptorpb.SourceCodeInfo
+	require.NoError(t, proto.Unmarshal([]byte(resp.File[0].GetContent()), &got))
+	assert.True(t, proto.Equal(srcInfo, &got))
+
+	plugin, err = protogen.Options{}.New(req)
+	require.NoError(t, err)
+	err = genSourceInfo(plugin, options{combine: true, skipEmpty: true, mode: "binary"})
+	require.EqualError(t, err, "combine option cannot be used with mode=binary")
+	err = genSourceInfo(plugin, options{mode: "text"})
+	require.EqualError(t, err, `invalid mode "text": must be "go" or "binary"`)
+}
+
+func TestCombined(t *testing.T) {
+	srcInfo := &descriptorpb.SourceCodeInfo{
+		Location: []*descriptorpb.SourceCodeInfo_Location{{Path: []int32{}, Span: []int32{0, 0, 0}}},
+	}
+	names := []string{"a/one.proto", "a/two.proto", "a/three.proto"}
+	var files []*descriptorpb.FileDescriptorProto
+	for _, name := range names {
+		files = append(files, &descriptorpb.FileDescriptorProto{
+			Name:           proto.String(name),
+			Package:        proto.String("test"),
+			Options:        &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test")},
+			SourceCodeInfo: srcInfo,
+		})
+	}
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: names,
+		ProtoFile:      files,
+	}
+	plugin, err := protogen.Options{}.New(req)
+	require.NoError(t, err)
+	require.NoError(t, genSourceInfo(plugin, options{combine: true, skipEmpty: true, mode: "go"}))
+	resp := plugin.Response()
+	require.Empty(t, resp.GetError())
+	require.Len(t, resp.File, 1)
+	assert.Equal(t, "example.com/test/"+combinedFileName, resp.File[0].GetName())
+
+	parsed, err := parser.ParseFile(token.NewFileSet(), resp.File[0].GetName(), resp.File[0].GetContent(), 0)
+	require.NoError(t, err)
+	var inits []*ast.FuncDecl
+	registered := map[string]string{}
+	for _, decl := range parsed.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if fn.Name.Name == "init" {
+			inits = append(inits, fn)
+			continue
+		}
+		// each registration function registers a single file
+		var paths []string
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Register" {
+					paths = append(paths, call.Args[0].(*ast.BasicLit).Value)
+				}
+			}
+			return true
+		})
+		require.Len(t, paths, 1, "function %s", fn.Name.Name)
+		registered[fn.Name.Name] = paths[0]
+	}
+	assert.Equal(t, map[string]string{
+		"registerSourceInfo_a_one_proto":   `"a/one.proto"`,
+		"registerSourceInfo_a_two_proto":   `"a/two.proto"`,
+		"registerSourceInfo_a_three_proto": `"a/three.proto"`,
+	}, registered)
+
+	// a single init function calls all of the registration functions
+	require.Len(t, inits, 1)
+	var called []string
+	for _, stmt := range inits[0].Body.List {
+		call := stmt.(*ast.ExprStmt).X.(*ast.CallExpr)
+		called = append(called, call.Fun.(*ast.Ident).Name)
+	}
+	assert.Equal(t, []string{"registerSourceInfo_a_one_proto", "registerSourceInfo_a_two_proto", "registerSourceInfo_a_three_proto"}, called)
+}
+
+func TestSkipEmpty(t *testing.T) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"a/b.proto"},
+		ProtoFile: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("a/b.proto"),
+			Package: proto.String("test"),
+			Options: &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test")},
+		}},
+	}
+	testCases := []struct {
+		name     string
+		opts     options
+		expected string
+	}{
+		{name: "default", opts: options{skipEmpty: true, mode: "go"}},
+		{name: "skip_empty=false", opts: options{mode: "go"}, expected: "example.com/test/b.pb.srcinfo.go"},
+		{name: "combine", opts: options{combine: true, skipEmpty: true, mode: "go"}},
+		{name: "combine, skip_empty=false", opts: options{combine: true, mode: "go"}, expected: "example.com/test/" + combinedFileName},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plugin, err := protogen.Options{}.New(req)
+			require.NoError(t, err)
+			require.NoError(t, genSourceInfo(plugin, tc.opts))
+			resp := plugin.Response()
+			require.Empty(t, resp.GetError())
+			if tc.expected == "" {
+				require.Empty(t, resp.File)
+				return
+			}
+			require.Len(t, resp.File, 1)
+			assert.Equal(t, tc.expected, resp.File[0].GetName())
+			// file is valid, but has nothing to register
+			parsed, err := parser.ParseFile(token.NewFileSet(), resp.File[0].GetName(), resp.File[0].GetContent(), 0)
+			require.NoError(t, err)
+			assert.Equal(t, "test", parsed.Name.Name)
+			assert.Empty(t, parsed.Decls)
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{"a/b.proto"},
+		ProtoFile: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("a/b.proto"),
+			Package: proto.String("test"),
+			Options: &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test")},
+			SourceCodeInfo: &descriptorpb.SourceCodeInfo{
+				Location: []*descriptorpb.SourceCodeInfo_Location{{Path: []int32{}, Span: []int32{0, 0, 0}}},
+			},
+		}},
+	}
+	testCases := []struct {
+		name     string
+		version  string
+		option   bool
+		expected string
+	}{
+		{name: "version", version: "1.2.3", option: true, expected: "// generated by protoc-gen-gosrcinfo v1.2.3\n"},
+		{name: "version with v prefix", version: "v1.2.3", option: true, expected: "// generated by protoc-gen-gosrcinfo v1.2.3\n"},
+		{name: "option not set", version: "1.2.3"},
+		{name: "no version", option: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			origVersion := Version
+			Version = tc.version
+			t.Cleanup(func() {
+				Version = origVersion
+			})
+			for _, combine := range []bool{false, true} {
+				plugin, err := protogen.Options{}.New(req)
+				require.NoError(t, err)
+				require.NoError(t, genSourceInfo(plugin, options{combine: combine, skipEmpty: true, version: tc.option, mode: "go"}))
+				resp := plugin.Response()
+				require.Empty(t, resp.GetError())
+				require.Len(t, resp.File, 1)
+				content := resp.File[0].GetContent()
+				if tc.expected != "" {
+					assert.Contains(t, content, tc.expected, "combine=%v", combine)
+				} else {
+					assert.NotContains(t, content, "// generated by", "combine=%v", combine)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package sourceinfo
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type contextKey struct{}
+
+// contextSourceInfo is a node in a linked list of source code info that has
+// been attached to a context. The most recently attached is first.
+type contextSourceInfo struct {
+	file    string
+	srcInfo *descriptorpb.SourceCodeInfo
+	next    *contextSourceInfo
+}
+
+// WithSourceInfo returns a context that carries the given source code info for
+// the given file. When queried via SourceInfoFromContext, this takes precedence
+// over any source code info registered for the file. This allows source code
+// info to be provided for a limited scope, such as in tests, without modifying
+// the global registry.
+func WithSourceInfo(ctx context.Context, file string, srcInfo *descriptorpb.SourceCodeInfo) context.Context {
+	next, _ := ctx.Value(contextKey{}).(*contextSourceInfo)
+	return context.WithValue(ctx, contextKey{}, &contextSourceInfo{file: file, srcInfo: srcInfo, next: next})
+}
+
+// SourceInfoFromContext returns the source code info for the given file. If the
+// given context has source code info for the file, attached via WithSourceInfo,
+// it is returned. Otherwise, this falls back to any source code info that was
+// registered for the file. It returns nil if no source code info is found or if
+// the registered source code info cannot be decoded. (Use ForFile to observe
+// such decoding errors.)
+func SourceInfoFromContext(ctx context.Context, file string) *descriptorpb.SourceCodeInfo {
+	for info, _ := ctx.Value(contextKey{}).(*contextSourceInfo); info != nil; info = info.next {
+		if info.file == file {
+			return info.srcInfo
+		}
+	}
+	srcInfo, err := ForFile(file)
+	if err != nil {
+		return nil
+	}
+	return srcInfo
+}
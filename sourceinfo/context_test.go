@@ -0,0 +1,40 @@
+package sourceinfo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/sourceinfo"
+)
+
+func TestSourceInfoFromContext(t *testing.T) {
+	file := testprotos.File_desc_test_comments_proto.Path()
+	registered, err := sourceinfo.ForFile(file)
+	require.NoError(t, err)
+	require.NotNil(t, registered)
+
+	ctx := context.Background()
+	assert.Same(t, registered, sourceinfo.SourceInfoFromContext(ctx, file))
+	assert.Nil(t, sourceinfo.SourceInfoFromContext(ctx, "does/not/exist.proto"))
+
+	override := &descriptorpb.SourceCodeInfo{
+		Location: []*descriptorpb.SourceCodeInfo_Location{
+			{Path: []int32{}, Span: []int32{0, 0, 10}, LeadingComments: proto.String(" Overridden\n")},
+		},
+	}
+	other := &descriptorpb.SourceCodeInfo{}
+	ctx1 := sourceinfo.WithSourceInfo(ctx, file, override)
+	ctx2 := sourceinfo.WithSourceInfo(ctx1, "does/not/exist.proto", other)
+	assert.Same(t, override, sourceinfo.SourceInfoFromContext(ctx2, file))
+	assert.Same(t, other, sourceinfo.SourceInfoFromContext(ctx2, "does/not/exist.proto"))
+	assert.Nil(t, sourceinfo.SourceInfoFromContext(ctx1, "does/not/exist.proto"))
+
+	// global registry is unaffected
+	assert.Same(t, registered, sourceinfo.SourceInfoFromContext(ctx, file))
+}
@@ -0,0 +1,80 @@
+package sourceinfo
+
+import (
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/jhump/protoreflect/v2/sourceloc"
+)
+
+// LocationOf returns the source code location for the given descriptor, from
+// the source code info registered for the descriptor's file. It returns false
+// if no source code info was registered for the file or if the registered
+// source code info has no location for the given descriptor.
+//
+// This can be used with descriptors from any source, not only those returned
+// from Files and Types. The descriptor's path is computed using
+// [sourceloc.PathFor].
+func LocationOf(d protoreflect.Descriptor) (*descriptorpb.SourceCodeInfo_Location, bool) {
+	file := d.ParentFile()
+	if file == nil {
+		return nil, false
+	}
+	srcInfo, err := ForFile(file.Path())
+	if err != nil || srcInfo == nil {
+		return nil, false
+	}
+	path := sourceloc.PathFor(d)
+	if path == nil {
+		return nil, false
+	}
+	for _, loc := range srcInfo.Location {
+		if slices.Equal(path, loc.Path) {
+			return loc, true
+		}
+	}
+	return nil, false
+}
+
+// LeadingComment returns the leading comment for the given descriptor, from the
+// source code info registered for the descriptor's file. It returns the empty
+// string if there is no such comment.
+//
+// Unlike the raw comments in source code info, the returned value does not have
+// a leading space at the start of each line or a trailing newline.
+func LeadingComment(d protoreflect.Descriptor) string {
+	loc, ok := LocationOf(d)
+	if !ok {
+		return ""
+	}
+	return cleanComment(loc.GetLeadingComments())
+}
+
+// TrailingComment returns the trailing comment for the given descriptor, from
+// the source code info registered for the descriptor's file. It returns the
+// empty string if there is no such comment.
+//
+// Like with LeadingComment, the leading space at the start of each line and
+// the trailing newline are removed.
+func TrailingComment(d protoreflect.Descriptor) string {
+	loc, ok := LocationOf(d)
+	if !ok {
+		return ""
+	}
+	return cleanComment(loc.GetTrailingComments())
+}
+
+func cleanComment(comment string) string {
+	comment = strings.TrimSuffix(comment, "\n")
+	if comment == "" {
+		return ""
+	}
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,53 @@
+package sourceinfo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/sourceinfo"
+)
+
+func TestLocationOf(t *testing.T) {
+	md := (&testprotos.TestMessage{}).ProtoReflect().Descriptor()
+	loc, ok := sourceinfo.LocationOf(md)
+	require.True(t, ok)
+	assert.Equal(t, []int32{4, 0}, loc.Path)
+	// spans are zero-based
+	assert.Equal(t, int32(7), loc.Span[0])
+	assert.Equal(t, " Comment for TestMessage\n", loc.GetLeadingComments())
+
+	loc, ok = sourceinfo.LocationOf(md.Fields().ByName("nm"))
+	require.True(t, ok)
+	assert.Equal(t, []int32{4, 0, 2, 0}, loc.Path)
+
+	// file itself
+	loc, ok = sourceinfo.LocationOf(md.ParentFile())
+	require.True(t, ok)
+	assert.Empty(t, loc.Path)
+
+	// no source info registered
+	_, ok = sourceinfo.LocationOf((&emptypb.Empty{}).ProtoReflect().Descriptor())
+	require.False(t, ok)
+}
+
+func TestComments(t *testing.T) {
+	file := testprotos.File_desc_test_comments_proto
+	md := file.Messages().ByName("Request")
+	assert.Equal(t, "We need a request for our RPC service below.", sourceinfo.LeadingComment(md))
+	assert.Equal(t, "A field comment", sourceinfo.LeadingComment(md.Fields().ByName("ids")))
+	assert.Equal(t, "field trailer #1...", sourceinfo.TrailingComment(md.Fields().ByName("ids")))
+
+	sd := file.Services().ByName("RpcService")
+	assert.Equal(t, "Service comment", sourceinfo.LeadingComment(sd))
+	assert.Equal(t, "service trailer\nthat spans multiple lines", sourceinfo.TrailingComment(sd))
+	mtd := sd.Methods().ByName("UnaryRpc")
+	assert.Equal(t, "", sourceinfo.LeadingComment(mtd))
+	assert.Equal(t, "trailer for method", sourceinfo.TrailingComment(mtd))
+
+	// no source info registered
+	assert.Equal(t, "", sourceinfo.LeadingComment((&emptypb.Empty{}).ProtoReflect().Descriptor()))
+}
@@ -0,0 +1,209 @@
+package sourceinfo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// PrintDescriptor writes a text representation of the given descriptor to w,
+// in a syntax that resembles the proto source. Each element is annotated with
+// a "// line:col" comment that indicates where the element is defined in its
+// source file. Line and column numbers are one-based. An element has no such
+// annotation if its location is unknown.
+//
+// Locations are taken from the descriptor's own source locations, if present.
+// Otherwise, they are taken from the source code info registered for the
+// descriptor's file. (See LocationOf.) So this can be used with descriptors
+// from any source, including those for generated types that are compiled into
+// the binary. This is useful when debugging, for example to correlate a field
+// with the revision of a proto source file that defines it.
+//
+// The given descriptor may be a file, message, field, oneof, enum, enum value,
+// service, or method. An error is returned for any other kind of descriptor or
+// if writing to w fails.
+func PrintDescriptor(d protoreflect.Descriptor, w io.Writer) error {
+	p := descPrinter{}
+	switch d := d.(type) {
+	case protoreflect.FileDescriptor:
+		p.printFile(d)
+	case protoreflect.MessageDescriptor:
+		p.printMessage(d, string(d.FullName()), "")
+	case protoreflect.FieldDescriptor:
+		if d.IsExtension() {
+			p.printExtension(d, "")
+		} else {
+			p.printField(d, "")
+		}
+	case protoreflect.OneofDescriptor:
+		p.printOneof(d, "")
+	case protoreflect.EnumDescriptor:
+		p.printEnum(d, string(d.FullName()), "")
+	case protoreflect.EnumValueDescriptor:
+		p.printEnumValue(d, "")
+	case protoreflect.ServiceDescriptor:
+		p.printService(d, string(d.FullName()))
+	case protoreflect.MethodDescriptor:
+		p.printMethod(d, "")
+	default:
+		return fmt.Errorf("cannot print descriptor of type %T", d)
+	}
+	_, err := io.WriteString(w, p.sb.String())
+	return err
+}
+
+type descPrinter struct {
+	sb strings.Builder
+}
+
+// printLine prints the given line, with the given indent, followed by the
+// location of the given descriptor, if known.
+func (p *descPrinter) printLine(d protoreflect.Descriptor, indent, line string) {
+	p.sb.WriteString(indent)
+	p.sb.WriteString(line)
+	if pos := position(d); pos != "" {
+		p.sb.WriteString("  // ")
+		p.sb.WriteString(pos)
+	}
+	p.sb.WriteString("\n")
+}
+
+func (p *descPrinter) printFile(fd protoreflect.FileDescriptor) {
+	p.sb.WriteString("// file: " + fd.Path() + "\n")
+	if pkg := fd.Package(); pkg != "" {
+		p.sb.WriteString("package " + string(pkg) + ";\n")
+	}
+	for i, length := 0, fd.Messages().Len(); i < length; i++ {
+		p.printMessage(fd.Messages().Get(i), string(fd.Messages().Get(i).Name()), "")
+	}
+	for i, length := 0, fd.Enums().Len(); i < length; i++ {
+		p.printEnum(fd.Enums().Get(i), string(fd.Enums().Get(i).Name()), "")
+	}
+	for i, length := 0, fd.Extensions().Len(); i < length; i++ {
+		p.printExtension(fd.Extensions().Get(i), "")
+	}
+	for i, length := 0, fd.Services().Len(); i < length; i++ {
+		p.printService(fd.Services().Get(i), string(fd.Services().Get(i).Name()))
+	}
+}
+
+func (p *descPrinter) printMessage(md protoreflect.MessageDescriptor, name, indent string) {
+	p.printLine(md, indent, "message "+name+" {")
+	nested := indent + "  "
+	fields := md.Fields()
+	for i, length := 0, fields.Len(); i < length; i++ {
+		fld := fields.Get(i)
+		if ood := fld.ContainingOneof(); ood != nil && !ood.IsSynthetic() {
+			if ood.Fields().Get(0) == fld {
+				p.printOneof(ood, nested)
+			}
+			continue
+		}
+		p.printField(fld, nested)
+	}
+	for i, length := 0, md.Messages().Len(); i < length; i++ {
+		child := md.Messages().Get(i)
+		if child.IsMapEntry() {
+			continue
+		}
+		p.printMessage(child, string(child.Name()), nested)
+	}
+	for i, length := 0, md.Enums().Len(); i < length; i++ {
+		p.printEnum(md.Enums().Get(i), string(md.Enums().Get(i).Name()), nested)
+	}
+	for i, length := 0, md.Extensions().Len(); i < length; i++ {
+		p.printExtension(md.Extensions().Get(i), nested)
+	}
+	p.sb.WriteString(indent + "}\n")
+}
+
+func (p *descPrinter) printOneof(ood protoreflect.OneofDescriptor, indent string) {
+	p.printLine(ood, indent, "oneof "+string(ood.Name())+" {")
+	for i, length := 0, ood.Fields().Len(); i < length; i++ {
+		p.printField(ood.Fields().Get(i), indent+"  ")
+	}
+	p.sb.WriteString(indent + "}\n")
+}
+
+func (p *descPrinter) printField(fld protoreflect.FieldDescriptor, indent string) {
+	var label string
+	switch {
+	case fld.IsMap():
+	case fld.IsList():
+		label = "repeated "
+	case fld.Cardinality() == protoreflect.Required:
+		label = "required "
+	case fld.HasOptionalKeyword():
+		label = "optional "
+	}
+	p.printLine(fld, indent, fmt.Sprintf("%s%s %s = %d;", label, fieldTypeName(fld), fld.Name(), fld.Number()))
+}
+
+func (p *descPrinter) printExtension(fld protoreflect.FieldDescriptor, indent string) {
+	p.sb.WriteString(indent + "extend " + string(fld.ContainingMessage().FullName()) + " {\n")
+	p.printField(fld, indent+"  ")
+	p.sb.WriteString(indent + "}\n")
+}
+
+func (p *descPrinter) printEnum(ed protoreflect.EnumDescriptor, name, indent string) {
+	p.printLine(ed, indent, "enum "+name+" {")
+	for i, length := 0, ed.Values().Len(); i < length; i++ {
+		p.printEnumValue(ed.Values().Get(i), indent+"  ")
+	}
+	p.sb.WriteString(indent + "}\n")
+}
+
+func (p *descPrinter) printEnumValue(evd protoreflect.EnumValueDescriptor, indent string) {
+	p.printLine(evd, indent, fmt.Sprintf("%s = %d;", evd.Name(), evd.Number()))
+}
+
+func (p *descPrinter) printService(sd protoreflect.ServiceDescriptor, name string) {
+	p.printLine(sd, "", "service "+name+" {")
+	for i, length := 0, sd.Methods().Len(); i < length; i++ {
+		p.printMethod(sd.Methods().Get(i), "  ")
+	}
+	p.sb.WriteString("}\n")
+}
+
+func (p *descPrinter) printMethod(mtd protoreflect.MethodDescriptor, indent string) {
+	var reqStream, respStream string
+	if mtd.IsStreamingClient() {
+		reqStream = "stream "
+	}
+	if mtd.IsStreamingServer() {
+		respStream = "stream "
+	}
+	p.printLine(mtd, indent, fmt.Sprintf("rpc %s(%s%s) returns (%s%s);",
+		mtd.Name(), reqStream, mtd.Input().FullName(), respStream, mtd.Output().FullName()))
+}
+
+func fieldTypeName(fld protoreflect.FieldDescriptor) string {
+	if fld.IsMap() {
+		return fmt.Sprintf("map<%s, %s>", fieldTypeName(fld.MapKey()), fieldTypeName(fld.MapValue()))
+	}
+	switch fld.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(fld.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(fld.Enum().FullName())
+	default:
+		return fld.Kind().String()
+	}
+}
+
+// position returns the "line:col" position of the given descriptor, or the
+// empty string if its location is not known.
+func position(d protoreflect.Descriptor) string {
+	if fd := d.ParentFile(); fd != nil {
+		if loc := fd.SourceLocations().ByDescriptor(d); loc.Path != nil {
+			return fmt.Sprintf("%d:%d", loc.StartLine+1, loc.StartColumn+1)
+		}
+	}
+	loc, ok := LocationOf(d)
+	if !ok || len(loc.GetSpan()) < 3 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", loc.Span[0]+1, loc.Span[1]+1)
+}
@@ -0,0 +1,86 @@
+package sourceinfo_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jhump/protoreflect/v2/internal/testprotos"
+	"github.com/jhump/protoreflect/v2/sourceinfo"
+)
+
+func TestPrintDescriptor(t *testing.T) {
+	compiler := protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{
+				"test.proto": `syntax = "proto3";
+package test;
+
+message Book {
+  string name = 1;
+  map<string, int64> counts = 2;
+  repeated Kind kinds = 3;
+  optional bool read = 4;
+  oneof format {
+    int32 pages = 5;
+    bool ebook = 6;
+  }
+  enum Kind {
+    KIND_UNSPECIFIED = 0;
+  }
+  message Empty {}
+}
+
+service Library {
+  rpc WatchBooks(Book) returns (stream Book);
+}
+`,
+			}),
+		},
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, sourceinfo.PrintDescriptor(files[0], &buf))
+	assert.Equal(t, `// file: test.proto
+package test;
+message Book {  // 4:1
+  string name = 1;  // 5:3
+  map<string, int64> counts = 2;  // 6:3
+  repeated test.Book.Kind kinds = 3;  // 7:3
+  optional bool read = 4;  // 8:3
+  oneof format {  // 9:3
+    int32 pages = 5;  // 10:5
+    bool ebook = 6;  // 11:5
+  }
+  message Empty {  // 16:3
+  }
+  enum Kind {  // 13:3
+    KIND_UNSPECIFIED = 0;  // 14:5
+  }
+}
+service Library {  // 19:1
+  rpc WatchBooks(test.Book) returns (stream test.Book);  // 20:3
+}
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, sourceinfo.PrintDescriptor(files[0].Messages().Get(0).Fields().ByName("read"), &buf))
+	assert.Equal(t, "optional bool read = 4;  // 8:3\n", buf.String())
+}
+
+func TestPrintDescriptor_RegisteredSourceInfo(t *testing.T) {
+	// Generated descriptors have no source info, so locations come from the
+	// source info registered by protoc-gen-gosrcinfo.
+	md := (&testprotos.Request{}).ProtoReflect().Descriptor()
+	require.Equal(t, 0, md.ParentFile().SourceLocations().Len())
+	var buf bytes.Buffer
+	require.NoError(t, sourceinfo.PrintDescriptor(md, &buf))
+	assert.Contains(t, buf.String(), "message foo.bar.Request {  // 25:1\n")
+}
@@ -50,6 +50,34 @@ func Register(file string, data []byte) {
 	sourceInfoDataByFile[file] = data
 }
 
+// RegisterSourceCodeInfo registers the given source code info for the given
+// file. Unlike Register, the source code info is provided as a message, not in
+// serialized form. This can be used to register source code info that is loaded
+// at runtime, such as from files produced by the protoc-gen-gosrcinfo plugin
+// with the "mode=binary" option. Like Register, it should be called before any
+// source code info for the file is queried.
+func RegisterSourceCodeInfo(file string, srcInfo *descriptorpb.SourceCodeInfo) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(sourceInfoDataByFile, file)
+	sourceInfoByFile[file] = srcInfo
+}
+
+// RegisterSourceInfoFromBytes registers the given source code info for the
+// given file. The given bytes are the serialized (but not gzipped) form of a
+// google.protobuf.SourceCodeInfo message, such as the contents of a file produced
+// by the protoc-gen-gosrcinfo plugin with the "mode=binary" option. An error is
+// returned if the bytes cannot be unmarshalled, in which case nothing is
+// registered.
+func RegisterSourceInfoFromBytes(file string, data []byte) error {
+	var srcInfo descriptorpb.SourceCodeInfo
+	if err := proto.Unmarshal(data, &srcInfo); err != nil {
+		return fmt.Errorf("failed to unmarshal source code info for %q: %w", file, err)
+	}
+	RegisterSourceCodeInfo(file, &srcInfo)
+	return nil
+}
+
 // ForFile queries for any registered source code info for the file
 // descriptor with the given path/name. It returns nil if no source code info
 // was registered.
@@ -9,9 +9,11 @@ import (
 	"github.com/bufbuild/protocompile/protoutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
 
 	_ "github.com/jhump/protoreflect/v2/internal/testprotos"
@@ -29,6 +31,39 @@ func TestRegistry(t *testing.T) {
 	checkFile(t, fdWithout, fd)
 }
 
+func TestRegisterSourceCodeInfo(t *testing.T) {
+	srcInfo := &descriptorpb.SourceCodeInfo{
+		Location: []*descriptorpb.SourceCodeInfo_Location{
+			{Path: []int32{}, Span: []int32{0, 0, 10}, LeadingComments: proto.String(" File comment\n")},
+		},
+	}
+	sourceinfo.RegisterSourceCodeInfo("registered/from/binary.proto", srcInfo)
+	got, err := sourceinfo.ForFile("registered/from/binary.proto")
+	require.NoError(t, err)
+	assert.Same(t, srcInfo, got)
+}
+
+func TestRegisterSourceInfoFromBytes(t *testing.T) {
+	srcInfo := &descriptorpb.SourceCodeInfo{
+		Location: []*descriptorpb.SourceCodeInfo_Location{
+			{Path: []int32{}, Span: []int32{0, 0, 10}, LeadingComments: proto.String(" File comment\n")},
+		},
+	}
+	data, err := proto.Marshal(srcInfo)
+	require.NoError(t, err)
+	err = sourceinfo.RegisterSourceInfoFromBytes("registered/from/bytes.proto", data)
+	require.NoError(t, err)
+	got, err := sourceinfo.ForFile("registered/from/bytes.proto")
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(srcInfo, got))
+
+	err = sourceinfo.RegisterSourceInfoFromBytes("registered/from/bad-bytes.proto", []byte{0xff, 0xff})
+	require.ErrorContains(t, err, `failed to unmarshal source code info for "registered/from/bad-bytes.proto"`)
+	got, err = sourceinfo.ForFile("registered/from/bad-bytes.proto")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
 func TestCanUpgrade(t *testing.T) {
 	fd, err := protoregistry.GlobalFiles.FindFileByPath("desc_test1.proto")
 	require.NoError(t, err)